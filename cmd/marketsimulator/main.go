@@ -1,20 +1,49 @@
 package main
 
 import (
+	"context"
 	"math/rand"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/hongkongkiwi/chaostheory/src/MarketSimulatorClient"
 	"github.com/hongkongkiwi/chaostheory/src/MarketSimulatorConfig"
+	"github.com/hongkongkiwi/chaostheory/src/Tracing"
 )
 
+// Where spans are written when TRACE_EXPORTER=file and TRACE_FILE isn't set
+const traceFile = "./logs/marketsimulator_traces.jsonl"
+
 func main() {
+	// Each tick is traced and sent with a traceparent header when TRACE_EXPORTER is otlp or file
+	shutdownTracing, err := Tracing.SetupFromEnv("MarketSimulator", traceFile)
+	if err != nil {
+		panic(err)
+	}
+	defer shutdownTracing(context.Background())
+
 	config := MarketSimulatorConfig.GenerateDefaultConfig()
 
 	if envVar := os.Getenv("PRICE_API_URL_BASE"); envVar != "" {
 		config.APIURL = envVar
 	}
+	// API keys for providers e.g. PRICE_API_KEYS=ProviderA=<key_id>.<secret>,ProviderB=...
+	if envVar := os.Getenv("PRICE_API_KEYS"); envVar != "" {
+		config.Credentials = make(map[string]string)
+		for _, providerKey := range strings.Split(envVar, ",") {
+			if provider, apiKey, ok := strings.Cut(providerKey, "="); ok {
+				config.Credentials[provider] = apiKey
+			}
+		}
+	}
+	if os.Getenv("PRICE_API_SIGN_REQUESTS") == "true" {
+		config.SignRequests = true
+	}
+	// Send each tick to /prices/batch rather than one request per update
+	if os.Getenv("PRICE_API_BATCH_UPDATES") == "true" {
+		config.BatchUpdates = true
+	}
 
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 
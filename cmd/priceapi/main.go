@@ -1,37 +1,171 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/Metrics"
 	"github.com/hongkongkiwi/chaostheory/src/PriceAPI"
 	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfigAPI"
+	"github.com/hongkongkiwi/chaostheory/src/Tracing"
 )
 
 // Constants
 const (
 	listenAddress = ":8080"
-	serverName    = "PriceAPI"
-	dbFile        = "./data/ProviderDB.sqlite"
+	// gRPC PriceService for low latency publishers and subscribers
+	grpcListenAddress = ":9090"
+	// FIX 4.4 acceptor for providers publishing market data over FIX
+	fixListenAddress = ":9876"
+	fixCompID        = "PRICEAPI"
+	serverName       = "PriceAPI"
+	dbFile           = "./data/ProviderDB.sqlite"
+	// How long to wait for a best price webhook to respond
+	webhookTimeout = 5 * time.Second
+	// How often to check whether another process has changed provider config
+	eligibilityCheckInterval = time.Second
+	// How often to check for expired provider quotes
+	quoteSweepInterval = time.Second
+	// How often provider health is sampled and unhealthy pairs are disabled
+	healthCheckInterval = time.Second
+	// Where spans are written when TRACE_EXPORTER=file and TRACE_FILE isn't set
+	traceFile = "./logs/priceapi_traces.jsonl"
+	// Where best price events are logged as text
+	bestPricesLogFile = "./logs/best_prices.log"
 )
 
 func main() {
+	// Export spans when TRACE_EXPORTER is otlp or file, traceparent headers are always propagated
+	shutdownTracing, err := Tracing.SetupFromEnv(serverName, traceFile)
+	if err != nil {
+		panic(err)
+	}
+	defer shutdownTracing(context.Background())
+
 	// In this case we only need readonly as we don't do any write operations
 	// and a limitation of badgerdb is that we can only have one writer
-	err := ProviderConfig.OpenDB(dbFile)
+	err = ProviderConfig.OpenDB(dbFile)
 	if err != nil {
 		panic(err)
 	}
 	defer ProviderConfig.CloseDB()
 
-	if err := Helpers.CreateDirIfNotExist(filepath.Dir(PriceAPI.PriceUpdatesLogFile)); err != nil {
+	// Serve provider eligibility lookups from memory rather than hitting SQLite on every update
+	if err := ProviderConfig.StartEligibilityCache(eligibilityCheckInterval); err != nil {
+		panic(err)
+	}
+	// Instruments are checked on every update too, and give each pair's display precision
+	if err := ProviderConfig.StartInstrumentCache(eligibilityCheckInterval); err != nil {
+		panic(err)
+	}
+	// Authenticated updates look up their credential, so keep those in memory as well
+	if err := ProviderConfig.StartCredentialCache(eligibilityCheckInterval); err != nil {
+		panic(err)
+	}
+	// Every update is checked against its pair's outlier filter settings
+	if err := ProviderConfig.StartOutlierFilterCache(eligibilityCheckInterval); err != nil {
+		panic(err)
+	}
+	// Rate limits are watched too, the engine swaps in new buckets when they change
+	if err := ProviderConfig.StartRateLimitCache(eligibilityCheckInterval); err != nil {
+		panic(err)
+	}
+
+	if err := Helpers.CreateDirIfNotExist(filepath.Dir(bestPricesLogFile)); err != nil {
+		panic(err)
+	}
+
+	engine := PriceAPI.NewPriceEngine()
+	defer engine.Close()
+
+	// Best price events go to stdout and the log file by default
+	if err := engine.Sinks.Register(PriceAPI.NewStdoutSink()); err != nil {
+		panic(err)
+	}
+	if err := engine.Sinks.Register(PriceAPI.NewTextLogSink(bestPricesLogFile)); err != nil {
+		panic(err)
+	}
+	if envVar := os.Getenv("PRICE_EVENTS_JSONL_FILE"); envVar != "" {
+		if err := Helpers.CreateDirIfNotExist(filepath.Dir(envVar)); err != nil {
+			panic(err)
+		}
+		if err := engine.Sinks.Register(PriceAPI.NewJSONLinesSink(envVar)); err != nil {
+			panic(err)
+		}
+	}
+	if envVar := os.Getenv("PRICE_EVENTS_WEBHOOK_URL"); envVar != "" {
+		if err := engine.Sinks.Register(PriceAPI.NewWebhookSink(envVar, webhookTimeout)); err != nil {
+			panic(err)
+		}
+	}
+
+	// Provider quotes never expire unless a TTL is given e.g. QUOTE_TTL=30s
+	if envVar := os.Getenv("QUOTE_TTL"); envVar != "" {
+		quoteTTL, err := time.ParseDuration(envVar)
+		if err != nil {
+			panic(err)
+		}
+		engine.SetQuoteTTL(quoteTTL)
+	}
+	stopQuoteSweeper := engine.StartQuoteSweeper(quoteSweepInterval)
+	defer stopQuoteSweeper()
+
+	// Score provider health and, when PROVIDER_AUTO_DISABLE=enabled, disable unhealthy pairs
+	// until they recover
+	engine.HealthAutoDisable = os.Getenv("PROVIDER_AUTO_DISABLE") == "enabled"
+	stopHealthMonitor := engine.StartHealthMonitor(healthCheckInterval)
+	defer stopHealthMonitor()
+
+	// Quotes too far from the other providers are quarantined unless the saved settings
+	// say otherwise, OUTLIER_FILTER=enabled or disabled switches the saved default
+	if envVar := os.Getenv("OUTLIER_FILTER"); envVar == "enabled" || envVar == "disabled" {
+		configs, err := ProviderConfig.GetOutlierFilterConfigs()
+		if err != nil {
+			panic(err)
+		}
+		configs.Default.Enabled = envVar == "enabled"
+		if err := engine.SetOutlierFilterConfig(configs.Default); err != nil {
+			panic(err)
+		}
+	}
+
+	// Providers must authenticate their updates with credentials from the ProviderConfigAPI
+	// when PRICE_API_AUTH=enabled
+	engine.ProviderAuthEnabled = os.Getenv("PRICE_API_AUTH") == "enabled"
+
+	// Changing the quote TTLs or outlier filter needs the same admin API key as the
+	// ProviderConfigAPI, the changes are refused until it is set
+	ProviderConfigAPI.AdminAPIKey = os.Getenv("ADMIN_API_KEY")
+
+	// Serve gRPC alongside the HTTP router
+	grpcListener, err := net.Listen("tcp", grpcListenAddress)
+	if err != nil {
+		panic(err)
+	}
+	grpcServer := engine.NewGRPCServer()
+	go func() {
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			fmt.Printf("Failed to start %s gRPC server: %v\n", serverName, err)
+		}
+	}()
+	defer grpcServer.GracefulStop()
+
+	// Accept FIX sessions from providers, their SenderCompID is the provider name
+	fixAcceptor, err := engine.StartFIXAcceptor(fixListenAddress, fixCompID)
+	if err != nil {
 		panic(err)
 	}
+	defer fixAcceptor.Close()
 
-	router := SetupRouter()
+	router := SetupRouter(engine)
 
 	// Start the HTTP server
 	if err := router.Run(listenAddress); err != nil {
@@ -39,22 +173,88 @@ func main() {
 	}
 }
 
-func SetupRouter() *gin.Engine {
+func SetupRouter(engine *PriceAPI.PriceEngine) *gin.Engine {
 	router := gin.New()
 
 	router.Use(
-		gin.LoggerWithWriter(gin.DefaultWriter, "/ping"),
+		gin.LoggerWithWriter(gin.DefaultWriter, "/ping", "/metrics"),
 		gin.Recovery(),
+		Tracing.Middleware,
+		Metrics.Middleware,
 	)
 
 	// Set trusted proxies to fix annoying error
 	router.SetTrustedProxies([]string{"127.0.0.1/8"})
 
 	// POST route to receive price updates
-	router.POST("/prices", PriceAPI.ProcessPriceUpdateRequest)
+	router.POST("/prices", engine.AuthenticateProvider, engine.ProcessPriceUpdateRequest)
+
+	// POST route to receive many price updates at once
+	router.POST("/prices/batch", engine.AuthenticateProvider, engine.ProcessPriceUpdateBatchRequest)
+
+	// GET route to retrieve the best prices for all pairs
+	router.GET("/prices", engine.GetBestPrices)
+
+	// GET route to retrieve the best prices for a specific pair
+	router.GET("/prices/:base/:quote", engine.GetBestPricesForPair)
+
+	// GET route to compare direct and synthetic cross rates for a pair
+	router.GET("/prices/:base/:quote/synthetic", engine.GetCrossRate)
+
+	// GET route to price a specific amount across providers
+	router.GET("/prices/:base/:quote/quote", engine.GetPriceForSize)
+
+	// WebSocket route to stream best price changes
+	router.GET("/stream", engine.StreamBestPrices)
+
+	// GET route to retrieve each provider's last quote and whether it is stale
+	router.GET("/quotes", engine.GetProviderQuotes)
+
+	// GET and PUT routes to view and change how long provider quotes are valid for
+	router.GET("/quotes/ttl", engine.GetQuoteTTLs)
+	router.PUT("/quotes/ttl", ProviderConfigAPI.AuthenticateAdmin, engine.SetQuoteTTLs)
+
+	// GET route to list currently open arbitrage opportunities
+	router.GET("/arbitrage", engine.GetArbitrageOpportunities)
+
+	// GET route to retrieve provider eligibility cache stats
+	router.GET("/eligibility/stats", engine.GetEligibilityCacheStats)
+
+	// GET routes to retrieve duplicate, out of order and gap counters per provider
+	router.GET("/sequence/stats", engine.GetSequenceStats)
+	router.GET("/sequence/stats/:providerName", engine.GetProviderSequenceStats)
+
+	// GET routes to retrieve provider to PriceAPI latency and clock skew per provider
+	router.GET("/latency/stats", engine.GetLatencyStats)
+	router.GET("/latency/stats/:providerName", engine.GetProviderLatencyStats)
+
+	// GET routes to retrieve the ingest queue length and rate limited and queue full
+	// rejections per provider
+	router.GET("/ingest/stats", engine.GetIngestStats)
+	router.GET("/ingest/stats/:providerName", engine.GetProviderIngestStats)
+
+	// GET and PUT routes to view and change the outlier filter bands
+	router.GET("/outliers/config", engine.GetOutlierFilterConfigs)
+	router.PUT("/outliers/config", ProviderConfigAPI.AuthenticateAdmin, engine.SetOutlierFilterConfigs)
+
+	// GET routes to retrieve quarantined quotes and outlier counters per provider
+	router.GET("/outliers/quarantine", engine.GetQuarantinedQuotes)
+	router.GET("/outliers/stats", engine.GetOutlierStats)
+	router.GET("/outliers/stats/:providerName", engine.GetProviderOutlierStats)
+
+	// GET routes to retrieve the health score of every provider's pairs
+	router.GET("/health/scores", engine.GetHealthScores)
+	router.GET("/health/scores/:providerName", engine.GetProviderHealthScores)
+
+	// GET route to retrieve delivery stats for best price sinks
+	router.GET("/sinks", engine.GetSinkStats)
+
+	// PUT route to recalculate best prices. It only re-reads config that is already
+	// saved, and the ProviderConfigAPI calls it after every change, so it stays open
+	router.PUT("/prices/recalculate", engine.ReCalculateBestPrices)
 
-	// PUT route to recalculate best prices
-	router.PUT("/prices/recalculate", PriceAPI.ReCalculateBestPrices)
+	// GET route for Prometheus metrics
+	router.GET("/metrics", Metrics.Handler)
 
 	// Ping route to check server status
 	router.GET("/ping", func(c *gin.Context) {
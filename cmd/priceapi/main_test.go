@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hongkongkiwi/chaostheory/src/PriceAPI"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfigAPI"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigChangesRequireAdmin(t *testing.T) {
+	engine := PriceAPI.NewPriceEngine()
+	defer engine.Close()
+
+	previousKey := ProviderConfigAPI.AdminAPIKey
+	defer func() { ProviderConfigAPI.AdminAPIKey = previousKey }()
+
+	router := SetupRouter(engine)
+	serve := func(method string, path string, body string, adminKey string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		if adminKey != "" {
+			req.Header.Set(ProviderConfigAPI.AdminAPIKeyHeader, adminKey)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Without an admin key configured changes are refused
+	ProviderConfigAPI.AdminAPIKey = ""
+	assert.Equal(t, http.StatusUnauthorized, serve("PUT", "/quotes/ttl", `{"default":"30s"}`, "anything").Code)
+
+	ProviderConfigAPI.AdminAPIKey = "admin-secret"
+	for _, path := range []string{"/quotes/ttl", "/outliers/config"} {
+		assert.Equal(t, http.StatusUnauthorized, serve("PUT", path, "", "").Code, "PUT %s without a key", path)
+		assert.Equal(t, http.StatusUnauthorized, serve("PUT", path, "", "wrong").Code, "PUT %s with the wrong key", path)
+	}
+	assert.Equal(t, http.StatusOK, serve("PUT", "/quotes/ttl", `{"default":"30s"}`, "admin-secret").Code)
+
+	// Reading the settings stays open
+	rr := serve("GET", "/quotes/ttl", "", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"30s"`)
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Metrics"
 	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
 	"github.com/hongkongkiwi/chaostheory/src/ProviderConfigAPI"
+	"github.com/hongkongkiwi/chaostheory/src/Tracing"
 )
 
 // Constants
@@ -14,10 +17,19 @@ const (
 	listenAddress = ":8081"
 	serverName    = "ProviderAPI"
 	dbFile        = "./data/ProviderDB.sqlite"
+	// Where spans are written when TRACE_EXPORTER=file and TRACE_FILE isn't set
+	traceFile = "./logs/providerapi_traces.jsonl"
 )
 
 func main() {
-	err := ProviderConfig.OpenDB(dbFile)
+	// Export spans when TRACE_EXPORTER is otlp or file, traceparent headers are always propagated
+	shutdownTracing, err := Tracing.SetupFromEnv(serverName, traceFile)
+	if err != nil {
+		panic(err)
+	}
+	defer shutdownTracing(context.Background())
+
+	err = ProviderConfig.OpenDB(dbFile)
 	if err != nil {
 		panic(err)
 	}
@@ -27,6 +39,9 @@ func main() {
 		ProviderConfigAPI.PriceAPIURLBase = envVar
 	}
 
+	// Credential management and config changes are refused until an admin API key is set
+	ProviderConfigAPI.AdminAPIKey = os.Getenv("ADMIN_API_KEY")
+
 	// Reshuffle
 	ProviderConfig.RandomizeProviders()
 
@@ -42,8 +57,10 @@ func SetupRouter() *gin.Engine {
 	router := gin.New()
 
 	router.Use(
-		gin.LoggerWithWriter(gin.DefaultWriter, "/ping"),
+		gin.LoggerWithWriter(gin.DefaultWriter, "/ping", "/metrics"),
 		gin.Recovery(),
+		Tracing.Middleware,
+		Metrics.Middleware,
 	)
 
 	// Set trusted proxies to fix annoying error
@@ -52,11 +69,42 @@ func SetupRouter() *gin.Engine {
 	// GET route to retrieve enabled currency pairs for all providers
 	router.GET("/providers", ProviderConfigAPI.GetProviders)
 
-	// PUT route to enable/disable currency pairs for a specific provider
-	router.PUT("/providers/:providerName", ProviderConfigAPI.SetPairsForProvider)
+	// PUT route to enable/disable currency pairs for a specific provider. Like every
+	// route that changes the config it needs the admin API key
+	router.PUT("/providers/:providerName", ProviderConfigAPI.AuthenticateAdmin, ProviderConfigAPI.SetPairsForProvider)
 
 	// GET route to retrieve enabled currency pairs for a specific provider
 	router.GET("/providers/:providerName", ProviderConfigAPI.GetPairsForProvider)
 
+	// Routes to manage the credentials providers authenticate price updates with, these
+	// hand out secrets so they need the admin API key
+	keys := router.Group("/providers/:providerName/keys", ProviderConfigAPI.AuthenticateAdmin)
+	keys.GET("", ProviderConfigAPI.GetCredentials)
+	keys.POST("", ProviderConfigAPI.CreateCredential)
+	keys.POST("/rotate", ProviderConfigAPI.RotateCredentials)
+	keys.DELETE("/:keyID", ProviderConfigAPI.DeleteCredential)
+
+	// Routes to manage how many price updates a second providers can send, across all
+	// of their pairs or for a single pair
+	router.GET("/providers/:providerName/limits", ProviderConfigAPI.GetRateLimits)
+	router.PUT("/providers/:providerName/limits", ProviderConfigAPI.AuthenticateAdmin, ProviderConfigAPI.SetRateLimit)
+	router.DELETE("/providers/:providerName/limits", ProviderConfigAPI.AuthenticateAdmin, ProviderConfigAPI.DeleteRateLimit)
+	router.PUT("/providers/:providerName/limits/:base/:quote", ProviderConfigAPI.AuthenticateAdmin, ProviderConfigAPI.SetRateLimit)
+	router.DELETE("/providers/:providerName/limits/:base/:quote", ProviderConfigAPI.AuthenticateAdmin, ProviderConfigAPI.DeleteRateLimit)
+
+	// Routes to manage the instruments providers can quote
+	router.GET("/instruments", ProviderConfigAPI.GetInstruments)
+	router.GET("/instruments/:base/:quote", ProviderConfigAPI.GetInstrument)
+	router.PUT("/instruments/:base/:quote", ProviderConfigAPI.AuthenticateAdmin, ProviderConfigAPI.SetInstrument)
+	router.DELETE("/instruments/:base/:quote", ProviderConfigAPI.AuthenticateAdmin, ProviderConfigAPI.DeleteInstrument)
+
+	// Routes to manage how far quotes can be from the market before the PriceAPI
+	// quarantines them, for every pair or a single pair
+	router.GET("/outliers/config", ProviderConfigAPI.GetOutlierFilterConfigs)
+	router.PUT("/outliers/config", ProviderConfigAPI.AuthenticateAdmin, ProviderConfigAPI.SetOutlierFilterConfigs)
+
+	// GET route for Prometheus metrics
+	router.GET("/metrics", Metrics.Handler)
+
 	return router
 }
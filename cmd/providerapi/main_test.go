@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfigAPI"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigChangesRequireAdmin(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestConfigChangesRequireAdmin")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	previousKey := ProviderConfigAPI.AdminAPIKey
+	defer func() { ProviderConfigAPI.AdminAPIKey = previousKey }()
+	ProviderConfigAPI.AdminAPIKey = "admin-secret"
+
+	router := SetupRouter()
+	serve := func(method string, path string, body string, adminKey string) int {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		if adminKey != "" {
+			req.Header.Set(ProviderConfigAPI.AdminAPIKeyHeader, adminKey)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	for _, route := range []struct{ method, path string }{
+		{"PUT", "/providers/ProviderA"},
+		{"PUT", "/providers/ProviderA/limits"},
+		{"DELETE", "/providers/ProviderA/limits"},
+		{"PUT", "/providers/ProviderA/limits/BTC/USD"},
+		{"DELETE", "/providers/ProviderA/limits/BTC/USD"},
+		{"PUT", "/instruments/BTC/NZD"},
+		{"DELETE", "/instruments/BTC/NZD"},
+		{"PUT", "/outliers/config"},
+		{"GET", "/providers/ProviderA/keys"},
+	} {
+		assert.Equal(t, http.StatusUnauthorized, serve(route.method, route.path, "", ""), "%s %s without a key", route.method, route.path)
+		assert.Equal(t, http.StatusUnauthorized, serve(route.method, route.path, "", "wrong"), "%s %s with the wrong key", route.method, route.path)
+	}
+	instrument, _ := ProviderConfig.GetInstrument("BTC/NZD")
+	assert.Nil(t, instrument, "Unauthenticated calls shouldn't change the config")
+
+	// Reading the config stays open, changing it works with the key
+	assert.Equal(t, http.StatusOK, serve("GET", "/instruments", "", ""))
+	assert.Equal(t, http.StatusOK, serve("GET", "/outliers/config", "", ""))
+	body := `{"price_precision":2,"tick_size":"0.01","lot_size":"0.0001","active":true}`
+	assert.Equal(t, http.StatusOK, serve("PUT", "/instruments/BTC/NZD", body, "admin-secret"))
+	instrument, _ = ProviderConfig.GetInstrument("BTC/NZD")
+	assert.NotNil(t, instrument)
+}
@@ -0,0 +1,301 @@
+// Package FIX encodes and decodes FIX 4.4 tag=value messages. It only covers what the
+// PriceAPI acceptor needs: the standard header and trailer, plain fields and repeating
+// groups.
+package FIX
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	BeginString44 = "FIX.4.4"
+	// Field delimiter
+	SOH = '\x01'
+	// Format of UTCTimestamp fields such as SendingTime
+	UTCTimestampFormat = "20060102-15:04:05.000"
+	// Largest body we will read, protects against corrupt BodyLength fields
+	MaxBodyLength = 1 << 20
+)
+
+// Tags used by the session and market data messages
+const (
+	TagBeginSeqNo           = 7
+	TagBeginString          = 8
+	TagBodyLength           = 9
+	TagCheckSum             = 10
+	TagEndSeqNo             = 16
+	TagMsgSeqNum            = 34
+	TagMsgType              = 35
+	TagNewSeqNo             = 36
+	TagPossDupFlag          = 43
+	TagRefSeqNum            = 45
+	TagSenderCompID         = 49
+	TagSendingTime          = 52
+	TagSymbol               = 55
+	TagTargetCompID         = 56
+	TagText                 = 58
+	TagEncryptMethod        = 98
+	TagHeartBtInt           = 108
+	TagTestReqID            = 112
+	TagGapFillFlag          = 123
+	TagResetSeqNumFlag      = 141
+	TagMDReqID              = 262
+	TagNoMDEntries          = 268
+	TagMDEntryType          = 269
+	TagMDEntryPx            = 270
+	TagMDEntrySize          = 271
+	TagMDUpdateAction       = 279
+	TagPassword             = 554
+	TagRefMsgType           = 372
+	TagSessionRejectReason  = 373
+	TagBusinessRejectReason = 380
+)
+
+// Message types
+const (
+	MsgTypeHeartbeat                     = "0"
+	MsgTypeTestRequest                   = "1"
+	MsgTypeResendRequest                 = "2"
+	MsgTypeReject                        = "3"
+	MsgTypeSequenceReset                 = "4"
+	MsgTypeLogout                        = "5"
+	MsgTypeLogon                         = "A"
+	MsgTypeMarketDataSnapshotFullRefresh = "W"
+	MsgTypeMarketDataIncrementalRefresh  = "X"
+	MsgTypeBusinessMessageReject         = "j"
+)
+
+// MDEntryType and MDUpdateAction values
+const (
+	MDEntryTypeBid       = "0"
+	MDEntryTypeOffer     = "1"
+	MDUpdateActionNew    = "0"
+	MDUpdateActionChange = "1"
+	MDUpdateActionDelete = "2"
+)
+
+// Field is a single tag=value pair.
+type Field struct {
+	Tag   int
+	Value string
+}
+
+// Message is every field between BodyLength and CheckSum, in order. MsgType is always
+// the first field.
+type Message struct {
+	Fields []Field
+}
+
+// NewMessage returns a message of the given type.
+func NewMessage(msgType string) *Message {
+	return &Message{Fields: []Field{{Tag: TagMsgType, Value: msgType}}}
+}
+
+// Add appends a field and returns the message so calls can be chained.
+func (m *Message) Add(tag int, value string) *Message {
+	m.Fields = append(m.Fields, Field{Tag: tag, Value: value})
+	return m
+}
+
+// Get returns the first value for a tag.
+func (m *Message) Get(tag int) (string, bool) {
+	for _, field := range m.Fields {
+		if field.Tag == tag {
+			return field.Value, true
+		}
+	}
+	return "", false
+}
+
+// GetInt returns the first value for a tag as an integer.
+func (m *Message) GetInt(tag int) (int, error) {
+	value, ok := m.Get(tag)
+	if !ok {
+		return 0, fmt.Errorf("missing tag %d", tag)
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("tag %d is not an integer: %q", tag, value)
+	}
+	return number, nil
+}
+
+// GetBool returns whether a Y/N tag is set to Y.
+func (m *Message) GetBool(tag int) bool {
+	value, _ := m.Get(tag)
+	return value == "Y"
+}
+
+// MsgType returns the message type.
+func (m *Message) MsgType() string {
+	msgType, _ := m.Get(TagMsgType)
+	return msgType
+}
+
+// Groups returns the repeating group counted by countTag. Each group starts with
+// delimiterTag and runs until the next delimiter or the end of the message.
+func (m *Message) Groups(countTag int, delimiterTag int) ([][]Field, error) {
+	start := -1
+	count := 0
+	for i, field := range m.Fields {
+		if field.Tag == countTag {
+			number, err := strconv.Atoi(field.Value)
+			if err != nil {
+				return nil, fmt.Errorf("tag %d is not an integer: %q", countTag, field.Value)
+			}
+			start, count = i+1, number
+			break
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("missing tag %d", countTag)
+	}
+
+	groups := make([][]Field, 0, count)
+	for _, field := range m.Fields[start:] {
+		if field.Tag == delimiterTag {
+			groups = append(groups, make([]Field, 0))
+		} else if len(groups) == 0 {
+			return nil, fmt.Errorf("repeating group %d must start with tag %d", countTag, delimiterTag)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], field)
+	}
+	if len(groups) != count {
+		return nil, fmt.Errorf("tag %d says %d entries but found %d", countTag, count, len(groups))
+	}
+	return groups, nil
+}
+
+// GetGroupField returns the first value for a tag in a repeating group entry.
+func GetGroupField(group []Field, tag int) (string, bool) {
+	for _, field := range group {
+		if field.Tag == tag {
+			return field.Value, true
+		}
+	}
+	return "", false
+}
+
+// Bytes encodes the message with its BeginString, BodyLength and CheckSum.
+func (m *Message) Bytes(beginString string) []byte {
+	var body bytes.Buffer
+	for _, field := range m.Fields {
+		writeField(&body, field.Tag, field.Value)
+	}
+
+	var message bytes.Buffer
+	writeField(&message, TagBeginString, beginString)
+	writeField(&message, TagBodyLength, strconv.Itoa(body.Len()))
+	message.Write(body.Bytes())
+	writeField(&message, TagCheckSum, fmt.Sprintf("%03d", checksum(message.Bytes())))
+	return message.Bytes()
+}
+
+func writeField(buf *bytes.Buffer, tag int, value string) {
+	buf.WriteString(strconv.Itoa(tag))
+	buf.WriteByte('=')
+	buf.WriteString(value)
+	buf.WriteByte(SOH)
+}
+
+func checksum(data []byte) int {
+	sum := 0
+	for _, b := range data {
+		sum += int(b)
+	}
+	return sum % 256
+}
+
+// readField reads the next field, checking it has the expected tag.
+func readField(reader *bufio.Reader, expectedTag int, raw *bytes.Buffer) (string, error) {
+	data, err := reader.ReadBytes(SOH)
+	if err != nil {
+		return "", err
+	}
+	raw.Write(data)
+	tag, value, ok := bytes.Cut(data[:len(data)-1], []byte("="))
+	if !ok || string(tag) != strconv.Itoa(expectedTag) {
+		return "", fmt.Errorf("expected tag %d but got %q", expectedTag, data)
+	}
+	return string(value), nil
+}
+
+// ReadMessage reads the next message, checking its BeginString, BodyLength and CheckSum.
+func ReadMessage(reader *bufio.Reader, beginString string) (*Message, error) {
+	var raw bytes.Buffer
+	value, err := readField(reader, TagBeginString, &raw)
+	if err != nil {
+		return nil, err
+	}
+	if value != beginString {
+		return nil, fmt.Errorf("unsupported BeginString %q", value)
+	}
+	value, err = readField(reader, TagBodyLength, &raw)
+	if err != nil {
+		return nil, err
+	}
+	bodyLength, err := strconv.Atoi(value)
+	if err != nil || bodyLength <= 0 || bodyLength > MaxBodyLength {
+		return nil, fmt.Errorf("invalid BodyLength %q", value)
+	}
+
+	body := make([]byte, bodyLength)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, err
+	}
+	raw.Write(body)
+	expectedChecksum := checksum(raw.Bytes())
+
+	var trailer bytes.Buffer
+	value, err = readField(reader, TagCheckSum, &trailer)
+	if err != nil {
+		return nil, err
+	}
+	if value != fmt.Sprintf("%03d", expectedChecksum) {
+		return nil, fmt.Errorf("CheckSum %s does not match %03d", value, expectedChecksum)
+	}
+
+	message, err := parseBody(body)
+	if err != nil {
+		return nil, err
+	}
+	if message.MsgType() == "" || message.Fields[0].Tag != TagMsgType {
+		return nil, fmt.Errorf("MsgType must be the first field after BodyLength")
+	}
+	return message, nil
+}
+
+func parseBody(body []byte) (*Message, error) {
+	if len(body) == 0 || body[len(body)-1] != SOH {
+		return nil, fmt.Errorf("body must end with SOH")
+	}
+	message := &Message{}
+	for _, data := range bytes.Split(body[:len(body)-1], []byte{SOH}) {
+		tag, value, ok := bytes.Cut(data, []byte("="))
+		if !ok {
+			return nil, fmt.Errorf("malformed field %q", data)
+		}
+		number, err := strconv.Atoi(string(tag))
+		if err != nil || number <= 0 {
+			return nil, fmt.Errorf("malformed tag %q", tag)
+		}
+		message.Fields = append(message.Fields, Field{Tag: number, Value: string(value)})
+	}
+	return message, nil
+}
+
+// FormatUTCTimestamp formats a time for UTCTimestamp fields.
+func FormatUTCTimestamp(t time.Time) string {
+	return t.UTC().Format(UTCTimestampFormat)
+}
+
+// ParseUTCTimestamp parses a UTCTimestamp field, with or without fractional seconds.
+func ParseUTCTimestamp(value string) (time.Time, error) {
+	// Fractional seconds are accepted even though the layout doesn't have them
+	return time.Parse("20060102-15:04:05", value)
+}
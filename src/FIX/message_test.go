@@ -0,0 +1,85 @@
+package FIX
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	message := NewMessage(MsgTypeLogon).
+		Add(TagSenderCompID, "ProviderA").
+		Add(TagTargetCompID, "PRICEAPI").
+		Add(TagMsgSeqNum, "1").
+		Add(TagHeartBtInt, "30")
+	data := message.Bytes(BeginString44)
+
+	assert.True(t, bytes.HasPrefix(data, []byte("8=FIX.4.4\x019=")))
+	assert.Regexp(t, `\x0110=\d{3}\x01$`, string(data))
+
+	decoded, err := ReadMessage(bufio.NewReader(bytes.NewReader(data)), BeginString44)
+	if assert.Nil(t, err) {
+		assert.Equal(t, message.Fields, decoded.Fields)
+		assert.Equal(t, MsgTypeLogon, decoded.MsgType())
+		heartbeat, err := decoded.GetInt(TagHeartBtInt)
+		assert.Nil(t, err)
+		assert.Equal(t, 30, heartbeat)
+	}
+}
+
+func TestReadMessageErrors(t *testing.T) {
+	valid := string(NewMessage(MsgTypeHeartbeat).Add(TagMsgSeqNum, "2").Bytes(BeginString44))
+
+	tests := map[string]string{
+		"bad checksum":    valid[:len(valid)-4] + "999\x01",
+		"wrong version":   strings.Replace(valid, "FIX.4.4", "FIX.4.2", 1),
+		"bad body length": strings.Replace(valid, "9=", "9=x", 1),
+		"truncated":       valid[:len(valid)-8],
+	}
+	for name, data := range tests {
+		_, err := ReadMessage(bufio.NewReader(strings.NewReader(data)), BeginString44)
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestMessageGroups(t *testing.T) {
+	message := NewMessage(MsgTypeMarketDataIncrementalRefresh).
+		Add(TagNoMDEntries, "2").
+		Add(TagMDUpdateAction, MDUpdateActionNew).
+		Add(TagMDEntryType, MDEntryTypeBid).
+		Add(TagMDEntryPx, "1.1").
+		Add(TagMDUpdateAction, MDUpdateActionDelete).
+		Add(TagMDEntryType, MDEntryTypeOffer)
+
+	groups, err := message.Groups(TagNoMDEntries, TagMDUpdateAction)
+	if assert.Nil(t, err) && assert.Len(t, groups, 2) {
+		price, ok := GetGroupField(groups[0], TagMDEntryPx)
+		assert.True(t, ok)
+		assert.Equal(t, "1.1", price)
+		_, ok = GetGroupField(groups[1], TagMDEntryPx)
+		assert.False(t, ok)
+	}
+
+	// The count must match the number of entries
+	message.Fields[1].Value = "3"
+	_, err = message.Groups(TagNoMDEntries, TagMDUpdateAction)
+	assert.NotNil(t, err)
+}
+
+func TestUTCTimestamp(t *testing.T) {
+	timestamp := time.Date(2024, 3, 1, 12, 30, 45, 123000000, time.UTC)
+	formatted := FormatUTCTimestamp(timestamp)
+	assert.Equal(t, "20240301-12:30:45.123", formatted)
+
+	parsed, err := ParseUTCTimestamp(formatted)
+	assert.Nil(t, err)
+	assert.True(t, timestamp.Equal(parsed))
+
+	parsed, err = ParseUTCTimestamp("20240301-12:30:45")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1709296245), parsed.Unix())
+}
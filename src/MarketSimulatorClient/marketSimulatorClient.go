@@ -1,16 +1,22 @@
 package MarketSimulatorClient
 
 import (
+	"context"
+	"encoding/json"
 	"io"
 	"log"
 	"math"
 	"math/rand"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/hongkongkiwi/chaostheory/src/MarketSimulatorConfig"
 	"github.com/hongkongkiwi/chaostheory/src/PriceAPI"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/hongkongkiwi/chaostheory/src/Tracing"
 	"github.com/parnurzeal/gorequest"
+	"github.com/shopspring/decimal"
 )
 
 type PriceHistory struct {
@@ -18,6 +24,8 @@ type PriceHistory struct {
 	AskPrice  float64
 	BidAmount float64
 	AskAmount float64
+	// Sequence number of the last update sent
+	Sequence uint64
 }
 
 var priceHistories = make(map[string]map[string]*PriceHistory)
@@ -28,10 +36,15 @@ func StartSimulation(config *MarketSimulatorConfig.SimulatorConfig) {
 		return
 	}
 
+	// Each tick is its own trace, continued by the PriceAPI
+	ctx, span := Tracing.Start(context.Background(), "MarketSimulator.StartSimulation")
+	defer span.End()
+
 	// Create a new random number generator with a specific seed value
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 
 	// Start simulating provider config updates
+	payloads := make([]*PriceAPI.PriceUpdateRequest, 0)
 	for provider, currencyPairs := range config.Providers {
 		// fmt.Printf("Starting simulation for provider: %s\n", provider)
 		if priceHistories[provider] == nil {
@@ -46,10 +59,13 @@ func StartSimulation(config *MarketSimulatorConfig.SimulatorConfig) {
 			askAmount, bidAmount := generateNewAmountsForPair(provider, currencyPair, r, config)
 
 			// Log the ask and bid prices
-			log.Printf("%s (%s) Ask price: %.2f amount: %.2f, Bid price: %.2f amount: %.2f", provider, currencyPair.String(), ask, askAmount, bid, bidAmount)
+			log.Printf("%s (%s) Ask price: %s amount: %s, Bid price: %s amount: %s", provider, currencyPair.String(), ask, askAmount, bid, bidAmount)
+
+			priceHistory := priceHistories[provider][currencyPair.String()]
+			priceHistory.Sequence++
 
 			// Prepare JSON payload
-			payload := &PriceAPI.PriceUpdateRequest{
+			payloads = append(payloads, &PriceAPI.PriceUpdateRequest{
 				Provider:  provider,
 				Base:      currencyPair[0],
 				Quote:     currencyPair[1],
@@ -57,36 +73,137 @@ func StartSimulation(config *MarketSimulatorConfig.SimulatorConfig) {
 				BidAmount: bidAmount,
 				Ask:       ask,
 				AskAmount: askAmount,
-				Timestamp: time.Now().UnixNano(),
-			}
-
-			// Send POST request to price store API endpoint using gorequest
-			resp, _, errs := gorequest.New().Post(config.APIURL + "/prices").
-				Send(payload).
-				Type("json").
-				End()
-
-			// Check for errors
-			if len(errs) > 0 {
-				for _, err := range errs {
-					log.Printf("Error sending POST request: %v", err)
-				}
-				continue
-			}
-
-			// Check response status code
-			if resp.StatusCode == 200 {
-				log.Printf("Sent update for provider %s and currency pair %s", provider, currencyPair.String())
-			} else {
-				body, _ := io.ReadAll(io.LimitReader(resp.Body, int64(os.Getpagesize())))
-				log.Printf("Unexpected status code: %d. Response body: %s", resp.StatusCode, string(body))
-			}
-			resp.Body.Close()
+				Timestamp: time.Now().UnixMilli(),
+				Sequence:  priceHistory.Sequence,
+			})
+		}
+	}
+
+	if len(payloads) == 0 {
+		return
+	}
+	if config.BatchUpdates && len(config.Credentials) == 0 {
+		// Send the whole tick in one request
+		sendPriceUpdateBatch(ctx, config, "", payloads)
+		return
+	}
+	if config.BatchUpdates {
+		// Each provider authenticates separately so send a batch per provider
+		batches := make(map[string][]*PriceAPI.PriceUpdateRequest)
+		for _, payload := range payloads {
+			batches[payload.Provider] = append(batches[payload.Provider], payload)
+		}
+		for provider, batch := range batches {
+			sendPriceUpdateBatch(ctx, config, provider, batch)
+		}
+		return
+	}
+	for _, payload := range payloads {
+		sendPriceUpdate(ctx, config, payload)
+	}
+}
+
+// newPriceAPIRequest creates a POST request with the given JSON body, authenticated as
+// the provider if the config has a key for it.
+func newPriceAPIRequest(config *MarketSimulatorConfig.SimulatorConfig, path string, provider string, body []byte) *gorequest.SuperAgent {
+	request := gorequest.New().Post(config.APIURL + path).Type("json")
+	// Send the body as is, gorequest would otherwise decode and re-encode it which
+	// changes the bytes the signature was made over
+	request.BounceToRawString = true
+	request.Send(string(body))
+
+	apiKey, ok := config.Credentials[provider]
+	if !ok {
+		return request
+	}
+	if !config.SignRequests {
+		return request.Set(PriceAPI.APIKeyHeader, apiKey)
+	}
+	keyID, secret, err := ProviderConfig.ParseAPIKey(apiKey)
+	if err != nil {
+		log.Printf("Error signing request for provider %s: %v", provider, err)
+		return request
+	}
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	return request.
+		Set(PriceAPI.KeyIDHeader, keyID).
+		Set(PriceAPI.SignatureTimestampHeader, timestamp).
+		Set(PriceAPI.SignatureHeader, ProviderConfig.SignRequest(secret, timestamp, body))
+}
+
+// sendPriceUpdate sends a single price update to the price API.
+func sendPriceUpdate(ctx context.Context, config *MarketSimulatorConfig.SimulatorConfig, payload *PriceAPI.PriceUpdateRequest) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error encoding price update: %v", err)
+		return
+	}
+
+	// Send POST request to price store API endpoint using gorequest
+	request := newPriceAPIRequest(config, "/prices", payload.Provider, body)
+	_, span := Tracing.StartClient(ctx, request)
+	resp, _, errs := request.End()
+	Tracing.EndClient(span, resp, errs)
+
+	// Check for errors
+	if len(errs) > 0 {
+		for _, err := range errs {
+			log.Printf("Error sending POST request: %v", err)
+		}
+		return
+	}
+
+	// Check response status code
+	if resp.StatusCode == 200 {
+		log.Printf("Sent update for provider %s and currency pair %s", payload.Provider, payload.GetPairName())
+	} else {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, int64(os.Getpagesize())))
+		log.Printf("Unexpected status code: %d. Response body: %s", resp.StatusCode, string(body))
+	}
+	resp.Body.Close()
+}
+
+// sendPriceUpdateBatch sends price updates in one request to the price API's batch
+// endpoint, authenticated as the provider if one is given.
+func sendPriceUpdateBatch(ctx context.Context, config *MarketSimulatorConfig.SimulatorConfig, provider string, payloads []*PriceAPI.PriceUpdateRequest) {
+	data, err := json.Marshal(payloads)
+	if err != nil {
+		log.Printf("Error encoding price updates: %v", err)
+		return
+	}
+
+	var response PriceAPI.PriceBatchResponse
+	request := newPriceAPIRequest(config, "/prices/batch", provider, data)
+	_, span := Tracing.StartClient(ctx, request)
+	resp, body, errs := request.EndStruct(&response)
+	Tracing.EndClient(span, resp, errs)
+
+	// Check for errors
+	if resp == nil {
+		for _, err := range errs {
+			log.Printf("Error sending POST request: %v", err)
+		}
+		return
+	}
+
+	if resp.StatusCode != 200 {
+		log.Printf("Unexpected status code: %d. Response body: %s", resp.StatusCode, string(body))
+		return
+	}
+	if len(errs) > 0 {
+		log.Printf("Error reading batch response: %v", errs[0])
+		return
+	}
+
+	log.Printf("Sent batch of %d updates, %d accepted, %d rejected", len(payloads), response.Accepted, response.Rejected)
+	for _, result := range response.Results {
+		if !result.Accepted {
+			log.Printf("Update for provider %s and currency pair %s rejected: %s", result.Provider, result.Pair, result.Error)
 		}
 	}
 }
 
-func generateNewPricesForPair(provider string, currencyPair *MarketSimulatorConfig.CurrencyPair, r *rand.Rand, config *MarketSimulatorConfig.SimulatorConfig) (float64, float64) {
+func generateNewPricesForPair(provider string, currencyPair *MarketSimulatorConfig.CurrencyPair, r *rand.Rand, config *MarketSimulatorConfig.SimulatorConfig) (decimal.Decimal, decimal.Decimal) {
 	// Get the price history for the given provider and currency pair
 	pairName := currencyPair.String()
 	priceHistory := priceHistories[provider][pairName]
@@ -125,10 +242,11 @@ func generateNewPricesForPair(provider string, currencyPair *MarketSimulatorConf
 		newBidPrice = math.Min(newBidPrice, priceHistory.AskPrice-0.01)
 	}
 
-	return math.Round(newAskPrice*100) / 100, math.Round(newBidPrice*100) / 100
+	precision := config.GetPricePrecision(pairName)
+	return decimal.NewFromFloat(newAskPrice).Round(precision), decimal.NewFromFloat(newBidPrice).Round(precision)
 }
 
-func generateNewAmountsForPair(provider string, currencyPair *MarketSimulatorConfig.CurrencyPair, r *rand.Rand, config *MarketSimulatorConfig.SimulatorConfig) (float64, float64) {
+func generateNewAmountsForPair(provider string, currencyPair *MarketSimulatorConfig.CurrencyPair, r *rand.Rand, config *MarketSimulatorConfig.SimulatorConfig) (decimal.Decimal, decimal.Decimal) {
 	// Get the price history for the given provider and currency pair
 	pairName := currencyPair.String()
 	priceHistory := priceHistories[provider][pairName]
@@ -159,5 +277,5 @@ func generateNewAmountsForPair(provider string, currencyPair *MarketSimulatorCon
 		newBidAmount = 0.01 + (r.Float64()*config.QuantityChangeFactor + 0.01)
 	}
 
-	return math.Round(newAskAmount*100) / 100, math.Round(newBidAmount*100) / 100
+	return decimal.NewFromFloat(newAskAmount).Round(config.AmountPrecision), decimal.NewFromFloat(newBidAmount).Round(config.AmountPrecision)
 }
@@ -1,12 +1,16 @@
 package MarketSimulatorClient
 
 import (
+	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/hongkongkiwi/chaostheory/src/Helpers"
 	"github.com/hongkongkiwi/chaostheory/src/MarketSimulatorConfig"
+	"github.com/hongkongkiwi/chaostheory/src/PriceAPI"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -98,3 +102,80 @@ func checkAmountDeviation(t *testing.T, config *MarketSimulatorConfig.SimulatorC
 		}
 	}
 }
+
+func TestStartSimulationBatch(t *testing.T) {
+	// Count the requests and updates the mock API receives
+	requests := make(map[string]int)
+	var received []*PriceAPI.PriceUpdateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(&PriceAPI.PriceBatchResponse{Accepted: len(received)})
+	}))
+	defer server.Close()
+
+	config := &MarketSimulatorConfig.SimulatorConfig{
+		Providers: map[string][]*MarketSimulatorConfig.CurrencyPair{
+			"Provider1": {{"BTC", "USD"}, {"ETH", "USD"}},
+			"Provider2": {{"BTC", "EUR"}},
+		},
+		APIURL:            server.URL,
+		InitialPrice:      100,
+		PriceChangeFactor: 1,
+		PricePrecision:    2,
+		AmountPrecision:   2,
+		BatchUpdates:      true,
+	}
+	priceHistories = make(map[string]map[string]*PriceHistory)
+	StartSimulation(config)
+
+	// The whole tick is sent as one batch
+	assert.Equal(t, map[string]int{"/prices/batch": 1}, requests)
+	assert.Equal(t, 3, len(received))
+}
+
+func TestStartSimulationSigned(t *testing.T) {
+	// The mock API checks each batch is signed by the provider it is for
+	signed := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var received []*PriceAPI.PriceUpdateRequest
+		if err := json.Unmarshal(body, &received); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		secret := map[string]string{"key1": "secret1", "key2": "secret2"}[r.Header.Get(PriceAPI.KeyIDHeader)]
+		timestamp := r.Header.Get(PriceAPI.SignatureTimestampHeader)
+		if r.Header.Get(PriceAPI.SignatureHeader) != ProviderConfig.SignRequest(secret, timestamp, body) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		for _, update := range received {
+			signed[update.Provider]++
+		}
+		json.NewEncoder(w).Encode(&PriceAPI.PriceBatchResponse{Accepted: len(received)})
+	}))
+	defer server.Close()
+
+	config := &MarketSimulatorConfig.SimulatorConfig{
+		Providers: map[string][]*MarketSimulatorConfig.CurrencyPair{
+			"Provider1": {{"BTC", "USD"}, {"ETH", "USD"}},
+			"Provider2": {{"BTC", "EUR"}},
+		},
+		APIURL:            server.URL,
+		InitialPrice:      100,
+		PriceChangeFactor: 1,
+		PricePrecision:    2,
+		AmountPrecision:   2,
+		BatchUpdates:      true,
+		Credentials:       map[string]string{"Provider1": "key1.secret1", "Provider2": "key2.secret2"},
+		SignRequests:      true,
+	}
+	priceHistories = make(map[string]map[string]*PriceHistory)
+	StartSimulation(config)
+
+	assert.Equal(t, map[string]int{"Provider1": 2, "Provider2": 1}, signed)
+}
@@ -37,6 +37,26 @@ type SimulatorConfig struct {
 	PriceChangeFactor    float64 `yaml:"price_change_factor"`
 	QuantityChangeFactor float64 `yaml:"quantity_change_factor"`
 	AllowArbitrage       bool    `yaml:"allow_arbitrage"`
+	// Send each tick as a single batch rather than one request per provider and pair
+	BatchUpdates bool `yaml:"batch_updates"`
+	// API keys ("<key_id>.<secret>") by provider, when set updates are authenticated
+	// with their provider's key and batches only hold one provider's updates
+	Credentials map[string]string `yaml:"credentials"`
+	// Sign requests with an HMAC of the body rather than sending the API key
+	SignRequests bool `yaml:"sign_requests"`
+	// Decimal places prices and amounts are rounded to
+	PricePrecision  int32 `yaml:"price_precision"`
+	AmountPrecision int32 `yaml:"amount_precision"`
+	// Price decimal places for pairs that differ from PricePrecision
+	PairPricePrecisions map[string]int32 `yaml:"pair_price_precisions"`
+}
+
+// GetPricePrecision returns the decimal places prices for a pair are rounded to.
+func (config *SimulatorConfig) GetPricePrecision(pairName string) int32 {
+	if precision, ok := config.PairPricePrecisions[pairName]; ok {
+		return precision
+	}
+	return config.PricePrecision
 }
 
 // generateDefaultConfig generates default configuration data and writes it to a file.
@@ -85,6 +105,9 @@ func GenerateDefaultConfig() *SimulatorConfig {
 		PriceChangeFactor:    10.0,
 		QuantityChangeFactor: 100.0,
 		AllowArbitrage:       false,
+		BatchUpdates:         false,
+		PricePrecision:       2,
+		AmountPrecision:      2,
 	}
 
 	return defaultConfig
@@ -19,4 +19,6 @@ func TestGenerateDefaultConfig(t *testing.T) {
 	// Generate default configuration
 	defaultConfig := GenerateDefaultConfig()
 	assert.NotNil(t, defaultConfig)
+	// Updates are sent one at a time unless batching is turned on
+	assert.False(t, defaultConfig.BatchUpdates)
 }
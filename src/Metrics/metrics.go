@@ -0,0 +1,20 @@
+// Package Metrics holds the Prometheus helpers shared by the PriceAPI and the
+// ProviderConfigAPI. Metrics are registered with the default Prometheus registry.
+package Metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Latency buckets in seconds, from half a millisecond to ten seconds
+var DefaultBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HistogramCount returns how many values were observed for the label values.
+func HistogramCount(histogram *prometheus.HistogramVec, labelValues ...string) uint64 {
+	var metric dto.Metric
+	if err := histogram.WithLabelValues(labelValues...).(prometheus.Metric).Write(&metric); err != nil {
+		return 0
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
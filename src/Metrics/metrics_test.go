@@ -0,0 +1,52 @@
+package Metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramCount(t *testing.T) {
+	histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "test_latency_seconds",
+		Help:    "Latency.",
+		Buckets: []float64{0.1, 1},
+	}, []string{"operation"})
+
+	histogram.WithLabelValues("read").Observe(0.05)
+	histogram.WithLabelValues("read").Observe(5)
+	histogram.WithLabelValues("write").Observe(0.5)
+
+	assert.Equal(t, uint64(2), HistogramCount(histogram, "read"))
+	assert.Equal(t, uint64(1), HistogramCount(histogram, "write"))
+	assert.Equal(t, uint64(0), HistogramCount(histogram, "delete"))
+}
+
+func TestMiddlewareAndHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware)
+	router.GET("/things/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	router.GET("/metrics", Handler)
+
+	before := testutil.ToFloat64(httpRequestsTotal.WithLabelValues("GET", "/things/:id", "204"))
+	for _, path := range []string{"/things/1", "/things/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+	// Requests are labelled by route so both paths count towards the same series
+	assert.Equal(t, before+2, testutil.ToFloat64(httpRequestsTotal.WithLabelValues("GET", "/things/:id", "204")))
+	assert.Less(t, float64(0), testutil.ToFloat64(httpRequestsTotal.WithLabelValues("GET", "unmatched", "404")))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, w.Body.String(), "# TYPE http_requests_total counter\n")
+	assert.Contains(t, w.Body.String(), `http_request_duration_seconds_bucket{method="GET",route="/things/:id",le="+Inf"}`)
+}
@@ -0,0 +1,245 @@
+package PriceAPI
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+type ArbitrageType = string
+
+const (
+	// One provider's bid is above another provider's ask on the same pair
+	CrossedMarketArbitrage ArbitrageType = "CrossedMarket"
+	// Converting through three currencies returns more than we started with
+	TriangularArbitrage ArbitrageType = "Triangular"
+)
+
+// Ignore loops that only profit through rounding when inverting rates
+var arbitrageEpsilon = decimal.New(1, -9)
+
+var basisPoints = decimal.NewFromInt(10000)
+
+// ArbitrageLeg is a single trade needed to capture an arbitrage opportunity.
+// Amount is in the pair's base currency.
+type ArbitrageLeg struct {
+	Pair     string          `json:"pair"`
+	Side     string          `json:"side"` // buy or sell
+	Provider string          `json:"provider"`
+	Price    decimal.Decimal `json:"price"`
+	Amount   decimal.Decimal `json:"amount"`
+}
+
+// ArbitrageOpportunity is a crossed market or triangular loop found in the consolidated books.
+type ArbitrageOpportunity struct {
+	ID   string        `json:"id"`
+	Type ArbitrageType `json:"type"`
+	// Set for crossed markets
+	Pair string `json:"pair,omitempty"`
+	// Set for triangular loops, in trading order starting with the profit currency
+	Currencies        []string        `json:"currencies,omitempty"`
+	Pairs             []string        `json:"pairs"`
+	Legs              []*ArbitrageLeg `json:"legs"`
+	ProfitCurrency    string          `json:"profit_currency"`
+	TheoreticalProfit decimal.Decimal `json:"theoretical_profit"`
+	ReturnBps         decimal.Decimal `json:"return_bps"`
+	DetectedAt        time.Time       `json:"detected_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+func crossedMarketID(pairName string) string {
+	return fmt.Sprintf("%s:%s", CrossedMarketArbitrage, pairName)
+}
+
+// canonicalCycle rotates a loop of currencies so the same loop always starts with the
+// same currency, no matter which pair change found it.
+func canonicalCycle(cycle []string) []string {
+	start := 0
+	for i := range cycle {
+		if cycle[i] < cycle[start] {
+			start = i
+		}
+	}
+	rotated := make([]string, 0, len(cycle))
+	for i := range cycle {
+		rotated = append(rotated, cycle[(start+i)%len(cycle)])
+	}
+	return rotated
+}
+
+func triangularID(cycle []string) string {
+	return fmt.Sprintf("%s:%s>%s", TriangularArbitrage, strings.Join(cycle, ">"), cycle[0])
+}
+
+// findCrossedMarket returns an opportunity if the best bid for a pair is above the best ask.
+func findCrossedMarket(bestPrices map[string]*BestPrice, pairName string) *ArbitrageOpportunity {
+	bestPrice := bestPrices[pairName]
+	if bestPrice == nil || bestPrice.Bid == nil || bestPrice.Ask == nil || bestPrice.Bid.Price.LessThanOrEqual(bestPrice.Ask.Price) {
+		return nil
+	}
+	bid, ask := bestPrice.Bid, bestPrice.Ask
+	amount := decimal.Min(bid.Amount, ask.Amount)
+	return &ArbitrageOpportunity{
+		ID:    crossedMarketID(pairName),
+		Type:  CrossedMarketArbitrage,
+		Pair:  pairName,
+		Pairs: []string{pairName},
+		Legs: []*ArbitrageLeg{
+			{Pair: pairName, Side: SideBuy, Provider: ask.Provider, Price: ask.Price, Amount: amount},
+			{Pair: pairName, Side: SideSell, Provider: bid.Provider, Price: bid.Price, Amount: amount},
+		},
+		ProfitCurrency:    bestPrice.Quote,
+		TheoreticalProfit: bid.Price.Sub(ask.Price).Mul(amount),
+		ReturnBps:         bid.Price.Sub(ask.Price).Div(ask.Price).Mul(basisPoints),
+	}
+}
+
+// findTriangularArbitrage returns an opportunity if converting through the loop of
+// currencies and back to the first one returns more than we started with.
+func findTriangularArbitrage(bestPrices map[string]*BestPrice, cycle []string) *ArbitrageOpportunity {
+	rates := make([]*legRate, 0, len(cycle))
+	// How much of each leg's from currency one unit of the start currency becomes
+	ratesBefore := make([]decimal.Decimal, 0, len(cycle))
+	rate := decimal.NewFromInt(1)
+	var maxStartAmount decimal.Decimal
+	for i := range cycle {
+		legRate := getLegRate(bestPrices, cycle[i], cycle[(i+1)%len(cycle)])
+		if legRate == nil || !legRate.bid.IsPositive() {
+			return nil
+		}
+		legMaxAmount := legRate.bidAmount.Div(rate)
+		if i == 0 || legMaxAmount.LessThan(maxStartAmount) {
+			maxStartAmount = legMaxAmount
+		}
+		rates = append(rates, legRate)
+		ratesBefore = append(ratesBefore, rate)
+		rate = rate.Mul(legRate.bid)
+	}
+	gain := rate.Sub(decimal.NewFromInt(1))
+	if gain.LessThanOrEqual(arbitrageEpsilon) {
+		return nil
+	}
+
+	opportunity := &ArbitrageOpportunity{
+		ID:                triangularID(cycle),
+		Type:              TriangularArbitrage,
+		Currencies:        cycle,
+		Pairs:             make([]string, 0, len(cycle)),
+		Legs:              make([]*ArbitrageLeg, 0, len(cycle)),
+		ProfitCurrency:    cycle[0],
+		TheoreticalProfit: maxStartAmount.Mul(gain),
+		ReturnBps:         gain.Mul(basisPoints),
+	}
+	for i, legRate := range rates {
+		fromAmount := maxStartAmount.Mul(ratesBefore[i])
+		leg := &ArbitrageLeg{Pair: legRate.leg.Pair}
+		if legRate.leg.Inverted {
+			// Buy the base of the quoted pair with our from currency
+			leg.Side, leg.Provider, leg.Price = SideBuy, legRate.leg.Ask.Provider, legRate.leg.Ask.Price
+			leg.Amount = fromAmount.Mul(legRate.bid)
+		} else {
+			leg.Side, leg.Provider, leg.Price = SideSell, legRate.leg.Bid.Provider, legRate.leg.Bid.Price
+			leg.Amount = fromAmount
+		}
+		opportunity.Pairs = append(opportunity.Pairs, legRate.leg.Pair)
+		opportunity.Legs = append(opportunity.Legs, leg)
+	}
+	return opportunity
+}
+
+// detectArbitrage re-checks every crossed market and triangular loop involving a pair
+// after its best price changes, publishing an event when an opportunity opens or closes.
+// Shards recalculate pairs concurrently, so detection holds arbitrageMu throughout and
+// reads the best prices once it has it. Otherwise a shard working from prices read
+// before another shard's change could close an opportunity that shard just opened.
+func (engine *PriceEngine) detectArbitrage(ctx context.Context, pairName string) {
+	engine.arbitrageMu.Lock()
+	defer engine.arbitrageMu.Unlock()
+	bestPrices := engine.getBestPrices()
+	base, quote, _ := strings.Cut(pairName, "/")
+
+	// nil means the opportunity was checked and isn't there
+	candidates := map[string]*ArbitrageOpportunity{
+		crossedMarketID(pairName): findCrossedMarket(bestPrices, pairName),
+	}
+	checkCycle := func(cycle []string) {
+		cycle = canonicalCycle(cycle)
+		candidates[triangularID(cycle)] = findTriangularArbitrage(bestPrices, cycle)
+	}
+
+	currencies := make(map[string]bool)
+	for _, bestPrice := range bestPrices {
+		currencies[bestPrice.Base] = true
+		currencies[bestPrice.Quote] = true
+	}
+	for currency := range currencies {
+		if currency == base || currency == quote {
+			continue
+		}
+		// Both directions around the loop
+		checkCycle([]string{base, quote, currency})
+		checkCycle([]string{base, currency, quote})
+	}
+
+	// Open loops through currencies that have since disappeared still need closing
+	for id, opportunity := range engine.openArbitrage {
+		if _, checked := candidates[id]; checked || opportunity.Type != TriangularArbitrage {
+			continue
+		}
+		for _, oppPair := range opportunity.Pairs {
+			if oppPair == pairName {
+				candidates[id] = findTriangularArbitrage(bestPrices, opportunity.Currencies)
+				break
+			}
+		}
+	}
+
+	// Publishing never blocks so events are sent under the lock, in the order the
+	// opportunities changed
+	now := engine.now()
+	for id, opportunity := range candidates {
+		existing := engine.openArbitrage[id]
+		switch {
+		case opportunity != nil && existing != nil:
+			opportunity.DetectedAt = existing.DetectedAt
+			opportunity.UpdatedAt = now
+			engine.openArbitrage[id] = opportunity
+		case opportunity != nil:
+			opportunity.DetectedAt = now
+			opportunity.UpdatedAt = now
+			engine.openArbitrage[id] = opportunity
+			engine.emitPriceEvent(ctx, NewArbitrageEvent(ArbitrageOpenedEventType, opportunity, now))
+		case existing != nil:
+			delete(engine.openArbitrage, id)
+			engine.emitPriceEvent(ctx, NewArbitrageEvent(ArbitrageClosedEventType, existing, now))
+		}
+	}
+}
+
+// getOpenArbitrage returns every open opportunity, most profitable (by return) first.
+func (engine *PriceEngine) getOpenArbitrage() []*ArbitrageOpportunity {
+	engine.arbitrageMu.RLock()
+	defer engine.arbitrageMu.RUnlock()
+	opportunities := make([]*ArbitrageOpportunity, 0, len(engine.openArbitrage))
+	for _, opportunity := range engine.openArbitrage {
+		opportunities = append(opportunities, opportunity)
+	}
+	sort.Slice(opportunities, func(i, j int) bool {
+		if !opportunities[i].ReturnBps.Equal(opportunities[j].ReturnBps) {
+			return opportunities[i].ReturnBps.GreaterThan(opportunities[j].ReturnBps)
+		}
+		return opportunities[i].ID < opportunities[j].ID
+	})
+	return opportunities
+}
+
+// GetArbitrageOpportunities returns every currently open crossed market and triangular arbitrage.
+func (engine *PriceEngine) GetArbitrageOpportunities(c *gin.Context) {
+	c.JSON(http.StatusOK, engine.getOpenArbitrage())
+}
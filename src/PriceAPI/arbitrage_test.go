@@ -0,0 +1,158 @@
+package PriceAPI
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindTriangularArbitrage(t *testing.T) {
+	bestPrices := map[string]*BestPrice{
+		"AAA/BBB": NewBestPrice("AAA", "BBB",
+			&PriceUpdate{Provider: "ProviderA", Base: "AAA", Quote: "BBB", Price: decimal.NewFromInt(2), Amount: decimal.NewFromInt(10)},
+			&PriceUpdate{Provider: "ProviderA", Base: "AAA", Quote: "BBB", Price: decimal.RequireFromString("2.01"), Amount: decimal.NewFromInt(10)}),
+		"BBB/CCC": NewBestPrice("BBB", "CCC",
+			&PriceUpdate{Provider: "ProviderB", Base: "BBB", Quote: "CCC", Price: decimal.NewFromInt(3), Amount: decimal.NewFromInt(100)},
+			&PriceUpdate{Provider: "ProviderB", Base: "BBB", Quote: "CCC", Price: decimal.RequireFromString("3.01"), Amount: decimal.NewFromInt(100)}),
+		"AAA/CCC": NewBestPrice("AAA", "CCC",
+			&PriceUpdate{Provider: "ProviderC", Base: "AAA", Quote: "CCC", Price: decimal.RequireFromString("5.8"), Amount: decimal.NewFromInt(100)},
+			&PriceUpdate{Provider: "ProviderC", Base: "AAA", Quote: "CCC", Price: decimal.RequireFromString("5.9"), Amount: decimal.NewFromInt(100)}),
+	}
+
+	// AAA -> BBB -> CCC -> AAA turns 1 AAA into 2 * 3 / 5.9 AAA
+	opportunity := findTriangularArbitrage(bestPrices, []string{"AAA", "BBB", "CCC"})
+	if assert.NotNil(t, opportunity) {
+		assert.Equal(t, "Triangular:AAA>BBB>CCC>AAA", opportunity.ID)
+		assert.Equal(t, "AAA", opportunity.ProfitCurrency)
+		assert.InDelta(t, (6/5.9-1)*10000, opportunity.ReturnBps.InexactFloat64(), 0.000001)
+		// Limited by the 10 AAA available on the first leg
+		assert.InDelta(t, 10*(6/5.9-1), opportunity.TheoreticalProfit.InexactFloat64(), 0.000001)
+		assert.Equal(t, SideSell, opportunity.Legs[0].Side)
+		assert.Equal(t, SideSell, opportunity.Legs[1].Side)
+		// The last leg buys AAA on the AAA/CCC ask
+		assert.Equal(t, SideBuy, opportunity.Legs[2].Side)
+		assert.Equal(t, "ProviderC", opportunity.Legs[2].Provider)
+		assert.InDelta(t, 60/5.9, opportunity.Legs[2].Amount.InexactFloat64(), 0.000001)
+	}
+
+	// The other direction loses money
+	assert.Nil(t, findTriangularArbitrage(bestPrices, []string{"AAA", "CCC", "BBB"}))
+}
+
+func TestDetectCrossedMarket(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	sink := NewChannelSink("TestDetectCrossedMarket", 100)
+	engine.Sinks.Register(sink)
+
+	waitForEvent := func(eventType PriceEventType) *PriceEvent {
+		timeout := time.After(time.Second)
+		for {
+			select {
+			case event := <-sink.Events():
+				if event.Type == eventType && event.Pair == "DDD/EEE" {
+					return event
+				}
+			case <-timeout:
+				t.Fatalf("Timed out waiting for %s event", eventType)
+				return nil
+			}
+		}
+	}
+
+	// ProviderA bids above ProviderB's offer
+	engine.setBestBidPrice("DDD/EEE", &PriceUpdate{Provider: "ProviderA", Base: "DDD", Quote: "EEE", Price: decimal.NewFromInt(101), Amount: decimal.NewFromInt(2)})
+	engine.setBestAskPrice("DDD/EEE", &PriceUpdate{Provider: "ProviderB", Base: "DDD", Quote: "EEE", Price: decimal.NewFromInt(100), Amount: decimal.NewFromInt(5)})
+	engine.detectArbitrage(context.Background(), "DDD/EEE")
+
+	event := waitForEvent(ArbitrageOpenedEventType)
+	assert.Equal(t, CrossedMarketArbitrage, event.Arbitrage.Type)
+	assert.Equal(t, "2", event.Arbitrage.TheoreticalProfit.String())
+	assert.Equal(t, "ProviderB", event.Arbitrage.Legs[0].Provider)
+	assert.Equal(t, SideBuy, event.Arbitrage.Legs[0].Side)
+
+	found := false
+	for _, opportunity := range engine.getOpenArbitrage() {
+		found = found || opportunity.ID == "CrossedMarket:DDD/EEE"
+	}
+	assert.True(t, found)
+
+	// Once the market uncrosses the opportunity is closed
+	engine.setBestBidPrice("DDD/EEE", &PriceUpdate{Provider: "ProviderA", Base: "DDD", Quote: "EEE", Price: decimal.NewFromInt(99), Amount: decimal.NewFromInt(2)})
+	engine.detectArbitrage(context.Background(), "DDD/EEE")
+
+	event = waitForEvent(ArbitrageClosedEventType)
+	assert.Equal(t, "CrossedMarket:DDD/EEE", event.Arbitrage.ID)
+	for _, opportunity := range engine.getOpenArbitrage() {
+		assert.NotEqual(t, "CrossedMarket:DDD/EEE", opportunity.ID)
+	}
+}
+
+func TestConcurrentArbitrageDetection(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	sink := NewChannelSink("TestConcurrentArbitrageDetection", 1000)
+	engine.Sinks.Register(sink)
+
+	// A clock that yields lets the other legs' goroutines run while a detection works
+	engine.Clock = func() time.Time {
+		runtime.Gosched()
+		return time.Now()
+	}
+
+	// The same loop as TestFindTriangularArbitrage, each leg is recalculated on its own
+	// goroutine as it would be by different ingest shards
+	const rounds = 100
+	for round := 0; round < rounds; round++ {
+		a, b, c := fmt.Sprintf("RA%d", round), fmt.Sprintf("RB%d", round), fmt.Sprintf("RC%d", round)
+		legs := []struct {
+			base, quote, bid, ask, amount string
+		}{
+			{a, b, "2", "2.01", "10"},
+			{b, c, "3", "3.01", "100"},
+			{a, c, "5.8", "5.9", "100"},
+		}
+		var wg sync.WaitGroup
+		for _, leg := range legs {
+			wg.Add(1)
+			go func(base, quote, bid, ask, amount string) {
+				defer wg.Done()
+				pairName := base + "/" + quote
+				engine.setBestBidPrice(pairName, &PriceUpdate{Provider: "ProviderA", Base: base, Quote: quote, Price: decimal.RequireFromString(bid), Amount: decimal.RequireFromString(amount)})
+				engine.setBestAskPrice(pairName, &PriceUpdate{Provider: "ProviderA", Base: base, Quote: quote, Price: decimal.RequireFromString(ask), Amount: decimal.RequireFromString(amount)})
+				engine.detectArbitrage(context.Background(), pairName)
+			}(leg.base, leg.quote, leg.bid, leg.ask, leg.amount)
+		}
+		wg.Wait()
+	}
+
+	opened := make(map[string]int)
+	closed := make(map[string]int)
+	for done := false; !done; {
+		select {
+		case event := <-sink.Events():
+			switch event.Type {
+			case ArbitrageOpenedEventType:
+				opened[event.Arbitrage.ID]++
+			case ArbitrageClosedEventType:
+				closed[event.Arbitrage.ID]++
+			}
+		case <-time.After(100 * time.Millisecond):
+			done = true
+		}
+	}
+	for round := 0; round < rounds; round++ {
+		id := triangularID([]string{fmt.Sprintf("RA%d", round), fmt.Sprintf("RB%d", round), fmt.Sprintf("RC%d", round)})
+		assert.Equal(t, 1, opened[id], "opened events for %s", id)
+		assert.Equal(t, 0, closed[id], "closed events for %s", id)
+	}
+	assert.Len(t, engine.getOpenArbitrage(), rounds)
+}
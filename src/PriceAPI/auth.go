@@ -0,0 +1,182 @@
+package PriceAPI
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Headers used to authenticate price updates, either an API key or an HMAC-SHA256
+// signature of the timestamp and body made with the key's secret
+const (
+	APIKeyHeader             = "X-API-Key"
+	KeyIDHeader              = "X-Key-ID"
+	SignatureHeader          = "X-Signature"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+	// gRPC metadata keys are lower case
+	apiKeyMetadata = "x-api-key"
+	// Gin context key holding the authenticated provider
+	authenticatedProviderKey = "authenticatedProvider"
+)
+
+// How old or far in the future a signature's timestamp can be, signatures are
+// remembered for this long so they can't be replayed
+var SignatureReplayWindow = 30 * time.Second
+
+// AuthenticateProvider is middleware that checks a request's API key or signature and
+// records which provider sent it. Handlers then check updates are for that provider.
+func (engine *PriceEngine) AuthenticateProvider(c *gin.Context) {
+	if !engine.ProviderAuthEnabled {
+		c.Next()
+		return
+	}
+	providerName, status, err := engine.authenticateRequest(c.Request)
+	if err != nil {
+		c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.Set(authenticatedProviderKey, providerName)
+	c.Next()
+}
+
+// authenticateRequest returns the provider a request is from, or the status to reply with.
+func (engine *PriceEngine) authenticateRequest(r *http.Request) (string, int, error) {
+	if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" {
+		return engine.authenticateAPIKey(apiKey)
+	}
+
+	keyID := r.Header.Get(KeyIDHeader)
+	signature := r.Header.Get(SignatureHeader)
+	timestamp := r.Header.Get(SignatureTimestampHeader)
+	if keyID == "" || signature == "" || timestamp == "" {
+		return "", http.StatusUnauthorized, fmt.Errorf("missing %s header or %s, %s and %s headers", APIKeyHeader, KeyIDHeader, SignatureHeader, SignatureTimestampHeader)
+	}
+
+	// Check the timestamp first so stale requests never touch the database
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", http.StatusUnauthorized, fmt.Errorf("%s must be Unix milliseconds", SignatureTimestampHeader)
+	}
+	now := engine.now()
+	if age := now.Sub(time.UnixMilli(signedAt)); age > SignatureReplayWindow || age < -SignatureReplayWindow {
+		return "", http.StatusUnauthorized, fmt.Errorf("signature timestamp is outside the %s replay window", SignatureReplayWindow)
+	}
+
+	credential, status, err := engine.getValidCredential(keyID)
+	if err != nil {
+		return "", status, err
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", http.StatusBadRequest, err
+	}
+	// Put the body back for the handler
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	expected := ProviderConfig.SignRequest(credential.Secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return "", http.StatusUnauthorized, fmt.Errorf("invalid signature")
+	}
+	if !engine.rememberSignature(signature, now) {
+		return "", http.StatusUnauthorized, fmt.Errorf("signature has already been used")
+	}
+	return credential.Provider, http.StatusOK, nil
+}
+
+func (engine *PriceEngine) authenticateAPIKey(apiKey string) (string, int, error) {
+	keyID, secret, err := ProviderConfig.ParseAPIKey(apiKey)
+	if err != nil {
+		return "", http.StatusUnauthorized, err
+	}
+	credential, status, err := engine.getValidCredential(keyID)
+	if err != nil {
+		return "", status, err
+	}
+	if !credential.VerifySecret(secret) {
+		return "", http.StatusUnauthorized, fmt.Errorf("invalid API key")
+	}
+	return credential.Provider, http.StatusOK, nil
+}
+
+// getValidCredential looks up a credential that hasn't expired.
+func (engine *PriceEngine) getValidCredential(keyID string) (*ProviderConfig.Credential, int, error) {
+	credential, err := ProviderConfig.GetCredential(keyID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	// Unknown and expired keys get the same error so key IDs can't be probed
+	if credential == nil || credential.IsExpired(engine.now()) {
+		return nil, http.StatusUnauthorized, fmt.Errorf("unknown or expired key %s", keyID)
+	}
+	return credential, http.StatusOK, nil
+}
+
+// rememberSignature returns false if the signature has been seen within the replay window.
+func (engine *PriceEngine) rememberSignature(signature string, now time.Time) bool {
+	engine.signaturesMu.Lock()
+	defer engine.signaturesMu.Unlock()
+	if now.After(engine.nextPrune) {
+		for seen, expiresAt := range engine.seenSignatures {
+			if now.After(expiresAt) {
+				delete(engine.seenSignatures, seen)
+			}
+		}
+		engine.nextPrune = now.Add(SignatureReplayWindow)
+	}
+	if expiresAt, ok := engine.seenSignatures[signature]; ok && !now.After(expiresAt) {
+		return false
+	}
+	// Long enough to cover a timestamp at the far end of the window
+	engine.seenSignatures[signature] = now.Add(2 * SignatureReplayWindow)
+	return true
+}
+
+// checkAuthenticatedProvider returns an error if the request was authenticated as a
+// different provider to the one the update is for.
+func checkAuthenticatedProvider(c *gin.Context, update *PriceUpdateRequest) error {
+	providerName, ok := c.Get(authenticatedProviderKey)
+	if !ok {
+		return nil
+	}
+	return checkProviderIdentity(providerName.(string), update)
+}
+
+func checkProviderIdentity(providerName string, update *PriceUpdateRequest) error {
+	if providerName != "" && providerName != update.Provider {
+		// Counted against the provider that authenticated, not the one it claimed to be
+		recordUpdateRejected(providerName, RejectForbidden)
+		return fmt.Errorf("authenticated as %s but the update is for %s", providerName, update.Provider)
+	}
+	return nil
+}
+
+// authenticateGRPC returns the provider for the API key in a call's metadata, or an
+// empty name if authentication is turned off.
+func (engine *PriceEngine) authenticateGRPC(ctx context.Context) (string, error) {
+	if !engine.ProviderAuthEnabled {
+		return "", nil
+	}
+	apiKeys := metadata.ValueFromIncomingContext(ctx, apiKeyMetadata)
+	if len(apiKeys) == 0 {
+		return "", status.Errorf(codes.Unauthenticated, "missing %s metadata", apiKeyMetadata)
+	}
+	providerName, httpStatus, err := engine.authenticateAPIKey(apiKeys[0])
+	if err != nil {
+		if httpStatus == http.StatusInternalServerError {
+			return "", status.Error(codes.Internal, err.Error())
+		}
+		return "", status.Error(codes.Unauthenticated, err.Error())
+	}
+	return providerName, nil
+}
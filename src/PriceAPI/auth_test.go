@@ -0,0 +1,146 @@
+package PriceAPI
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/PriceProto"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestProviderAuthentication(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestProviderAuthentication")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("AVAX", "TRY"))
+	ProviderConfig.SetPairEnabled("AuthProviderA", "AVAX/TRY", true)
+	ProviderConfig.SetPairEnabled("AuthProviderB", "AVAX/TRY", true)
+
+	engine.ProviderAuthEnabled = true
+
+	credentialA, err := ProviderConfig.CreateCredential("AuthProviderA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialB, err := ProviderConfig.CreateCredential("AuthProviderB")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.Default()
+	router.POST("/prices", engine.AuthenticateProvider, engine.ProcessPriceUpdateRequest)
+	router.POST("/prices/batch", engine.AuthenticateProvider, engine.ProcessPriceUpdateBatchRequest)
+
+	// Each update is a millisecond newer than the last so none are dropped as out of order
+	timestamp := time.Now().UnixMilli()
+	newUpdate := func(provider string) []byte {
+		timestamp++
+		return []byte(`{"provider":"` + provider + `","base":"AVAX","quote":"TRY","bid":"1000","bid_amount":"1","timestamp":` + strconv.FormatInt(timestamp, 10) + `}`)
+	}
+	send := func(path string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(body))
+		for header, value := range headers {
+			req.Header.Set(header, value)
+		}
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	sign := func(credential *ProviderConfig.Credential, signedAt time.Time, body []byte) map[string]string {
+		signatureTimestamp := strconv.FormatInt(signedAt.UnixMilli(), 10)
+		return map[string]string{
+			KeyIDHeader:              credential.KeyID,
+			SignatureTimestampHeader: signatureTimestamp,
+			SignatureHeader:          ProviderConfig.SignRequest(credential.Secret, signatureTimestamp, body),
+		}
+	}
+
+	// API keys
+	rr := send("/prices", newUpdate("AuthProviderA"), nil)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = send("/prices", newUpdate("AuthProviderA"), map[string]string{APIKeyHeader: credentialA.APIKey()})
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = send("/prices", newUpdate("AuthProviderA"), map[string]string{APIKeyHeader: credentialA.KeyID + ".wrong"})
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = send("/prices", newUpdate("AuthProviderA"), map[string]string{APIKeyHeader: "unknown.secret"})
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	// A provider can't send updates for another provider
+	rr = send("/prices", newUpdate("AuthProviderA"), map[string]string{APIKeyHeader: credentialB.APIKey()})
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	// HMAC signatures
+	body := newUpdate("AuthProviderA")
+	headers := sign(credentialA, time.Now(), body)
+	rr = send("/prices", body, headers)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	// The same request can't be replayed
+	rr = send("/prices", body, headers)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	// The body can't be changed
+	rr = send("/prices", newUpdate("AuthProviderA"), sign(credentialA, time.Now(), body))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	// Signatures outside the replay window are rejected
+	body = newUpdate("AuthProviderA")
+	rr = send("/prices", body, sign(credentialA, time.Now().Add(-2*SignatureReplayWindow), body))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// Expired credentials stop working
+	if _, err := ProviderConfig.RotateCredentials("AuthProviderB", 0); err != nil {
+		t.Fatal(err)
+	}
+	rr = send("/prices", newUpdate("AuthProviderB"), map[string]string{APIKeyHeader: credentialB.APIKey()})
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// Batch updates for another provider are rejected individually
+	body = []byte("[" + string(newUpdate("AuthProviderA")) + "," + string(newUpdate("AuthProviderB")) + "]")
+	rr = send("/prices/batch", body, sign(credentialA, time.Now(), body))
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var response PriceBatchResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, response.Accepted)
+	assert.Equal(t, 1, response.Rejected)
+	if assert.Equal(t, 2, len(response.Results)) {
+		assert.True(t, response.Results[0].Accepted)
+		assert.Contains(t, response.Results[1].Error, "authenticated as AuthProviderA")
+	}
+
+	// gRPC calls send the API key as metadata
+	server := &PriceServiceServer{engine: engine}
+	newProtoUpdate := func(provider string) *PriceProto.PriceUpdateRequest {
+		timestamp++
+		return &PriceProto.PriceUpdateRequest{Provider: provider, Base: "AVAX", Quote: "TRY", Bid: "1000", BidAmount: "1", Timestamp: timestamp}
+	}
+	_, err = server.PublishPrice(context.Background(), newProtoUpdate("AuthProviderA"))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(apiKeyMetadata, credentialA.APIKey()))
+	_, err = server.PublishPrice(ctx, newProtoUpdate("AuthProviderA"))
+	assert.Nil(t, err)
+	_, err = server.PublishPrice(ctx, newProtoUpdate("AuthProviderB"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -0,0 +1,37 @@
+package PriceAPI
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// BestPrice is the consolidated best bid and best ask for a single currency pair.
+type BestPrice struct {
+	Base   string           `json:"base"`
+	Quote  string           `json:"quote"`
+	Bid    *PriceUpdate     `json:"bid"`
+	Ask    *PriceUpdate     `json:"ask"`
+	Spread *decimal.Decimal `json:"spread"`
+}
+
+// NewBestPrice builds a BestPrice from the current best bid and ask, either of which may be nil.
+// The spread is only set when both sides are available.
+func NewBestPrice(base string, quote string, bid *PriceUpdate, ask *PriceUpdate) *BestPrice {
+	bestPrice := &BestPrice{
+		Base:  base,
+		Quote: quote,
+		Bid:   bid,
+		Ask:   ask,
+	}
+	if bid != nil && ask != nil {
+		spread := ask.Price.Sub(bid.Price)
+		bestPrice.Spread = &spread
+	}
+	return bestPrice
+}
+
+// GetPairName returns the pair name based on the Base and Quote fields of the BestPrice.
+func (p *BestPrice) GetPairName() string {
+	return fmt.Sprintf("%s/%s", p.Base, p.Quote)
+}
@@ -0,0 +1,153 @@
+package PriceAPI
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/Tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ConsolidatedBook is the top of book for a single currency pair across all enabled providers.
+// Each provider contributes at most one bid and one ask, sorted best first.
+type ConsolidatedBook struct {
+	Base  string         `json:"base"`
+	Quote string         `json:"quote"`
+	Bids  []*PriceUpdate `json:"bids"`
+	Asks  []*PriceUpdate `json:"asks"`
+}
+
+// BestBid returns the best bid in the book or nil if there are no bids.
+func (b *ConsolidatedBook) BestBid() *PriceUpdate {
+	if len(b.Bids) == 0 {
+		return nil
+	}
+	return b.Bids[0]
+}
+
+// BestAsk returns the best ask in the book or nil if there are no asks.
+func (b *ConsolidatedBook) BestAsk() *PriceUpdate {
+	if len(b.Asks) == 0 {
+		return nil
+	}
+	return b.Asks[0]
+}
+
+// isBetterBid reports whether bid a ranks ahead of bid b.
+// The highest price wins, ties go to the earliest quote and then the largest amount.
+func isBetterBid(a *PriceUpdate, b *PriceUpdate) bool {
+	if !a.Price.Equal(b.Price) {
+		return a.Price.GreaterThan(b.Price)
+	}
+	return hasPriority(a, b)
+}
+
+// isBetterAsk reports whether ask a ranks ahead of ask b.
+// The lowest price wins, ties go to the earliest quote and then the largest amount.
+func isBetterAsk(a *PriceUpdate, b *PriceUpdate) bool {
+	if !a.Price.Equal(b.Price) {
+		return a.Price.LessThan(b.Price)
+	}
+	return hasPriority(a, b)
+}
+
+// hasPriority breaks ties between two quotes at the same price using time priority
+// then amount, falling back to provider name so the ordering is always deterministic.
+func hasPriority(a *PriceUpdate, b *PriceUpdate) bool {
+	if a.Timestamp != b.Timestamp {
+		return a.Timestamp < b.Timestamp
+	}
+	if !a.Amount.Equal(b.Amount) {
+		return a.Amount.GreaterThan(b.Amount)
+	}
+	return a.Provider < b.Provider
+}
+
+// samePriceUpdate reports whether two best prices are identical, treating nil as no price.
+func samePriceUpdate(a *PriceUpdate, b *PriceUpdate) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(b)
+}
+
+// getPairUpdateRequests returns the last update request from every provider that quoted a pair.
+func (engine *PriceEngine) getPairUpdateRequests(pairName string) []*PriceUpdateRequest {
+	book := engine.getBook(pairName)
+	if book == nil {
+		return nil
+	}
+	book.mu.Lock()
+	defer book.mu.Unlock()
+	updates := make([]*PriceUpdateRequest, 0, len(book.quotes))
+	for _, update := range book.quotes {
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+// buildConsolidatedBook builds the consolidated book for a pair from the last update
+// of every provider that currently has the pair enabled. Stale quotes are left out.
+func (engine *PriceEngine) buildConsolidatedBook(pairName string, now time.Time) *ConsolidatedBook {
+	base, quote, _ := strings.Cut(pairName, "/")
+	book := &ConsolidatedBook{
+		Base:  base,
+		Quote: quote,
+		Bids:  make([]*PriceUpdate, 0),
+		Asks:  make([]*PriceUpdate, 0),
+	}
+
+	for _, updatePriceReq := range engine.getPairUpdateRequests(pairName) {
+		if engine.isQuoteStale(updatePriceReq, now) {
+			continue
+		}
+		isEnabled, err := engine.Eligibility.GetProviderPairEnabled(updatePriceReq.Provider, pairName)
+		if err != nil || !isEnabled {
+			continue
+		}
+		// A zero price means the provider is not quoting that side
+		if updatePriceReq.Bid.IsPositive() {
+			book.Bids = append(book.Bids, updatePriceReq.NewPriceUpdateBid())
+		}
+		if updatePriceReq.Ask.IsPositive() {
+			book.Asks = append(book.Asks, updatePriceReq.NewPriceUpdateAsk())
+		}
+	}
+
+	sort.Slice(book.Bids, func(i, j int) bool { return isBetterBid(book.Bids[i], book.Bids[j]) })
+	sort.Slice(book.Asks, func(i, j int) bool { return isBetterAsk(book.Asks[i], book.Asks[j]) })
+	return book
+}
+
+// recalculateBestPricesForPair rebuilds the consolidated book for a pair and publishes
+// any change to the best bid or ask. If the previous best provider has moved away or is
+// no longer enabled the next best provider is promoted.
+func (engine *PriceEngine) recalculateBestPricesForPair(ctx context.Context, pairName string, now time.Time) {
+	defer observeRecalculation(pairName, time.Now())
+	ctx, span := Tracing.Start(ctx, "PriceAPI.recalculateBestPricesForPair", trace.WithAttributes(attribute.String("pair", pairName)))
+	defer span.End()
+	consolidated := engine.buildConsolidatedBook(pairName, now)
+	newBid, newAsk := consolidated.BestBid(), consolidated.BestAsk()
+
+	// Both sides are published together so readers never see half of a change
+	book := engine.lockBook(pairName)
+	oldBid, oldAsk := book.bestSides()
+	bidChanged, askChanged := !samePriceUpdate(newBid, oldBid), !samePriceUpdate(newAsk, oldAsk)
+	if bidChanged || askChanged {
+		book.publish(newBid, newAsk)
+	}
+	book.mu.Unlock()
+
+	if bidChanged {
+		engine.emitPriceUpdate(ctx, pairName, newBid, "Bid")
+	}
+	if askChanged {
+		engine.emitPriceUpdate(ctx, pairName, newAsk, "Ask")
+	}
+	if bidChanged || askChanged {
+		engine.detectArbitrage(ctx, pairName)
+	}
+}
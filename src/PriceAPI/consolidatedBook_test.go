@@ -0,0 +1,72 @@
+package PriceAPI
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsBetterBidAndAsk(t *testing.T) {
+	low := &PriceUpdate{Provider: "A", Price: decimal.NewFromInt(100), Amount: decimal.NewFromInt(1), Timestamp: 2}
+	high := &PriceUpdate{Provider: "B", Price: decimal.NewFromInt(101), Amount: decimal.NewFromInt(1), Timestamp: 2}
+	assert.True(t, isBetterBid(high, low), "Highest bid should win")
+	assert.True(t, isBetterAsk(low, high), "Lowest ask should win")
+
+	earlier := &PriceUpdate{Provider: "B", Price: decimal.NewFromInt(100), Amount: decimal.NewFromInt(1), Timestamp: 1}
+	assert.True(t, isBetterBid(earlier, low), "Earlier bid should win on a price tie")
+	assert.True(t, isBetterAsk(earlier, low), "Earlier ask should win on a price tie")
+
+	larger := &PriceUpdate{Provider: "B", Price: decimal.NewFromInt(100), Amount: decimal.NewFromInt(5), Timestamp: 2}
+	assert.True(t, isBetterBid(larger, low), "Larger bid should win on a price and time tie")
+	assert.True(t, isBetterAsk(larger, low), "Larger ask should win on a price and time tie")
+}
+
+func TestRecalculateBestPricesForPair(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestRecalculateBestPricesForPair")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	ProviderConfig.SetPairEnabled("ProviderA", "BTC/USD", true)
+	ProviderConfig.SetPairEnabled("ProviderB", "BTC/USD", true)
+	ProviderConfig.SetPairEnabled("ProviderC", "BTC/USD", false)
+
+	engine.saveProviderUpdateRequest(&PriceUpdateRequest{Provider: "ProviderA", Base: "BTC", Quote: "USD", Bid: decimal.NewFromInt(100), BidAmount: decimal.NewFromInt(1), Ask: decimal.NewFromInt(103), AskAmount: decimal.NewFromInt(1), Timestamp: 1})
+	engine.saveProviderUpdateRequest(&PriceUpdateRequest{Provider: "ProviderB", Base: "BTC", Quote: "USD", Bid: decimal.NewFromInt(99), BidAmount: decimal.NewFromInt(1), Ask: decimal.NewFromInt(102), AskAmount: decimal.NewFromInt(1), Timestamp: 1})
+	// Disabled providers never make it into the book
+	engine.saveProviderUpdateRequest(&PriceUpdateRequest{Provider: "ProviderC", Base: "BTC", Quote: "USD", Bid: decimal.NewFromInt(150), BidAmount: decimal.NewFromInt(1), Ask: decimal.NewFromInt(50), AskAmount: decimal.NewFromInt(1), Timestamp: 1})
+	engine.recalculateBestPricesForPair(context.Background(), "BTC/USD", engine.now())
+
+	assert.Equal(t, "ProviderA", engine.GetBestBidPrice("BTC/USD").Provider)
+	assert.Equal(t, "ProviderB", engine.GetBestAskPrice("BTC/USD").Provider)
+
+	// ProviderA moves its bid away so ProviderB should be promoted
+	engine.saveProviderUpdateRequest(&PriceUpdateRequest{Provider: "ProviderA", Base: "BTC", Quote: "USD", Bid: decimal.NewFromInt(98), BidAmount: decimal.NewFromInt(1), Ask: decimal.NewFromInt(103), AskAmount: decimal.NewFromInt(1), Timestamp: 2})
+	engine.recalculateBestPricesForPair(context.Background(), "BTC/USD", engine.now())
+
+	assert.Equal(t, "ProviderB", engine.GetBestBidPrice("BTC/USD").Provider)
+	assert.Equal(t, "99", engine.GetBestBidPrice("BTC/USD").Price.String())
+
+	// Disabling every provider clears the best prices
+	ProviderConfig.SetPairEnabled("ProviderA", "BTC/USD", false)
+	ProviderConfig.SetPairEnabled("ProviderB", "BTC/USD", false)
+	engine.recalculateBestPricesForPair(context.Background(), "BTC/USD", engine.now())
+
+	assert.Nil(t, engine.GetBestBidPrice("BTC/USD"))
+	assert.Nil(t, engine.GetBestAskPrice("BTC/USD"))
+}
@@ -0,0 +1,190 @@
+package PriceAPI
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PriceSourceDirect    = "direct"
+	PriceSourceSynthetic = "synthetic"
+)
+
+// SyntheticLeg is one of the two quoted pairs used to derive a cross rate.
+type SyntheticLeg struct {
+	Pair string `json:"pair"`
+	// True when the quoted pair is the reverse of the leg, e.g. EUR/USD used for USD->EUR
+	Inverted bool         `json:"inverted"`
+	Bid      *PriceUpdate `json:"bid"`
+	Ask      *PriceUpdate `json:"ask"`
+}
+
+// SyntheticPrice is an implied price for a pair through a single intermediate currency.
+// Amounts are in the base currency.
+type SyntheticPrice struct {
+	Base      string           `json:"base"`
+	Quote     string           `json:"quote"`
+	Via       string           `json:"via"`
+	Bid       *decimal.Decimal `json:"bid"`
+	BidAmount decimal.Decimal  `json:"bid_amount"`
+	Ask       *decimal.Decimal `json:"ask"`
+	AskAmount decimal.Decimal  `json:"ask_amount"`
+	Legs      []*SyntheticLeg  `json:"legs"`
+}
+
+// PublishedPrice is the better of the direct and synthetic prices for one side.
+type PublishedPrice struct {
+	Source   string          `json:"source"` // direct or synthetic
+	Price    decimal.Decimal `json:"price"`
+	Amount   decimal.Decimal `json:"amount"`
+	Provider string          `json:"provider,omitempty"` // set for direct prices
+	Via      string          `json:"via,omitempty"`      // set for synthetic prices
+}
+
+// CrossRate compares the direct best prices for a pair with every implied cross rate.
+type CrossRate struct {
+	Base      string            `json:"base"`
+	Quote     string            `json:"quote"`
+	Direct    *BestPrice        `json:"direct"`
+	Synthetic []*SyntheticPrice `json:"synthetic"`
+	Bid       *PublishedPrice   `json:"bid"`
+	Ask       *PublishedPrice   `json:"ask"`
+}
+
+// legRate is the price of one currency in another taken from a quoted pair, inverting it if needed.
+// Amounts are in the from currency.
+type legRate struct {
+	leg       *SyntheticLeg
+	bid       decimal.Decimal
+	bidAmount decimal.Decimal
+	ask       decimal.Decimal
+	askAmount decimal.Decimal
+}
+
+// getLegRate finds the rate to convert from one currency to another using the
+// direct pair (from/to) or the inverted pair (to/from).
+func getLegRate(bestPrices map[string]*BestPrice, from string, to string) *legRate {
+	if direct := bestPrices[fmt.Sprintf("%s/%s", from, to)]; direct != nil {
+		rate := &legRate{leg: &SyntheticLeg{Pair: direct.GetPairName(), Bid: direct.Bid, Ask: direct.Ask}}
+		if direct.Bid != nil && direct.Bid.Price.IsPositive() {
+			rate.bid, rate.bidAmount = direct.Bid.Price, direct.Bid.Amount
+		}
+		if direct.Ask != nil && direct.Ask.Price.IsPositive() {
+			rate.ask, rate.askAmount = direct.Ask.Price, direct.Ask.Amount
+		}
+		return rate
+	}
+	if inverse := bestPrices[fmt.Sprintf("%s/%s", to, from)]; inverse != nil {
+		rate := &legRate{leg: &SyntheticLeg{Pair: inverse.GetPairName(), Inverted: true, Bid: inverse.Bid, Ask: inverse.Ask}}
+		// Selling from for to is buying to with from, so the bid comes from the inverse ask
+		if inverse.Ask != nil && inverse.Ask.Price.IsPositive() {
+			rate.bid, rate.bidAmount = decimal.NewFromInt(1).Div(inverse.Ask.Price), inverse.Ask.Amount.Mul(inverse.Ask.Price)
+		}
+		if inverse.Bid != nil && inverse.Bid.Price.IsPositive() {
+			rate.ask, rate.askAmount = decimal.NewFromInt(1).Div(inverse.Bid.Price), inverse.Bid.Amount.Mul(inverse.Bid.Price)
+		}
+		return rate
+	}
+	return nil
+}
+
+// calculateSyntheticPrice derives base/quote through an intermediate currency, returning
+// nil if either leg is missing or neither side could be priced.
+func calculateSyntheticPrice(bestPrices map[string]*BestPrice, base string, quote string, via string) *SyntheticPrice {
+	first := getLegRate(bestPrices, base, via)
+	second := getLegRate(bestPrices, via, quote)
+	if first == nil || second == nil {
+		return nil
+	}
+
+	syntheticPrice := &SyntheticPrice{
+		Base:  base,
+		Quote: quote,
+		Via:   via,
+		Legs:  []*SyntheticLeg{first.leg, second.leg},
+	}
+	if first.bid.IsPositive() && second.bid.IsPositive() {
+		bid := first.bid.Mul(second.bid)
+		syntheticPrice.Bid = &bid
+		// The second leg amount is in the intermediate currency so convert it back to base
+		syntheticPrice.BidAmount = decimal.Min(first.bidAmount, second.bidAmount.Div(first.bid))
+	}
+	if first.ask.IsPositive() && second.ask.IsPositive() {
+		ask := first.ask.Mul(second.ask)
+		syntheticPrice.Ask = &ask
+		syntheticPrice.AskAmount = decimal.Min(first.askAmount, second.askAmount.Div(first.ask))
+	}
+	if syntheticPrice.Bid == nil && syntheticPrice.Ask == nil {
+		return nil
+	}
+	return syntheticPrice
+}
+
+// calculateCrossRate derives every synthetic price for a pair and publishes the better
+// of the direct and synthetic price on each side.
+func calculateCrossRate(bestPrices map[string]*BestPrice, base string, quote string) *CrossRate {
+	crossRate := &CrossRate{
+		Base:      base,
+		Quote:     quote,
+		Direct:    bestPrices[fmt.Sprintf("%s/%s", base, quote)],
+		Synthetic: make([]*SyntheticPrice, 0),
+	}
+
+	// Every other currency we have prices for is a candidate intermediate
+	currencies := make(map[string]bool)
+	for _, bestPrice := range bestPrices {
+		currencies[bestPrice.Base] = true
+		currencies[bestPrice.Quote] = true
+	}
+	intermediates := make([]string, 0, len(currencies))
+	for currency := range currencies {
+		if currency != base && currency != quote {
+			intermediates = append(intermediates, currency)
+		}
+	}
+	sort.Strings(intermediates)
+
+	for _, via := range intermediates {
+		if syntheticPrice := calculateSyntheticPrice(bestPrices, base, quote, via); syntheticPrice != nil {
+			crossRate.Synthetic = append(crossRate.Synthetic, syntheticPrice)
+		}
+	}
+
+	if crossRate.Direct != nil && crossRate.Direct.Bid != nil {
+		crossRate.Bid = &PublishedPrice{Source: PriceSourceDirect, Price: crossRate.Direct.Bid.Price, Amount: crossRate.Direct.Bid.Amount, Provider: crossRate.Direct.Bid.Provider}
+	}
+	if crossRate.Direct != nil && crossRate.Direct.Ask != nil {
+		crossRate.Ask = &PublishedPrice{Source: PriceSourceDirect, Price: crossRate.Direct.Ask.Price, Amount: crossRate.Direct.Ask.Amount, Provider: crossRate.Direct.Ask.Provider}
+	}
+	// Synthetic prices only win when strictly better so direct quotes are preferred on a tie
+	for _, syntheticPrice := range crossRate.Synthetic {
+		if syntheticPrice.Bid != nil && (crossRate.Bid == nil || syntheticPrice.Bid.GreaterThan(crossRate.Bid.Price)) {
+			crossRate.Bid = &PublishedPrice{Source: PriceSourceSynthetic, Price: *syntheticPrice.Bid, Amount: syntheticPrice.BidAmount, Via: syntheticPrice.Via}
+		}
+		if syntheticPrice.Ask != nil && (crossRate.Ask == nil || syntheticPrice.Ask.LessThan(crossRate.Ask.Price)) {
+			crossRate.Ask = &PublishedPrice{Source: PriceSourceSynthetic, Price: *syntheticPrice.Ask, Amount: syntheticPrice.AskAmount, Via: syntheticPrice.Via}
+		}
+	}
+	return crossRate
+}
+
+// GetCrossRate returns the direct and synthetic prices for a pair along with the better of the two.
+func (engine *PriceEngine) GetCrossRate(c *gin.Context) {
+	base := c.Param("base")
+	quote := c.Param("quote")
+	if base == "" || quote == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty base or quote param"})
+		return
+	}
+
+	crossRate := calculateCrossRate(engine.getBestPrices(), base, quote)
+	if crossRate.Bid == nil && crossRate.Ask == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no direct or synthetic prices for %s/%s", base, quote)})
+		return
+	}
+	c.JSON(http.StatusOK, crossRate)
+}
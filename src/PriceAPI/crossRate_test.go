@@ -0,0 +1,53 @@
+package PriceAPI
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateCrossRate(t *testing.T) {
+	bestPrices := map[string]*BestPrice{
+		"BTC/USD": NewBestPrice("BTC", "USD",
+			&PriceUpdate{Provider: "ProviderA", Base: "BTC", Quote: "USD", Price: decimal.NewFromInt(50000), Amount: decimal.NewFromInt(2)},
+			&PriceUpdate{Provider: "ProviderB", Base: "BTC", Quote: "USD", Price: decimal.NewFromInt(50100), Amount: decimal.NewFromInt(3)}),
+		"EUR/USD": NewBestPrice("EUR", "USD",
+			&PriceUpdate{Provider: "ProviderA", Base: "EUR", Quote: "USD", Price: decimal.RequireFromString("1.25"), Amount: decimal.NewFromInt(100000)},
+			&PriceUpdate{Provider: "ProviderB", Base: "EUR", Quote: "USD", Price: decimal.RequireFromString("1.26"), Amount: decimal.NewFromInt(1000)}),
+	}
+
+	// BTC/EUR via USD needs USD/EUR which is EUR/USD inverted
+	crossRate := calculateCrossRate(bestPrices, "BTC", "EUR")
+	assert.Nil(t, crossRate.Direct)
+	assert.Equal(t, 1, len(crossRate.Synthetic))
+
+	syntheticPrice := crossRate.Synthetic[0]
+	assert.Equal(t, "USD", syntheticPrice.Via)
+	assert.False(t, syntheticPrice.Legs[0].Inverted)
+	assert.True(t, syntheticPrice.Legs[1].Inverted)
+	// Selling BTC for EUR: sell BTC at the BTC/USD bid then buy EUR at the EUR/USD ask
+	assert.InDelta(t, 50000/1.26, syntheticPrice.Bid.InexactFloat64(), 0.000001)
+	// Buying BTC with EUR: sell EUR at the EUR/USD bid then buy BTC at the BTC/USD ask
+	assert.InDelta(t, 50100/1.25, syntheticPrice.Ask.InexactFloat64(), 0.000001)
+	// The EUR/USD ask only has 1000 EUR (1260 USD) so the bid is limited to 1260/50000 BTC
+	assert.InDelta(t, 1260.0/50000, syntheticPrice.BidAmount.InexactFloat64(), 0.000001)
+	assert.InDelta(t, 125000.0/50100, syntheticPrice.AskAmount.InexactFloat64(), 0.000001)
+
+	assert.Equal(t, PriceSourceSynthetic, crossRate.Bid.Source)
+	assert.Equal(t, "USD", crossRate.Bid.Via)
+
+	// A better direct quote is published instead of the synthetic one
+	bestPrices["BTC/EUR"] = NewBestPrice("BTC", "EUR",
+		&PriceUpdate{Provider: "ProviderC", Base: "BTC", Quote: "EUR", Price: decimal.NewFromInt(40000), Amount: decimal.NewFromInt(1)},
+		&PriceUpdate{Provider: "ProviderC", Base: "BTC", Quote: "EUR", Price: decimal.NewFromInt(40500), Amount: decimal.NewFromInt(1)})
+	crossRate = calculateCrossRate(bestPrices, "BTC", "EUR")
+	assert.Equal(t, PriceSourceDirect, crossRate.Bid.Source)
+	assert.Equal(t, "ProviderC", crossRate.Bid.Provider)
+	assert.Equal(t, PriceSourceSynthetic, crossRate.Ask.Source)
+
+	// No legs means no synthetic price
+	crossRate = calculateCrossRate(bestPrices, "ETH", "JPY")
+	assert.Equal(t, 0, len(crossRate.Synthetic))
+	assert.Nil(t, crossRate.Bid)
+}
@@ -0,0 +1,590 @@
+package PriceAPI
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/FIX"
+	"github.com/shopspring/decimal"
+)
+
+// How long a new connection has to send its Logon
+var FIXLogonTimeout = 10 * time.Second
+
+// fixSequence is the next incoming and outgoing MsgSeqNum for a provider. It is kept
+// between connections so a provider can carry on where it left off after reconnecting.
+type fixSequence struct {
+	incoming int
+	outgoing int
+}
+
+// fixQuote is a provider's top of book for a symbol built up from market data messages
+type fixQuote struct {
+	bid       decimal.Decimal
+	bidAmount decimal.Decimal
+	ask       decimal.Decimal
+	askAmount decimal.Decimal
+}
+
+// FIXAcceptor accepts FIX 4.4 sessions from providers and feeds their market data into
+// the best price pipeline. The SenderCompID is used as the provider name.
+type FIXAcceptor struct {
+	CompID    string
+	engine    *PriceEngine
+	listener  net.Listener
+	sequences map[string]*fixSequence
+	// Logged on sessions by provider
+	sessions map[string]*fixSession
+	closed   bool
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+}
+
+type fixSession struct {
+	acceptor  *FIXAcceptor
+	conn      net.Conn
+	reader    *bufio.Reader
+	provider  string
+	sequence  *fixSequence
+	heartbeat time.Duration
+	quotes    map[string]*fixQuote
+	// Guards writes and the outgoing sequence number
+	writeMu   sync.Mutex
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// StartFIXAcceptor listens for FIX sessions on the given address, identifying itself
+// with compID. Initiators must send it as their TargetCompID.
+func (engine *PriceEngine) StartFIXAcceptor(address string, compID string) (*FIXAcceptor, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	acceptor := &FIXAcceptor{
+		CompID:    compID,
+		engine:    engine,
+		listener:  listener,
+		sequences: make(map[string]*fixSequence),
+		sessions:  make(map[string]*fixSession),
+	}
+	acceptor.wg.Add(1)
+	go acceptor.acceptLoop()
+	return acceptor, nil
+}
+
+// Addr returns the address the acceptor is listening on.
+func (acceptor *FIXAcceptor) Addr() net.Addr {
+	return acceptor.listener.Addr()
+}
+
+// Close logs out every session and stops accepting connections.
+func (acceptor *FIXAcceptor) Close() {
+	acceptor.mu.Lock()
+	acceptor.closed = true
+	sessions := make([]*fixSession, 0, len(acceptor.sessions))
+	for _, session := range acceptor.sessions {
+		sessions = append(sessions, session)
+	}
+	acceptor.mu.Unlock()
+
+	acceptor.listener.Close()
+	for _, session := range sessions {
+		session.logout("acceptor shutting down")
+	}
+	acceptor.wg.Wait()
+}
+
+func (acceptor *FIXAcceptor) acceptLoop() {
+	defer acceptor.wg.Done()
+	for {
+		conn, err := acceptor.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				fmt.Println("Error accepting FIX connection:", err)
+			}
+			return
+		}
+		session := &fixSession{
+			acceptor: acceptor,
+			conn:     conn,
+			reader:   bufio.NewReader(conn),
+			quotes:   make(map[string]*fixQuote),
+			done:     make(chan struct{}),
+		}
+		acceptor.wg.Add(1)
+		go func() {
+			defer acceptor.wg.Done()
+			session.run()
+		}()
+	}
+}
+
+// register claims the provider's session and sequence numbers, only one session per
+// provider can be logged on at a time.
+func (acceptor *FIXAcceptor) register(session *fixSession, reset bool) error {
+	acceptor.mu.Lock()
+	defer acceptor.mu.Unlock()
+	if acceptor.closed {
+		return fmt.Errorf("acceptor is shutting down")
+	}
+	if acceptor.sessions[session.provider] != nil {
+		return fmt.Errorf("%s is already logged on", session.provider)
+	}
+	sequence := acceptor.sequences[session.provider]
+	if sequence == nil || reset {
+		sequence = &fixSequence{incoming: 1, outgoing: 1}
+		acceptor.sequences[session.provider] = sequence
+	}
+	session.sequence = sequence
+	acceptor.sessions[session.provider] = session
+	return nil
+}
+
+func (acceptor *FIXAcceptor) unregister(session *fixSession) {
+	acceptor.mu.Lock()
+	defer acceptor.mu.Unlock()
+	if acceptor.sessions[session.provider] == session {
+		delete(acceptor.sessions, session.provider)
+	}
+}
+
+func (session *fixSession) close() {
+	session.closeOnce.Do(func() {
+		close(session.done)
+		session.conn.Close()
+		session.acceptor.unregister(session)
+	})
+}
+
+// send fills in the standard header and writes the message
+func (session *fixSession) send(message *FIX.Message) error {
+	session.writeMu.Lock()
+	defer session.writeMu.Unlock()
+	err := session.write(message, session.sequence.outgoing)
+	session.sequence.outgoing++
+	return err
+}
+
+// write must be called with writeMu held
+func (session *fixSession) write(message *FIX.Message, seqNum int) error {
+	header := FIX.NewMessage(message.MsgType()).
+		Add(FIX.TagSenderCompID, session.acceptor.CompID).
+		Add(FIX.TagTargetCompID, session.provider).
+		Add(FIX.TagMsgSeqNum, strconv.Itoa(seqNum)).
+		Add(FIX.TagSendingTime, FIX.FormatUTCTimestamp(time.Now()))
+	header.Fields = append(header.Fields, message.Fields[1:]...)
+	_, err := session.conn.Write(header.Bytes(FIX.BeginString44))
+	return err
+}
+
+// logout sends a Logout and disconnects
+func (session *fixSession) logout(reason string) {
+	if session.sequence != nil {
+		session.send(FIX.NewMessage(FIX.MsgTypeLogout).Add(FIX.TagText, reason))
+	}
+	session.close()
+}
+
+// rejectLogon writes a Logout to a connection that never logged on
+func (session *fixSession) rejectLogon(reason string) {
+	fmt.Printf("Rejecting FIX logon from %s: %s\n", session.conn.RemoteAddr(), reason)
+	session.writeMu.Lock()
+	session.write(FIX.NewMessage(FIX.MsgTypeLogout).Add(FIX.TagText, reason), 1)
+	session.writeMu.Unlock()
+	session.conn.Close()
+}
+
+func (session *fixSession) run() {
+	session.conn.SetReadDeadline(time.Now().Add(FIXLogonTimeout))
+	logon, err := FIX.ReadMessage(session.reader, FIX.BeginString44)
+	if err != nil {
+		session.conn.Close()
+		return
+	}
+	if err := session.logon(logon); err != nil {
+		session.rejectLogon(err.Error())
+		return
+	}
+	defer session.close()
+	fmt.Printf("FIX session logged on for %s\n", session.provider)
+
+	go session.heartbeatLoop()
+
+	testRequestSent := false
+	for {
+		// Allow a little over one heartbeat interval before checking the connection is alive
+		session.conn.SetReadDeadline(time.Now().Add(session.heartbeat + session.heartbeat/5))
+		message, err := FIX.ReadMessage(session.reader, FIX.BeginString44)
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			if testRequestSent {
+				session.logout("heartbeat timeout")
+				return
+			}
+			testRequestSent = true
+			session.send(FIX.NewMessage(FIX.MsgTypeTestRequest).Add(FIX.TagTestReqID, strconv.FormatInt(time.Now().UnixNano(), 10)))
+			continue
+		}
+		if err != nil {
+			select {
+			case <-session.done:
+			default:
+				fmt.Printf("FIX session for %s disconnected: %v\n", session.provider, err)
+			}
+			return
+		}
+		testRequestSent = false
+		if !session.handle(message) {
+			return
+		}
+	}
+}
+
+// logon validates the Logon message, claims the provider's sequence numbers and replies
+func (session *fixSession) logon(message *FIX.Message) error {
+	if message.MsgType() != FIX.MsgTypeLogon {
+		return fmt.Errorf("first message must be Logon")
+	}
+	if targetCompID, _ := message.Get(FIX.TagTargetCompID); targetCompID != session.acceptor.CompID {
+		return fmt.Errorf("unknown TargetCompID %q", targetCompID)
+	}
+	session.provider, _ = message.Get(FIX.TagSenderCompID)
+	if session.provider == "" {
+		return fmt.Errorf("missing SenderCompID")
+	}
+	// The provider's API key is sent as the Password
+	if session.acceptor.engine.ProviderAuthEnabled {
+		password, _ := message.Get(FIX.TagPassword)
+		providerName, _, err := session.acceptor.engine.authenticateAPIKey(password)
+		if err != nil {
+			return err
+		}
+		if providerName != session.provider {
+			return fmt.Errorf("API key is for %s not %s", providerName, session.provider)
+		}
+	}
+	heartbeat, err := message.GetInt(FIX.TagHeartBtInt)
+	if err != nil || heartbeat <= 0 {
+		return fmt.Errorf("HeartBtInt must be greater than 0")
+	}
+	session.heartbeat = time.Duration(heartbeat) * time.Second
+	seqNum, err := message.GetInt(FIX.TagMsgSeqNum)
+	if err != nil {
+		return err
+	}
+
+	reset := message.GetBool(FIX.TagResetSeqNumFlag)
+	if err := session.acceptor.register(session, reset); err != nil {
+		return err
+	}
+	if seqNum < session.sequence.incoming {
+		expected := session.sequence.incoming
+		session.acceptor.unregister(session)
+		session.sequence = nil
+		return fmt.Errorf("MsgSeqNum too low, expecting %d but received %d", expected, seqNum)
+	}
+
+	response := FIX.NewMessage(FIX.MsgTypeLogon).
+		Add(FIX.TagEncryptMethod, "0").
+		Add(FIX.TagHeartBtInt, strconv.Itoa(heartbeat))
+	if reset {
+		response.Add(FIX.TagResetSeqNumFlag, "Y")
+	}
+	if err := session.send(response); err != nil {
+		return err
+	}
+	session.checkSequence(seqNum)
+	return nil
+}
+
+// checkSequence asks for a resend if messages were missed and moves the expected
+// sequence number past this message
+func (session *fixSession) checkSequence(seqNum int) {
+	if seqNum > session.sequence.incoming {
+		fmt.Printf("FIX sequence gap for %s, expected %d but received %d\n", session.provider, session.sequence.incoming, seqNum)
+		session.send(FIX.NewMessage(FIX.MsgTypeResendRequest).
+			Add(FIX.TagBeginSeqNo, strconv.Itoa(session.sequence.incoming)).
+			Add(FIX.TagEndSeqNo, "0"))
+	}
+	session.sequence.incoming = seqNum + 1
+}
+
+// handle processes a message once logged on, returning false if the session has ended
+func (session *fixSession) handle(message *FIX.Message) bool {
+	if senderCompID, _ := message.Get(FIX.TagSenderCompID); senderCompID != session.provider {
+		session.logout(fmt.Sprintf("SenderCompID changed to %q", senderCompID))
+		return false
+	}
+	seqNum, err := message.GetInt(FIX.TagMsgSeqNum)
+	if err != nil {
+		session.logout(err.Error())
+		return false
+	}
+
+	// A SequenceReset without GapFill moves the sequence number regardless of MsgSeqNum
+	if message.MsgType() == FIX.MsgTypeSequenceReset && !message.GetBool(FIX.TagGapFillFlag) {
+		session.resetSequence(message, seqNum)
+		return true
+	}
+	if seqNum < session.sequence.incoming {
+		if message.GetBool(FIX.TagPossDupFlag) {
+			// Quotes are only useful while they are current, so resent messages are dropped
+			return true
+		}
+		session.logout(fmt.Sprintf("MsgSeqNum too low, expecting %d but received %d", session.sequence.incoming, seqNum))
+		return false
+	}
+	// Rather than hold quotes back behind a gap they are processed straight away
+	session.checkSequence(seqNum)
+
+	switch message.MsgType() {
+	case FIX.MsgTypeHeartbeat, FIX.MsgTypeReject:
+	case FIX.MsgTypeTestRequest:
+		testReqID, _ := message.Get(FIX.TagTestReqID)
+		session.send(FIX.NewMessage(FIX.MsgTypeHeartbeat).Add(FIX.TagTestReqID, testReqID))
+	case FIX.MsgTypeResendRequest:
+		session.gapFill(message)
+	case FIX.MsgTypeSequenceReset:
+		session.resetSequence(message, seqNum)
+	case FIX.MsgTypeLogout:
+		fmt.Printf("FIX session for %s logged out\n", session.provider)
+		// Free the provider before replying so it can log straight back on
+		session.acceptor.unregister(session)
+		session.send(FIX.NewMessage(FIX.MsgTypeLogout))
+		session.close()
+		return false
+	case FIX.MsgTypeMarketDataSnapshotFullRefresh:
+		session.handleMarketData(message, session.applySnapshot)
+	case FIX.MsgTypeMarketDataIncrementalRefresh:
+		session.handleMarketData(message, session.applyIncremental)
+	default:
+		session.businessReject(message, seqNum, "3", fmt.Sprintf("unsupported MsgType %q", message.MsgType()))
+	}
+	return true
+}
+
+// gapFill answers a ResendRequest, we don't keep sent messages so everything requested
+// is skipped with a single SequenceReset
+func (session *fixSession) gapFill(message *FIX.Message) {
+	beginSeqNo, err := message.GetInt(FIX.TagBeginSeqNo)
+	if err != nil {
+		return
+	}
+	session.writeMu.Lock()
+	defer session.writeMu.Unlock()
+	gapFill := FIX.NewMessage(FIX.MsgTypeSequenceReset).
+		Add(FIX.TagPossDupFlag, "Y").
+		Add(FIX.TagGapFillFlag, "Y").
+		Add(FIX.TagNewSeqNo, strconv.Itoa(session.sequence.outgoing))
+	session.write(gapFill, beginSeqNo)
+}
+
+func (session *fixSession) resetSequence(message *FIX.Message, seqNum int) {
+	newSeqNo, err := message.GetInt(FIX.TagNewSeqNo)
+	if err != nil || newSeqNo < session.sequence.incoming {
+		session.send(FIX.NewMessage(FIX.MsgTypeReject).
+			Add(FIX.TagRefSeqNum, strconv.Itoa(seqNum)).
+			Add(FIX.TagText, "NewSeqNo must not be lower than the expected MsgSeqNum"))
+		return
+	}
+	session.sequence.incoming = newSeqNo
+}
+
+func (session *fixSession) businessReject(message *FIX.Message, seqNum int, reason string, text string) {
+	session.send(FIX.NewMessage(FIX.MsgTypeBusinessMessageReject).
+		Add(FIX.TagRefSeqNum, strconv.Itoa(seqNum)).
+		Add(FIX.TagRefMsgType, message.MsgType()).
+		Add(FIX.TagBusinessRejectReason, reason).
+		Add(FIX.TagText, text))
+}
+
+// handleMarketData applies a market data message to the session's quotes, then validates
+// and queues an update for every symbol it changed
+func (session *fixSession) handleMarketData(message *FIX.Message, apply func(*FIX.Message) ([]string, error)) {
+	engine := session.acceptor.engine
+	seqNum, _ := message.GetInt(FIX.TagMsgSeqNum)
+	symbols, err := apply(message)
+	if err != nil {
+		session.businessReject(message, seqNum, "0", err.Error())
+		return
+	}
+
+	receivedAt := engine.now()
+	timestamp := receivedAt
+	if sendingTime, ok := message.Get(FIX.TagSendingTime); ok {
+		if parsed, err := FIX.ParseUTCTimestamp(sendingTime); err == nil {
+			timestamp = parsed
+		}
+	}
+
+	updates := make([]*PriceUpdateRequest, 0, len(symbols))
+	for _, symbol := range symbols {
+		base, quote, _ := strings.Cut(symbol, "/")
+		providerQuote := session.quotes[symbol]
+		update := &PriceUpdateRequest{
+			Provider:   session.provider,
+			Base:       base,
+			Quote:      quote,
+			Bid:        providerQuote.bid,
+			BidAmount:  providerQuote.bidAmount,
+			Ask:        providerQuote.ask,
+			AskAmount:  providerQuote.askAmount,
+			Timestamp:  timestamp.UnixMilli(),
+			ReceivedAt: receivedAt,
+		}
+		if _, err := engine.checkRateLimit(update); err != nil {
+			session.businessReject(message, seqNum, "0", fmt.Sprintf("%s: %v", symbol, err))
+			continue
+		}
+		if !engine.reserveIngestQueue(1) {
+			engine.recordQueueFull(session.provider)
+			session.businessReject(message, seqNum, "0", fmt.Sprintf("%s: ingest queue is full", symbol))
+			continue
+		}
+		if _, err := engine.validatePriceUpdateRequest(update); err != nil {
+			engine.releaseIngestQueue(1)
+			session.businessReject(message, seqNum, "0", fmt.Sprintf("%s: %v", symbol, err))
+			continue
+		}
+		updates = append(updates, update)
+	}
+	if len(updates) > 0 {
+		engine.enqueuePriceUpdates(updates, false)
+	}
+}
+
+func parseFIXSymbol(symbol string) error {
+	base, quote, ok := strings.Cut(symbol, "/")
+	if !ok || base == "" || quote == "" {
+		return fmt.Errorf("Symbol must be BASE/QUOTE, got %q", symbol)
+	}
+	return nil
+}
+
+func parseFIXEntry(entry []FIX.Field) (string, decimal.Decimal, decimal.Decimal, error) {
+	entryType, _ := FIX.GetGroupField(entry, FIX.TagMDEntryType)
+	if entryType != FIX.MDEntryTypeBid && entryType != FIX.MDEntryTypeOffer {
+		return "", decimal.Zero, decimal.Zero, fmt.Errorf("unsupported MDEntryType %q", entryType)
+	}
+	values := make([]decimal.Decimal, 0, 2)
+	for _, tag := range []int{FIX.TagMDEntryPx, FIX.TagMDEntrySize} {
+		value, _ := FIX.GetGroupField(entry, tag)
+		number, err := decimal.NewFromString(value)
+		if err != nil {
+			return "", decimal.Zero, decimal.Zero, fmt.Errorf("tag %d is not a decimal: %q", tag, value)
+		}
+		values = append(values, number)
+	}
+	return entryType, values[0], values[1], nil
+}
+
+// applySnapshot replaces the quote for the message's symbol with the best bid and offer in the snapshot
+func (session *fixSession) applySnapshot(message *FIX.Message) ([]string, error) {
+	symbol, _ := message.Get(FIX.TagSymbol)
+	if err := parseFIXSymbol(symbol); err != nil {
+		return nil, err
+	}
+	entries, err := message.Groups(FIX.TagNoMDEntries, FIX.TagMDEntryType)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &fixQuote{}
+	for _, entry := range entries {
+		entryType, price, amount, err := parseFIXEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		if entryType == FIX.MDEntryTypeBid && (snapshot.bid.IsZero() || price.GreaterThan(snapshot.bid)) {
+			snapshot.bid, snapshot.bidAmount = price, amount
+		}
+		if entryType == FIX.MDEntryTypeOffer && (snapshot.ask.IsZero() || price.LessThan(snapshot.ask)) {
+			snapshot.ask, snapshot.askAmount = price, amount
+		}
+	}
+	session.quotes[symbol] = snapshot
+	return []string{symbol}, nil
+}
+
+// applyIncremental updates one side of a quote for each entry. Providers send their
+// top of book so a new or changed entry replaces that side and a delete clears it.
+func (session *fixSession) applyIncremental(message *FIX.Message) ([]string, error) {
+	entries, err := message.Groups(FIX.TagNoMDEntries, FIX.TagMDUpdateAction)
+	if err != nil {
+		return nil, err
+	}
+	defaultSymbol, _ := message.Get(FIX.TagSymbol)
+
+	// Check every entry before changing anything so a bad message has no effect
+	type change struct {
+		symbol, action, entryType string
+		price, amount             decimal.Decimal
+	}
+	changes := make([]*change, 0, len(entries))
+	for _, entry := range entries {
+		entryChange := &change{symbol: defaultSymbol}
+		entryChange.action, _ = FIX.GetGroupField(entry, FIX.TagMDUpdateAction)
+		if symbol, ok := FIX.GetGroupField(entry, FIX.TagSymbol); ok {
+			entryChange.symbol = symbol
+		}
+		if err := parseFIXSymbol(entryChange.symbol); err != nil {
+			return nil, err
+		}
+		switch entryChange.action {
+		case FIX.MDUpdateActionNew, FIX.MDUpdateActionChange:
+			entryChange.entryType, entryChange.price, entryChange.amount, err = parseFIXEntry(entry)
+			if err != nil {
+				return nil, err
+			}
+		case FIX.MDUpdateActionDelete:
+			entryChange.entryType, _ = FIX.GetGroupField(entry, FIX.TagMDEntryType)
+			if entryChange.entryType != FIX.MDEntryTypeBid && entryChange.entryType != FIX.MDEntryTypeOffer {
+				return nil, fmt.Errorf("unsupported MDEntryType %q", entryChange.entryType)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported MDUpdateAction %q", entryChange.action)
+		}
+		changes = append(changes, entryChange)
+	}
+
+	symbols := make([]string, 0)
+	for _, entryChange := range changes {
+		providerQuote := session.quotes[entryChange.symbol]
+		if providerQuote == nil {
+			providerQuote = &fixQuote{}
+			session.quotes[entryChange.symbol] = providerQuote
+		}
+		if !slices.Contains(symbols, entryChange.symbol) {
+			symbols = append(symbols, entryChange.symbol)
+		}
+		if entryChange.entryType == FIX.MDEntryTypeBid {
+			providerQuote.bid, providerQuote.bidAmount = entryChange.price, entryChange.amount
+		} else {
+			providerQuote.ask, providerQuote.askAmount = entryChange.price, entryChange.amount
+		}
+	}
+	return symbols, nil
+}
+
+func (session *fixSession) heartbeatLoop() {
+	ticker := time.NewTicker(session.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := session.send(FIX.NewMessage(FIX.MsgTypeHeartbeat)); err != nil {
+				return
+			}
+		case <-session.done:
+			return
+		}
+	}
+}
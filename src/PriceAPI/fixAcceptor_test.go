@@ -0,0 +1,277 @@
+package PriceAPI
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/FIX"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/stretchr/testify/assert"
+)
+
+// fixInitiator is a minimal stand-in for a provider's FIX engine
+type fixInitiator struct {
+	t        *testing.T
+	conn     net.Conn
+	reader   *bufio.Reader
+	senderID string
+	targetID string
+	seqNum   int
+}
+
+func dialFIXInitiator(t *testing.T, acceptor *FIXAcceptor, senderID string) *fixInitiator {
+	conn, err := net.Dial("tcp", acceptor.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fixInitiator{t: t, conn: conn, reader: bufio.NewReader(conn), senderID: senderID, targetID: "PRICEAPI", seqNum: 1}
+}
+
+// send fills in the header using the next sequence number
+func (initiator *fixInitiator) send(message *FIX.Message) {
+	initiator.sendWithSeqNum(message, initiator.seqNum)
+	initiator.seqNum++
+}
+
+func (initiator *fixInitiator) sendWithSeqNum(message *FIX.Message, seqNum int) {
+	header := FIX.NewMessage(message.MsgType()).
+		Add(FIX.TagSenderCompID, initiator.senderID).
+		Add(FIX.TagTargetCompID, initiator.targetID).
+		Add(FIX.TagMsgSeqNum, strconv.Itoa(seqNum)).
+		Add(FIX.TagSendingTime, FIX.FormatUTCTimestamp(time.Now()))
+	header.Fields = append(header.Fields, message.Fields[1:]...)
+	if _, err := initiator.conn.Write(header.Bytes(FIX.BeginString44)); err != nil {
+		initiator.t.Fatal(err)
+	}
+}
+
+// read returns the next message that isn't a Heartbeat without a TestReqID
+func (initiator *fixInitiator) read() *FIX.Message {
+	for {
+		initiator.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		message, err := FIX.ReadMessage(initiator.reader, FIX.BeginString44)
+		if err != nil {
+			initiator.t.Fatal(err)
+		}
+		if _, ok := message.Get(FIX.TagTestReqID); message.MsgType() == FIX.MsgTypeHeartbeat && !ok {
+			continue
+		}
+		return message
+	}
+}
+
+// sync waits until every message sent so far has been processed
+func (initiator *fixInitiator) sync() {
+	initiator.send(FIX.NewMessage(FIX.MsgTypeTestRequest).Add(FIX.TagTestReqID, "sync"))
+	response := initiator.read()
+	assert.Equal(initiator.t, FIX.MsgTypeHeartbeat, response.MsgType())
+}
+
+func (initiator *fixInitiator) logon() *FIX.Message {
+	initiator.send(FIX.NewMessage(FIX.MsgTypeLogon).
+		Add(FIX.TagEncryptMethod, "0").
+		Add(FIX.TagHeartBtInt, "30").
+		Add(FIX.TagResetSeqNumFlag, "Y"))
+	return initiator.read()
+}
+
+func TestFIXAcceptor(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestFIXAcceptor")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("ATOM", "HKD"))
+	ProviderConfig.SetPairEnabled("FIXProviderA", "ATOM/HKD", true)
+
+	acceptor, err := engine.StartFIXAcceptor("127.0.0.1:0", "PRICEAPI")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acceptor.Close()
+
+	initiator := dialFIXInitiator(t, acceptor, "FIXProviderA")
+	defer initiator.conn.Close()
+	response := initiator.logon()
+	assert.Equal(t, FIX.MsgTypeLogon, response.MsgType())
+	targetCompID, _ := response.Get(FIX.TagTargetCompID)
+	assert.Equal(t, "FIXProviderA", targetCompID)
+
+	// Only one session per provider
+	duplicate := dialFIXInitiator(t, acceptor, "FIXProviderA")
+	defer duplicate.conn.Close()
+	assert.Equal(t, FIX.MsgTypeLogout, duplicate.logon().MsgType())
+
+	// The best bid and offer in a snapshot become the provider's quote
+	initiator.send(FIX.NewMessage(FIX.MsgTypeMarketDataSnapshotFullRefresh).
+		Add(FIX.TagSymbol, "ATOM/HKD").
+		Add(FIX.TagNoMDEntries, "3").
+		Add(FIX.TagMDEntryType, FIX.MDEntryTypeBid).Add(FIX.TagMDEntryPx, "70.1").Add(FIX.TagMDEntrySize, "10").
+		Add(FIX.TagMDEntryType, FIX.MDEntryTypeBid).Add(FIX.TagMDEntryPx, "70.2").Add(FIX.TagMDEntrySize, "5").
+		Add(FIX.TagMDEntryType, FIX.MDEntryTypeOffer).Add(FIX.TagMDEntryPx, "70.4").Add(FIX.TagMDEntrySize, "8"))
+	initiator.sync()
+	bestPrice := engine.getBestPrice("ATOM", "HKD")
+	if assert.NotNil(t, bestPrice) && assert.NotNil(t, bestPrice.Bid) && assert.NotNil(t, bestPrice.Ask) {
+		assert.Equal(t, "70.2", bestPrice.Bid.Price.String())
+		assert.Equal(t, "5", bestPrice.Bid.Amount.String())
+		assert.Equal(t, "70.4", bestPrice.Ask.Price.String())
+		assert.Equal(t, "FIXProviderA", bestPrice.Bid.Provider)
+	}
+
+	// Incremental refreshes change one side and deletes clear it
+	initiator.send(FIX.NewMessage(FIX.MsgTypeMarketDataIncrementalRefresh).
+		Add(FIX.TagNoMDEntries, "2").
+		Add(FIX.TagMDUpdateAction, FIX.MDUpdateActionChange).Add(FIX.TagMDEntryType, FIX.MDEntryTypeBid).
+		Add(FIX.TagSymbol, "ATOM/HKD").Add(FIX.TagMDEntryPx, "70.3").Add(FIX.TagMDEntrySize, "2").
+		Add(FIX.TagMDUpdateAction, FIX.MDUpdateActionDelete).Add(FIX.TagMDEntryType, FIX.MDEntryTypeOffer).
+		Add(FIX.TagSymbol, "ATOM/HKD"))
+	initiator.sync()
+	bestPrice = engine.getBestPrice("ATOM", "HKD")
+	if assert.NotNil(t, bestPrice) && assert.NotNil(t, bestPrice.Bid) {
+		assert.Equal(t, "70.3", bestPrice.Bid.Price.String())
+		assert.Nil(t, bestPrice.Ask)
+	}
+
+	// Invalid symbols are rejected without ending the session
+	initiator.send(FIX.NewMessage(FIX.MsgTypeMarketDataSnapshotFullRefresh).
+		Add(FIX.TagSymbol, "ATOMHKD").
+		Add(FIX.TagNoMDEntries, "1").
+		Add(FIX.TagMDEntryType, FIX.MDEntryTypeBid).Add(FIX.TagMDEntryPx, "70.1").Add(FIX.TagMDEntrySize, "10"))
+	response = initiator.read()
+	assert.Equal(t, FIX.MsgTypeBusinessMessageReject, response.MsgType())
+
+	// Prices that don't fit the instrument are rejected too
+	initiator.send(FIX.NewMessage(FIX.MsgTypeMarketDataSnapshotFullRefresh).
+		Add(FIX.TagSymbol, "ATOM/HKD").
+		Add(FIX.TagNoMDEntries, "1").
+		Add(FIX.TagMDEntryType, FIX.MDEntryTypeBid).Add(FIX.TagMDEntryPx, "70.123456").Add(FIX.TagMDEntrySize, "10"))
+	response = initiator.read()
+	assert.Equal(t, FIX.MsgTypeBusinessMessageReject, response.MsgType())
+
+	// A sequence gap asks for a resend
+	initiator.seqNum += 3
+	initiator.send(FIX.NewMessage(FIX.MsgTypeHeartbeat))
+	response = initiator.read()
+	assert.Equal(t, FIX.MsgTypeResendRequest, response.MsgType())
+	beginSeqNo, _ := response.GetInt(FIX.TagBeginSeqNo)
+	assert.Equal(t, initiator.seqNum-4, beginSeqNo)
+
+	// Resent messages below the expected sequence number are ignored
+	initiator.sendWithSeqNum(FIX.NewMessage(FIX.MsgTypeHeartbeat).Add(FIX.TagPossDupFlag, "Y"), beginSeqNo)
+	initiator.sync()
+
+	// Logout is acknowledged
+	initiator.send(FIX.NewMessage(FIX.MsgTypeLogout))
+	assert.Equal(t, FIX.MsgTypeLogout, initiator.read().MsgType())
+
+	// Sequence numbers carry over when reconnecting without a reset
+	reconnect := dialFIXInitiator(t, acceptor, "FIXProviderA")
+	defer reconnect.conn.Close()
+	reconnect.seqNum = initiator.seqNum
+	reconnect.send(FIX.NewMessage(FIX.MsgTypeLogon).Add(FIX.TagEncryptMethod, "0").Add(FIX.TagHeartBtInt, "30"))
+	response = reconnect.read()
+	assert.Equal(t, FIX.MsgTypeLogon, response.MsgType())
+	reconnect.sync()
+}
+
+func TestFIXAcceptorRejectsLogon(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	acceptor, err := engine.StartFIXAcceptor("127.0.0.1:0", "PRICEAPI")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acceptor.Close()
+
+	// Wrong TargetCompID
+	initiator := dialFIXInitiator(t, acceptor, "FIXProviderB")
+	defer initiator.conn.Close()
+	initiator.targetID = "OTHER"
+	initiator.send(FIX.NewMessage(FIX.MsgTypeLogon).Add(FIX.TagHeartBtInt, "30"))
+	response := initiator.read()
+	assert.Equal(t, FIX.MsgTypeLogout, response.MsgType())
+	text, _ := response.Get(FIX.TagText)
+	assert.Contains(t, text, "TargetCompID")
+
+	// Missing HeartBtInt
+	initiator = dialFIXInitiator(t, acceptor, "FIXProviderB")
+	defer initiator.conn.Close()
+	initiator.send(FIX.NewMessage(FIX.MsgTypeLogon))
+	response = initiator.read()
+	assert.Equal(t, FIX.MsgTypeLogout, response.MsgType())
+	text, _ = response.Get(FIX.TagText)
+	assert.Contains(t, text, "HeartBtInt")
+
+	// The first message must be a Logon
+	initiator = dialFIXInitiator(t, acceptor, "FIXProviderB")
+	defer initiator.conn.Close()
+	initiator.send(FIX.NewMessage(FIX.MsgTypeHeartbeat))
+	assert.Equal(t, FIX.MsgTypeLogout, initiator.read().MsgType())
+}
+
+func TestFIXAcceptorAuthentication(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestFIXAcceptorAuthentication")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	engine.ProviderAuthEnabled = true
+	credential, err := ProviderConfig.CreateCredential("FIXProviderC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCredential, err := ProviderConfig.CreateCredential("FIXProviderD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acceptor, err := engine.StartFIXAcceptor("127.0.0.1:0", "PRICEAPI")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acceptor.Close()
+
+	// The API key is sent as the Password
+	for _, password := range []string{"", "unknown.secret", otherCredential.APIKey()} {
+		initiator := dialFIXInitiator(t, acceptor, "FIXProviderC")
+		defer initiator.conn.Close()
+		logon := FIX.NewMessage(FIX.MsgTypeLogon).Add(FIX.TagEncryptMethod, "0").Add(FIX.TagHeartBtInt, "30")
+		if password != "" {
+			logon.Add(FIX.TagPassword, password)
+		}
+		initiator.send(logon)
+		assert.Equal(t, FIX.MsgTypeLogout, initiator.read().MsgType(), password)
+	}
+
+	initiator := dialFIXInitiator(t, acceptor, "FIXProviderC")
+	defer initiator.conn.Close()
+	initiator.send(FIX.NewMessage(FIX.MsgTypeLogon).
+		Add(FIX.TagEncryptMethod, "0").
+		Add(FIX.TagHeartBtInt, "30").
+		Add(FIX.TagPassword, credential.APIKey()))
+	assert.Equal(t, FIX.MsgTypeLogon, initiator.read().MsgType())
+}
@@ -0,0 +1,239 @@
+package PriceAPI
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/hongkongkiwi/chaostheory/src/PriceProto"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Most rejections returned at the end of a PublishPrices stream
+var MaxReportedRejections = 1000
+
+// PriceServiceServer implements the gRPC PriceService on top of the same validation and
+// best price selection as the HTTP handlers.
+type PriceServiceServer struct {
+	PriceProto.UnimplementedPriceServiceServer
+	engine *PriceEngine
+}
+
+// NewGRPCServer returns a gRPC server with the PriceService registered.
+func (engine *PriceEngine) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(opts...)
+	PriceProto.RegisterPriceServiceServer(server, &PriceServiceServer{engine: engine})
+	return server
+}
+
+// priceUpdateRequestFromProto converts a protobuf update, treating empty decimals as zero.
+func priceUpdateRequestFromProto(req *PriceProto.PriceUpdateRequest) (*PriceUpdateRequest, error) {
+	update := &PriceUpdateRequest{
+		Provider:  req.GetProvider(),
+		Base:      req.GetBase(),
+		Quote:     req.GetQuote(),
+		Timestamp: req.GetTimestamp(),
+		Sequence:  req.GetSequence(),
+	}
+	for _, field := range []struct {
+		name  string
+		value string
+		dest  *decimal.Decimal
+	}{
+		{"bid", req.GetBid(), &update.Bid},
+		{"bid_amount", req.GetBidAmount(), &update.BidAmount},
+		{"ask", req.GetAsk(), &update.Ask},
+		{"ask_amount", req.GetAskAmount(), &update.AskAmount},
+	} {
+		if field.value == "" {
+			continue
+		}
+		value, err := decimal.NewFromString(field.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a decimal: %q", field.name, field.value)
+		}
+		*field.dest = value
+	}
+	return update, nil
+}
+
+func priceUpdateToProto(update *PriceUpdate) *PriceProto.PriceUpdate {
+	if update == nil {
+		return nil
+	}
+	return &PriceProto.PriceUpdate{
+		Provider:  update.Provider,
+		Base:      update.Base,
+		Quote:     update.Quote,
+		Price:     update.Price.String(),
+		Amount:    update.Amount.String(),
+		Timestamp: update.Timestamp,
+	}
+}
+
+func streamMessageToProto(message *StreamMessage) *PriceProto.BestPriceMessage {
+	protoMessage := &PriceProto.BestPriceMessage{
+		Type:  message.Type,
+		Pair:  message.Pair,
+		Side:  message.Side,
+		Price: priceUpdateToProto(message.Price),
+	}
+	if len(message.Prices) > 0 {
+		protoMessage.Prices = make(map[string]*PriceProto.BestPrice, len(message.Prices))
+		for pairName, bestPrice := range message.Prices {
+			protoBestPrice := &PriceProto.BestPrice{
+				Base:  bestPrice.Base,
+				Quote: bestPrice.Quote,
+				Bid:   priceUpdateToProto(bestPrice.Bid),
+				Ask:   priceUpdateToProto(bestPrice.Ask),
+			}
+			if bestPrice.Spread != nil {
+				protoBestPrice.Spread = bestPrice.Spread.String()
+			}
+			protoMessage.Prices[pairName] = protoBestPrice
+		}
+	}
+	return protoMessage
+}
+
+// encodeProto returns the message as it is sent to gRPC subscribers.
+func (payload *streamPayload) encodeProto() *PriceProto.BestPriceMessage {
+	payload.protoOnce.Do(func() {
+		payload.proto = streamMessageToProto(payload.message)
+	})
+	return payload.proto
+}
+
+// statusCodeFromHTTP maps the status returned by validatePriceUpdateRequest to a gRPC code.
+func statusCodeFromHTTP(httpStatus int) codes.Code {
+	if httpStatus == http.StatusBadRequest {
+		return codes.InvalidArgument
+	}
+	// Out of order and duplicate updates
+	if httpStatus == http.StatusConflict {
+		return codes.Aborted
+	}
+	// Quotes quarantined as outliers
+	if httpStatus == http.StatusUnprocessableEntity {
+		return codes.FailedPrecondition
+	}
+	return codes.Internal
+}
+
+// validateProtoUpdate converts and validates a protobuf update from the authenticated provider.
+func (engine *PriceEngine) validateProtoUpdate(providerName string, req *PriceProto.PriceUpdateRequest) (*PriceUpdateRequest, error) {
+	update, err := priceUpdateRequestFromProto(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := checkProviderIdentity(providerName, update); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if _, err := engine.checkRateLimit(update); err != nil {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	update.ReceivedAt = engine.now()
+	if httpStatus, err := engine.validatePriceUpdateRequest(update); err != nil {
+		return nil, status.Error(statusCodeFromHTTP(httpStatus), err.Error())
+	}
+	return update, nil
+}
+
+// PublishPrice validates and applies a single price update.
+func (s *PriceServiceServer) PublishPrice(ctx context.Context, req *PriceProto.PriceUpdateRequest) (*PriceProto.PriceUpdateResult, error) {
+	providerName, err := s.engine.authenticateGRPC(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !s.engine.reserveIngestQueue(1) {
+		s.engine.recordQueueFull(req.GetProvider())
+		return nil, status.Error(codes.Unavailable, "ingest queue is full")
+	}
+	update, err := s.engine.validateProtoUpdate(providerName, req)
+	if err != nil {
+		s.engine.releaseIngestQueue(1)
+		return nil, err
+	}
+	if !s.engine.enqueuePriceUpdates([]*PriceUpdateRequest{update}, false) {
+		return nil, status.Error(codes.Unavailable, "price engine is closed")
+	}
+	return &PriceProto.PriceUpdateResult{
+		Provider: update.Provider,
+		Pair:     update.GetPairName(),
+		Accepted: true,
+	}, nil
+}
+
+// PublishPrices queues each update as it arrives on the stream so a long lived
+// connection sees the same latency as unary calls. Updates from one stream are
+// applied in order for each pair.
+func (s *PriceServiceServer) PublishPrices(stream PriceProto.PriceService_PublishPricesServer) error {
+	// The stream is authenticated once when it is opened
+	providerName, err := s.engine.authenticateGRPC(stream.Context())
+	if err != nil {
+		return err
+	}
+	response := &PriceProto.PublishPricesResponse{}
+	for index := int64(0); ; index++ {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(response)
+		}
+		if err != nil {
+			return err
+		}
+
+		var update *PriceUpdateRequest
+		if s.engine.reserveIngestQueue(1) {
+			if update, err = s.engine.validateProtoUpdate(providerName, req); err != nil {
+				s.engine.releaseIngestQueue(1)
+			}
+		} else {
+			s.engine.recordQueueFull(req.GetProvider())
+			err = status.Error(codes.Unavailable, "ingest queue is full")
+		}
+		if err != nil {
+			response.Rejected++
+			if len(response.Rejections) < MaxReportedRejections {
+				response.Rejections = append(response.Rejections, &PriceProto.PriceUpdateResult{
+					Index:    index,
+					Provider: req.GetProvider(),
+					Pair:     fmt.Sprintf("%s/%s", req.GetBase(), req.GetQuote()),
+					Error:    status.Convert(err).Message(),
+				})
+			}
+			continue
+		}
+		if !s.engine.enqueuePriceUpdates([]*PriceUpdateRequest{update}, false) {
+			return status.Error(codes.Unavailable, "price engine is closed")
+		}
+		response.Accepted++
+	}
+}
+
+// SubscribeBestPrices streams a snapshot followed by best price changes. Subscribers
+// share the WebSocket stream hub so they get the same pair filtering and slow
+// consumers are disconnected in the same way.
+func (s *PriceServiceServer) SubscribeBestPrices(req *PriceProto.SubscribeBestPricesRequest, stream PriceProto.PriceService_SubscribeBestPricesServer) error {
+	client := newStreamClient(nil)
+	s.engine.streamHub.register(client, req.GetPairs(), s.engine.getBestPrices)
+	defer s.engine.streamHub.unregister(client)
+
+	for {
+		select {
+		case payload, ok := <-client.send:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "disconnected for falling behind")
+			}
+			if err := stream.Send(payload.encodeProto()); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package PriceAPI
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/PriceProto"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestPriceServiceServer(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestPriceServiceServer")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("DOT", "CAD"))
+	ProviderConfig.SetPairEnabled("ProviderA", "DOT/CAD", true)
+	ProviderConfig.SetPairEnabled("ProviderB", "DOT/CAD", true)
+
+	// Serve in memory
+	listener := bufconn.Listen(1024 * 1024)
+	server := engine.NewGRPCServer()
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := PriceProto.NewPriceServiceClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	subscription, err := client.SubscribeBestPrices(ctx, &PriceProto.SubscribeBestPricesRequest{Pairs: []string{"DOT/CAD"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := subscription.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "snapshot", message.Type)
+
+	// Unary publish
+	result, err := client.PublishPrice(ctx, &PriceProto.PriceUpdateRequest{
+		Provider: "ProviderA", Base: "DOT", Quote: "CAD", Bid: "7.1", BidAmount: "100", Ask: "7.2", AskAmount: "100", Timestamp: time.Now().UnixMilli(),
+	})
+	if assert.Nil(t, err) {
+		assert.True(t, result.Accepted)
+		assert.Equal(t, "DOT/CAD", result.Pair)
+	}
+	_, err = client.PublishPrice(ctx, &PriceProto.PriceUpdateRequest{Provider: "ProviderA", Base: "DOT", Quote: "CAD", Bid: "abc"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Subscribers see the new best prices
+	sides := make(map[string]string)
+	for len(sides) < 2 {
+		message, err := subscription.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "update", message.Type)
+		sides[message.Side] = message.Price.Price
+	}
+	assert.Equal(t, map[string]string{"Bid": "7.1", "Ask": "7.2"}, sides)
+
+	// Client streaming publish
+	publishStream, err := client.PublishPrices(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publishStream.Send(&PriceProto.PriceUpdateRequest{Provider: "ProviderB", Base: "DOT", Quote: "CAD", Bid: "7.15", BidAmount: "10", Ask: "7.25", AskAmount: "10", Timestamp: time.Now().UnixMilli()})
+	publishStream.Send(&PriceProto.PriceUpdateRequest{Provider: "ProviderB", Base: "DOT", Quote: "NZD", Bid: "7.15", Ask: "7.25"})
+	response, err := publishStream.CloseAndRecv()
+	if assert.Nil(t, err) {
+		assert.Equal(t, int64(1), response.Accepted)
+		assert.Equal(t, int64(1), response.Rejected)
+		assert.Equal(t, int64(1), response.Rejections[0].Index)
+	}
+
+	message, err = subscription.Recv()
+	if assert.Nil(t, err) {
+		assert.Equal(t, "Bid", message.Side)
+		assert.Equal(t, "ProviderB", message.Price.Provider)
+	}
+}
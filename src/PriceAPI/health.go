@@ -0,0 +1,331 @@
+package PriceAPI
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+)
+
+// Health scores run from 100 (healthy) to 0. Each component is a rolling 0 to 1 penalty
+// and the weights say how much of the score it can take away.
+const (
+	healthStalenessWeight = 25
+	healthRejectWeight    = 25
+	healthGapWeight       = 15
+	healthLatencyWeight   = 15
+	healthCrossedWeight   = 20
+	// How quickly the rolling penalties move, per update for rejects, gaps and latency
+	// and per health check for staleness and time crossed
+	healthUpdateSmoothing = 0.05
+	healthCheckSmoothing  = 0.1
+)
+
+// Pairs scoring below HealthDisableThreshold are disabled when an engine's
+// HealthAutoDisable is on, then re-enabled once they score HealthReEnableThreshold
+// after the cool down
+var (
+	HealthDisableThreshold  = 50.0
+	HealthReEnableThreshold = 75.0
+	HealthCooldown          = 5 * time.Minute
+	// Fewest updates before a pair can be disabled, so one bad update can't do it
+	HealthMinUpdates = uint64(10)
+	// Quotes older than this count as stale even if their pair has no quote TTL
+	HealthStaleAfter = 30 * time.Second
+	// Latency at or above this takes the full latency weight
+	HealthMaxLatency = time.Second
+)
+
+// ProviderHealth is the current health score of a provider's pair and what makes it up.
+type ProviderHealth struct {
+	Provider string  `json:"provider"`
+	Pair     string  `json:"pair"`
+	Score    float64 `json:"score"`
+	Updates  uint64  `json:"updates"`
+	// Rolling share of health checks the quote was stale for
+	Staleness float64 `json:"staleness"`
+	// Rolling share of updates that were rejected
+	RejectRate float64 `json:"reject_rate"`
+	// Rolling share of updates that followed a sequence gap
+	GapRate   float64 `json:"gap_rate"`
+	LatencyMs float64 `json:"latency_ms"`
+	// Rolling share of health checks the quote crossed another provider's best price
+	CrossedRatio   float64    `json:"crossed_ratio"`
+	Enabled        bool       `json:"enabled"`
+	AutoDisabled   bool       `json:"auto_disabled"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+}
+
+// pairHealth holds the rolling health penalties for a provider's pair
+type pairHealth struct {
+	updates      uint64
+	staleness    float64
+	rejectRate   float64
+	gapRate      float64
+	latencyMs    float64
+	crossedRatio float64
+}
+
+func smooth(average float64, sample float64, smoothing float64) float64 {
+	return average + (sample-average)*smoothing
+}
+
+func (engine *PriceEngine) getPairHealth(providerName string, pairName string) *pairHealth {
+	if engine.healthRecords[providerName] == nil {
+		engine.healthRecords[providerName] = make(map[string]*pairHealth)
+	}
+	health := engine.healthRecords[providerName][pairName]
+	if health == nil {
+		health = &pairHealth{}
+		engine.healthRecords[providerName][pairName] = health
+	}
+	return health
+}
+
+// recordHealthUpdate adds an accepted or rejected update to its pair's rolling health.
+func (engine *PriceEngine) recordHealthUpdate(update *PriceUpdateRequest, accepted bool) {
+	engine.healthMu.Lock()
+	defer engine.healthMu.Unlock()
+	health := engine.getPairHealth(update.Provider, update.GetPairName())
+	health.updates++
+	if !accepted {
+		health.rejectRate = smooth(health.rejectRate, 1, healthUpdateSmoothing)
+		return
+	}
+	health.rejectRate = smooth(health.rejectRate, 0, healthUpdateSmoothing)
+	gap := 0.0
+	if update.sequenceGap {
+		gap = 1
+	}
+	health.gapRate = smooth(health.gapRate, gap, healthUpdateSmoothing)
+	// A provider clock running ahead is not latency
+	latencyMs := math.Max(0, float64(update.ReceivedAt.UnixMilli()-update.Timestamp))
+	health.latencyMs = smooth(health.latencyMs, latencyMs, healthUpdateSmoothing)
+}
+
+// score turns the rolling penalties into a score from 0 to 100.
+func (health *pairHealth) score() float64 {
+	latency := math.Min(1, health.latencyMs/float64(HealthMaxLatency.Milliseconds()))
+	score := 100 -
+		healthStalenessWeight*health.staleness -
+		healthRejectWeight*health.rejectRate -
+		healthGapWeight*health.gapRate -
+		healthLatencyWeight*latency -
+		healthCrossedWeight*health.crossedRatio
+	return math.Max(0, math.Min(100, score))
+}
+
+// describe lists the penalties dragging a score down for the disabled reason.
+func (health *pairHealth) describe() string {
+	return fmt.Sprintf("health score %.1f: staleness %.2f, reject rate %.2f, gap rate %.2f, latency %.0fms, crossed %.2f",
+		health.score(), health.staleness, health.rejectRate, health.gapRate, health.latencyMs, health.crossedRatio)
+}
+
+// getProviderUpdateRequest returns a provider's last update for a pair.
+func (engine *PriceEngine) getProviderUpdateRequest(providerName string, pairName string) *PriceUpdateRequest {
+	book := engine.getBook(pairName)
+	if book == nil {
+		return nil
+	}
+	book.mu.Lock()
+	defer book.mu.Unlock()
+	return book.quotes[providerName]
+}
+
+// isQuoteCrossed reports whether a quote's bid is at or above another provider's best
+// ask, or its ask is at or below another provider's best bid.
+func (engine *PriceEngine) isQuoteCrossed(update *PriceUpdateRequest) bool {
+	pairName := update.GetPairName()
+	if bestAsk := engine.GetBestAskPrice(pairName); bestAsk != nil && bestAsk.Provider != update.Provider &&
+		update.Bid.IsPositive() && update.Bid.GreaterThanOrEqual(bestAsk.Price) {
+		return true
+	}
+	if bestBid := engine.GetBestBidPrice(pairName); bestBid != nil && bestBid.Provider != update.Provider &&
+		update.Ask.IsPositive() && update.Ask.LessThanOrEqual(bestBid.Price) {
+		return true
+	}
+	return false
+}
+
+// checkHealth samples every pair's staleness and whether it is crossed, then disables
+// unhealthy pairs and re-enables recovered ones if HealthAutoDisable is on. Returns once
+// the pairs it changed have been recalculated.
+func (engine *PriceEngine) checkHealth(now time.Time) {
+	type pairKey struct{ provider, pair string }
+	quotes := make(map[pairKey]*PriceUpdateRequest)
+	engine.healthMu.Lock()
+	for providerName, pairs := range engine.healthRecords {
+		for pairName := range pairs {
+			quotes[pairKey{providerName, pairName}] = nil
+		}
+	}
+	engine.healthMu.Unlock()
+
+	// Look everything up before taking the health lock again
+	crossed := make(map[pairKey]bool, len(quotes))
+	for key := range quotes {
+		quotes[key] = engine.getProviderUpdateRequest(key.provider, key.pair)
+		if quotes[key] != nil {
+			crossed[key] = engine.isQuoteCrossed(quotes[key])
+		}
+	}
+
+	scores := make(map[pairKey]*pairHealth, len(quotes))
+	engine.healthMu.Lock()
+	for key, quote := range quotes {
+		health := engine.getPairHealth(key.provider, key.pair)
+		stale := 1.0
+		if quote != nil && !engine.isQuoteStale(quote, now) && now.Sub(quote.ReceivedAt) < HealthStaleAfter {
+			stale = 0
+		}
+		health.staleness = smooth(health.staleness, stale, healthCheckSmoothing)
+		crossedSample := 0.0
+		if crossed[key] {
+			crossedSample = 1
+		}
+		health.crossedRatio = smooth(health.crossedRatio, crossedSample, healthCheckSmoothing)
+		copied := *health
+		scores[key] = &copied
+	}
+	engine.healthMu.Unlock()
+
+	if !engine.HealthAutoDisable {
+		return
+	}
+	autoDisabled, err := ProviderConfig.GetAutoDisabledPairs()
+	if err != nil {
+		fmt.Println("Error loading automatically disabled pairs:", err)
+		return
+	}
+	recalculate := make([]string, 0)
+	for key, health := range scores {
+		score := health.score()
+		if disabled := autoDisabled[key.provider][key.pair]; disabled != nil {
+			if now.Sub(disabled.DisabledAt) >= HealthCooldown && score >= HealthReEnableThreshold {
+				fmt.Printf("Re-enabling %s for %s, %s\n", key.pair, key.provider, health.describe())
+				if err := ProviderConfig.AutoReEnablePair(key.provider, key.pair); err != nil {
+					fmt.Println("Error re-enabling pair:", err)
+					continue
+				}
+				recalculate = append(recalculate, key.pair)
+			}
+			continue
+		}
+		if health.updates < HealthMinUpdates || score >= HealthDisableThreshold {
+			continue
+		}
+		// Pairs disabled by hand are left alone
+		if enabled, err := engine.Eligibility.GetProviderPairEnabled(key.provider, key.pair); err != nil || !enabled {
+			continue
+		}
+		reason := health.describe()
+		fmt.Printf("Disabling %s for %s, %s\n", key.pair, key.provider, reason)
+		if err := ProviderConfig.AutoDisablePair(key.provider, key.pair, reason); err != nil {
+			fmt.Println("Error disabling pair:", err)
+			continue
+		}
+		recalculate = append(recalculate, key.pair)
+	}
+	engine.enqueueRecalculation(context.Background(), recalculate, engine.now(), true)
+}
+
+// StartHealthMonitor checks provider health every interval until the returned stop
+// function is called.
+func (engine *PriceEngine) StartHealthMonitor(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				engine.checkHealth(engine.now())
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// getProviderHealth returns the health of every pair, optionally for a single provider,
+// sorted by provider then pair.
+func (engine *PriceEngine) getProviderHealth(providerName string) ([]*ProviderHealth, error) {
+	autoDisabled, err := ProviderConfig.GetAutoDisabledPairs()
+	if err != nil {
+		return nil, err
+	}
+
+	engine.healthMu.Lock()
+	results := make([]*ProviderHealth, 0)
+	for healthProvider, pairs := range engine.healthRecords {
+		if providerName != "" && providerName != healthProvider {
+			continue
+		}
+		for pairName, health := range pairs {
+			results = append(results, &ProviderHealth{
+				Provider:     healthProvider,
+				Pair:         pairName,
+				Score:        math.Round(health.score()*10) / 10,
+				Updates:      health.updates,
+				Staleness:    health.staleness,
+				RejectRate:   health.rejectRate,
+				GapRate:      health.gapRate,
+				LatencyMs:    health.latencyMs,
+				CrossedRatio: health.crossedRatio,
+			})
+		}
+	}
+	engine.healthMu.Unlock()
+
+	for _, result := range results {
+		result.Enabled, _ = engine.Eligibility.GetProviderPairEnabled(result.Provider, result.Pair)
+		if disabled := autoDisabled[result.Provider][result.Pair]; disabled != nil {
+			result.AutoDisabled = true
+			result.DisabledReason = disabled.Reason
+			result.DisabledAt = &disabled.DisabledAt
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Provider != results[j].Provider {
+			return results[i].Provider < results[j].Provider
+		}
+		return results[i].Pair < results[j].Pair
+	})
+	return results, nil
+}
+
+// GetHealthScores returns the health of every provider's pairs.
+func (engine *PriceEngine) GetHealthScores(c *gin.Context) {
+	results, err := engine.getProviderHealth("")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// GetProviderHealthScores returns the health of a single provider's pairs.
+func (engine *PriceEngine) GetProviderHealthScores(c *gin.Context) {
+	providerName := c.Param("providerName")
+	results, err := engine.getProviderHealth(providerName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(results) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no updates received from %s", providerName)})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,122 @@
+package PriceAPI
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderHealth(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestProviderHealth")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("ALGO", "CZK"))
+	ProviderConfig.SetPairEnabled("HealthProviderA", "ALGO/CZK", true)
+	ProviderConfig.SetPairEnabled("HealthProviderB", "ALGO/CZK", true)
+
+	disableOutlierFilter(t, engine)
+
+	engine.HealthAutoDisable = true
+	HealthMinUpdates = 5
+	HealthDisableThreshold = 95
+	defer func() {
+		HealthMinUpdates = 10
+		HealthDisableThreshold = 50
+		HealthReEnableThreshold = 75
+		HealthCooldown = 5 * time.Minute
+	}()
+
+	router := gin.Default()
+	router.POST("/prices", engine.ProcessPriceUpdateRequest)
+	router.GET("/health/scores/:providerName", engine.GetProviderHealthScores)
+
+	timestamp := time.Now().UnixMilli()
+	send := func(provider string, bid string, ask string) int {
+		timestamp++
+		rr := httptest.NewRecorder()
+		body := `{"provider":"` + provider + `","base":"ALGO","quote":"CZK","bid":"` + bid + `","bid_amount":"1","ask":"` + ask + `","ask_amount":"1","timestamp":` + strconv.FormatInt(timestamp, 10) + `}`
+		req, _ := http.NewRequest("POST", "/prices", bytes.NewBufferString(body))
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	waitForBestBid := func(price string) {
+		assert.Eventually(t, func() bool {
+			bestBid := engine.GetBestBidPrice("ALGO/CZK")
+			return bestBid != nil && bestBid.Price.String() == price
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	// A's bid crosses B's ask, then it sends a run of crossed quotes that are rejected
+	assert.Equal(t, http.StatusOK, send("HealthProviderB", "9", "10"))
+	waitForBestBid("9")
+	assert.Equal(t, http.StatusOK, send("HealthProviderA", "11", "12"))
+	waitForBestBid("11")
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, http.StatusBadRequest, send("HealthProviderA", "12", "11"))
+	}
+
+	engine.checkHealth(time.Now())
+	enabled, _ := ProviderConfig.GetProviderPairEnabled("HealthProviderA", "ALGO/CZK")
+	assert.False(t, enabled)
+	// B hasn't sent enough updates to be judged
+	enabled, _ = ProviderConfig.GetProviderPairEnabled("HealthProviderB", "ALGO/CZK")
+	assert.True(t, enabled)
+	// The book is rebuilt without A straight away
+	assert.Equal(t, "9", engine.GetBestBidPrice("ALGO/CZK").Price.String())
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/health/scores/HealthProviderA", nil)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var scores []*ProviderHealth
+	if err := json.Unmarshal(rr.Body.Bytes(), &scores); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Equal(t, 1, len(scores)) {
+		assert.Equal(t, uint64(11), scores[0].Updates)
+		assert.InDelta(t, 0.40, scores[0].RejectRate, 0.01)
+		assert.InDelta(t, 0.1, scores[0].CrossedRatio, 0.001)
+		assert.Less(t, scores[0].Score, 95.0)
+		assert.False(t, scores[0].Enabled)
+		assert.True(t, scores[0].AutoDisabled)
+		assert.Contains(t, scores[0].DisabledReason, "reject rate 0.40")
+	}
+
+	// Still in its cool down
+	HealthReEnableThreshold = 50
+	engine.checkHealth(time.Now())
+	enabled, _ = ProviderConfig.GetProviderPairEnabled("HealthProviderA", "ALGO/CZK")
+	assert.False(t, enabled)
+
+	HealthCooldown = 0
+	engine.checkHealth(time.Now())
+	enabled, _ = ProviderConfig.GetProviderPairEnabled("HealthProviderA", "ALGO/CZK")
+	assert.True(t, enabled)
+	assert.Equal(t, "11", engine.GetBestBidPrice("ALGO/CZK").Price.String())
+
+	rr = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/health/scores/UnknownProvider", nil)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
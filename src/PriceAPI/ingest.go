@@ -0,0 +1,109 @@
+package PriceAPI
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Most updates that can be waiting to be applied, the queue can't be made bigger than this
+const ingestQueueCapacity = 10000
+
+// IngestStats counts a provider's updates that were turned away before validation.
+type IngestStats struct {
+	RateLimited uint64 `json:"rate_limited"`
+	QueueFull   uint64 `json:"queue_full"`
+}
+
+// IngestQueueStats is how full the ingest queue is along with every provider's rejections.
+type IngestQueueStats struct {
+	Queued int64 `json:"queued"`
+	Limit  int64 `json:"limit"`
+	// Updates and recalculations waiting on each worker
+	Shards    []int                  `json:"shards"`
+	Providers map[string]IngestStats `json:"providers"`
+}
+
+// Updates reserved or waiting in every engine's queue, for the queue metric
+var queuedUpdatesTotal atomic.Int64
+
+// SetIngestQueueLimit sets how many updates can be waiting to be applied before new
+// updates are rejected with a 503.
+func (engine *PriceEngine) SetIngestQueueLimit(limit int) {
+	engine.ingestQueueLimit.Store(int64(min(max(limit, 0), ingestQueueCapacity)))
+}
+
+// reserveIngestQueue reserves space for updates in the queue, returning false if it is
+// full. Space that isn't used must be given back with releaseIngestQueue.
+func (engine *PriceEngine) reserveIngestQueue(count int) bool {
+	if engine.queuedUpdates.Add(int64(count)) > engine.ingestQueueLimit.Load() {
+		engine.queuedUpdates.Add(-int64(count))
+		return false
+	}
+	queuedUpdatesTotal.Add(int64(count))
+	return true
+}
+
+func (engine *PriceEngine) releaseIngestQueue(count int) {
+	engine.queuedUpdates.Add(-int64(count))
+	queuedUpdatesTotal.Add(-int64(count))
+}
+
+// recordQueueFull counts an update rejected because the queue was full.
+func (engine *PriceEngine) recordQueueFull(providerName string) {
+	recordUpdateRejected(providerName, RejectQueueFull)
+	engine.recordIngestRejection(providerName, func(stats *IngestStats) { stats.QueueFull++ })
+}
+
+func (engine *PriceEngine) recordIngestRejection(providerName string, count func(*IngestStats)) {
+	engine.ingestStatsMu.Lock()
+	defer engine.ingestStatsMu.Unlock()
+	stats := engine.ingestStats[providerName]
+	if stats == nil {
+		stats = &IngestStats{}
+		engine.ingestStats[providerName] = stats
+	}
+	count(stats)
+}
+
+func (engine *PriceEngine) getShardLengths() []int {
+	lengths := make([]int, len(engine.ingestShards))
+	for i, shard := range engine.ingestShards {
+		lengths[i] = len(shard)
+	}
+	return lengths
+}
+
+// getIngestStats returns a copy of the rejection counters for every provider.
+func (engine *PriceEngine) getIngestStats() map[string]IngestStats {
+	engine.ingestStatsMu.Lock()
+	defer engine.ingestStatsMu.Unlock()
+	stats := make(map[string]IngestStats, len(engine.ingestStats))
+	for providerName, providerStats := range engine.ingestStats {
+		stats[providerName] = *providerStats
+	}
+	return stats
+}
+
+// GetIngestStats returns how full the ingest queue is and every provider's rejections.
+func (engine *PriceEngine) GetIngestStats(c *gin.Context) {
+	c.JSON(http.StatusOK, &IngestQueueStats{
+		Queued:    engine.queuedUpdates.Load(),
+		Limit:     engine.ingestQueueLimit.Load(),
+		Shards:    engine.getShardLengths(),
+		Providers: engine.getIngestStats(),
+	})
+}
+
+// GetProviderIngestStats returns the rejection counters for a single provider.
+func (engine *PriceEngine) GetProviderIngestStats(c *gin.Context) {
+	providerName := c.Param("providerName")
+	stats, ok := engine.getIngestStats()[providerName]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no updates rejected from %s", providerName)})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
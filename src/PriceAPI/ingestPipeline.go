@@ -0,0 +1,143 @@
+package PriceAPI
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Number of ingest workers each engine starts. Every pair belongs to one worker so its
+// updates and recalculations are applied in the order they were queued.
+var IngestWorkers = runtime.GOMAXPROCS(0)
+
+// Values of the ack query param, accepted replies once an update is validated and
+// queued, applied once it has been used to recalculate the best price
+const (
+	AckAccepted = "accepted"
+	AckApplied  = "applied"
+)
+
+// ingestTask is a pair's validated updates or, when updates is nil, a recalculation
+// of the pair's best prices
+type ingestTask struct {
+	ctx      context.Context
+	pairName string
+	updates  []*PriceUpdateRequest
+	// Quotes that have expired by then are left out of a recalculation
+	now time.Time
+	// Closed once the task has been applied, nil when nobody is waiting
+	done chan struct{}
+}
+
+// startIngestWorkers starts a worker for each shard, they run until the engine is closed.
+func (engine *PriceEngine) startIngestWorkers(workers int) {
+	engine.ingestShards = make([]chan *ingestTask, max(workers, 1))
+	for i := range engine.ingestShards {
+		// Each shard can hold every reserved update so queueing them never blocks
+		engine.ingestShards[i] = make(chan *ingestTask, ingestQueueCapacity)
+		go engine.processIngestShard(engine.ingestShards[i])
+	}
+}
+
+func (engine *PriceEngine) ingestShard(pairName string) chan *ingestTask {
+	hash := fnv.New32a()
+	hash.Write([]byte(pairName))
+	return engine.ingestShards[hash.Sum32()%uint32(len(engine.ingestShards))]
+}
+
+// processIngestShard applies a shard's tasks one at a time until the engine is closed
+func (engine *PriceEngine) processIngestShard(shard chan *ingestTask) {
+	for {
+		select {
+		case task := <-shard:
+			if task.updates != nil {
+				engine.applyPriceUpdates(task.updates)
+				engine.releaseIngestQueue(len(task.updates))
+			} else {
+				engine.recalculateBestPricesForPair(task.ctx, task.pairName, task.now)
+			}
+			if task.done != nil {
+				close(task.done)
+			}
+		case <-engine.stopIngest:
+			return
+		}
+	}
+}
+
+// submitIngestTasks queues tasks on their pairs' shards. When wait is true it returns
+// once they have all been applied, false means the engine was closed first.
+func (engine *PriceEngine) submitIngestTasks(tasks []*ingestTask, wait bool) bool {
+	for _, task := range tasks {
+		if wait {
+			task.done = make(chan struct{})
+		}
+		select {
+		case engine.ingestShard(task.pairName) <- task:
+		case <-engine.stopIngest:
+			return false
+		}
+	}
+	if !wait {
+		return true
+	}
+	for _, task := range tasks {
+		select {
+		case <-task.done:
+		case <-engine.stopIngest:
+			return false
+		}
+	}
+	return true
+}
+
+// enqueuePriceUpdates queues updates that space has been reserved for, split by pair.
+// Each pair's updates are applied in the order they are given.
+func (engine *PriceEngine) enqueuePriceUpdates(updates []*PriceUpdateRequest, wait bool) bool {
+	tasks := make([]*ingestTask, 0, 1)
+	pairTasks := make(map[string]*ingestTask)
+	for _, update := range updates {
+		pairName := update.GetPairName()
+		task := pairTasks[pairName]
+		if task == nil {
+			task = &ingestTask{pairName: pairName}
+			pairTasks[pairName] = task
+			tasks = append(tasks, task)
+		}
+		task.updates = append(task.updates, update)
+	}
+	return engine.submitIngestTasks(tasks, wait)
+}
+
+// enqueueRecalculation queues a best price recalculation for each pair behind any
+// updates already queued for it, quotes that have expired by now are left out.
+func (engine *PriceEngine) enqueueRecalculation(ctx context.Context, pairNames []string, now time.Time, wait bool) bool {
+	tasks := make([]*ingestTask, 0, len(pairNames))
+	for _, pairName := range pairNames {
+		tasks = append(tasks, &ingestTask{ctx: ctx, pairName: pairName, now: now})
+	}
+	return engine.submitIngestTasks(tasks, wait)
+}
+
+// getAck reads the ack query param, returning true if the caller wants to wait until
+// its updates have been applied.
+func getAck(c *gin.Context) (bool, error) {
+	switch ack := c.Query("ack"); ack {
+	case "", AckAccepted:
+		return false, nil
+	case AckApplied:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown ack %q, must be %s or %s", ack, AckAccepted, AckApplied)
+	}
+}
+
+// abortEngineClosed replies to a caller that was waiting when the engine was closed.
+func abortEngineClosed(c *gin.Context) {
+	c.JSON(http.StatusServiceUnavailable, gin.H{"error": "price engine is closed"})
+}
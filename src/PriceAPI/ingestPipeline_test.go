@@ -0,0 +1,111 @@
+package PriceAPI
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIngestPipelineOrdering(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	engine.Eligibility = stubEligibility{"PipelineProviderA": true, "PipelineProviderB": true}
+
+	// Updates for a pair are applied in the order they were queued, however many pairs share the workers
+	pairs := []string{"XTZ/BRL", "XTZ/CLP", "XTZ/COP", "XTZ/PEN"}
+	for i := 1; i <= 200; i++ {
+		for _, pairName := range pairs {
+			assert.True(t, engine.reserveIngestQueue(1))
+			engine.enqueuePriceUpdates([]*PriceUpdateRequest{{Provider: "PipelineProviderA", Base: "XTZ", Quote: pairName[4:], Bid: decimal.NewFromInt(int64(i)), BidAmount: decimal.NewFromInt(1), Timestamp: int64(i)}}, false)
+		}
+	}
+	// A recalculation is applied after the updates queued before it
+	assert.True(t, engine.enqueueRecalculation(context.Background(), pairs, engine.now(), true))
+	for _, pairName := range pairs {
+		assert.Equal(t, "200", engine.GetBestBidPrice(pairName).Price.String())
+	}
+	assert.Equal(t, int64(0), engine.queuedUpdates.Load())
+
+	// Disabling a provider only takes effect for the pair once it is recalculated
+	engine.Eligibility = stubEligibility{"PipelineProviderB": true}
+	assert.Equal(t, "PipelineProviderA", engine.GetBestBidPrice("XTZ/BRL").Provider)
+	assert.True(t, engine.enqueueRecalculation(context.Background(), []string{"XTZ/BRL"}, engine.now(), true))
+	assert.Nil(t, engine.GetBestBidPrice("XTZ/BRL"))
+
+	// Waiting callers are released when the engine is closed
+	engine.Close()
+	assert.False(t, engine.enqueueRecalculation(context.Background(), pairs, engine.now(), true))
+}
+
+func TestPriceUpdateAck(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestPriceUpdateAck")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("XTZ", "ARS"))
+	ProviderConfig.SetPairEnabled("PipelineProviderA", "XTZ/ARS", true)
+
+	router := gin.Default()
+	router.POST("/prices", engine.ProcessPriceUpdateRequest)
+	router.POST("/prices/batch", engine.ProcessPriceUpdateBatchRequest)
+	router.PUT("/prices/recalculate", engine.ReCalculateBestPrices)
+
+	send := func(method string, path string, body string) int {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	timestamp := time.Now().UnixMilli()
+	newUpdate := func(bid int) string {
+		timestamp++
+		return `{"provider":"PipelineProviderA","base":"XTZ","quote":"ARS","bid":"` + strconv.Itoa(bid) + `","bid_amount":"1","timestamp":` + strconv.FormatInt(timestamp, 10) + `}`
+	}
+
+	// With ack=applied the best price has been updated by the time the reply is sent
+	for bid := 100; bid < 110; bid++ {
+		assert.Equal(t, http.StatusOK, send("POST", "/prices?ack=applied", newUpdate(bid)))
+		assert.Equal(t, strconv.Itoa(bid), engine.GetBestBidPrice("XTZ/ARS").Price.String())
+	}
+	assert.Equal(t, http.StatusOK, send("POST", "/prices/batch?ack=applied", "["+newUpdate(120)+","+newUpdate(121)+"]"))
+	assert.Equal(t, "121", engine.GetBestBidPrice("XTZ/ARS").Price.String())
+
+	ProviderConfig.SetPairEnabled("PipelineProviderA", "XTZ/ARS", false)
+	assert.Equal(t, http.StatusOK, send("PUT", "/prices/recalculate?ack=applied", ""))
+	assert.Nil(t, engine.GetBestBidPrice("XTZ/ARS"))
+
+	// The default only waits for the update to be queued
+	ProviderConfig.SetPairEnabled("PipelineProviderA", "XTZ/ARS", true)
+	assert.Equal(t, http.StatusOK, send("POST", "/prices?ack=accepted", newUpdate(130)))
+	assert.Eventually(t, func() bool {
+		bestBid := engine.GetBestBidPrice("XTZ/ARS")
+		return bestBid != nil && bestBid.Price.String() == "130"
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/prices?ack=later", newUpdate(140)))
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/prices/batch?ack=later", "["+newUpdate(140)+"]"))
+	assert.Equal(t, http.StatusBadRequest, send("PUT", "/prices/recalculate?ack=later", ""))
+}
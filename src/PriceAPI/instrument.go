@@ -0,0 +1,36 @@
+package PriceAPI
+
+import (
+	"fmt"
+
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+)
+
+// validateInstrumentUpdate checks a price update against its instrument. Only sides with a
+// price are checked, a zero bid or ask means the provider isn't quoting that side.
+func validateInstrumentUpdate(instrument *ProviderConfig.Instrument, update *PriceUpdateRequest) error {
+	pairName := update.GetPairName()
+	if instrument == nil {
+		return fmt.Errorf("instrument %s is not registered", pairName)
+	}
+	if !instrument.Active {
+		return fmt.Errorf("instrument %s is not active", pairName)
+	}
+	if update.Bid.IsPositive() {
+		if err := instrument.ValidatePrice(update.Bid); err != nil {
+			return fmt.Errorf("bid: %w", err)
+		}
+		if err := instrument.ValidateAmount(update.BidAmount); err != nil {
+			return fmt.Errorf("bid_amount: %w", err)
+		}
+	}
+	if update.Ask.IsPositive() {
+		if err := instrument.ValidatePrice(update.Ask); err != nil {
+			return fmt.Errorf("ask: %w", err)
+		}
+		if err := instrument.ValidateAmount(update.AskAmount); err != nil {
+			return fmt.Errorf("ask_amount: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package PriceAPI
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpgradedDatabaseAcceptsUpdates(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestUpgradedDatabaseAcceptsUpdates")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	defer os.Remove(tmpDBFileName.Name())
+
+	// A database from before instruments, with providers and pairs but no instruments table
+	oldDB, err := sql.Open("sqlite3", tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening old database: %v", err)
+	}
+	_, err = oldDB.Exec(`CREATE TABLE providers (name TEXT PRIMARY KEY, "pairs" TEXT);
+		INSERT INTO providers (name, pairs) VALUES ('UpgradeProvider', '{"GRT/NOK":true,"GRT/JPY":false}');`)
+	oldDB.Close()
+	if err != nil {
+		t.Fatalf("Error writing old database: %v", err)
+	}
+
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer ProviderConfig.CloseDB()
+
+	// Every configured pair gets a default instrument, enabled or not
+	for _, pairName := range []string{"GRT/NOK", "GRT/JPY"} {
+		instrument, err := ProviderConfig.GetInstrument(pairName)
+		assert.NoError(t, err)
+		assert.NotNil(t, instrument, "Expected %s to be registered", pairName)
+	}
+
+	router := gin.Default()
+	router.POST("/prices", engine.ProcessPriceUpdateRequest)
+	body := `{"provider":"UpgradeProvider","base":"GRT","quote":"NOK","bid":"1.5","bid_amount":"1","ask":"1.51","ask_amount":"1","timestamp":` + strconv.FormatInt(time.Now().UnixMilli(), 10) + `}`
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/prices", bytes.NewBufferString(body))
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Eventually(t, func() bool {
+		bestBid := engine.GetBestBidPrice("GRT/NOK")
+		return bestBid != nil && bestBid.Price.String() == "1.5"
+	}, time.Second, 10*time.Millisecond)
+
+	// Reopening doesn't bring back instruments that were deleted on purpose
+	ProviderConfig.DeleteInstrument("GRT/JPY")
+	ProviderConfig.CloseDB()
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error reopening database: %v", err)
+	}
+	instrument, _ := ProviderConfig.GetInstrument("GRT/JPY")
+	assert.Nil(t, instrument)
+}
@@ -0,0 +1,105 @@
+package PriceAPI
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/Metrics"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Reasons an update is rejected, used as the reason label on the rejected counter
+const (
+	RejectInvalid     = "invalid"
+	RejectOutOfOrder  = "out_of_order"
+	RejectOutlier     = "outlier"
+	RejectRateLimited = "rate_limited"
+	RejectQueueFull   = "queue_full"
+	RejectForbidden   = "forbidden"
+	RejectInternal    = "internal"
+)
+
+var (
+	updatesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "priceapi_updates_received_total",
+		Help: "Price updates received by provider.",
+	}, []string{"provider"})
+	updatesAccepted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "priceapi_updates_accepted_total",
+		Help: "Price updates accepted by provider.",
+	}, []string{"provider"})
+	updatesRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "priceapi_updates_rejected_total",
+		Help: "Price updates rejected by provider and reason.",
+	}, []string{"provider", "reason"})
+	bestPriceChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "priceapi_best_price_changes_total",
+		Help: "Changes to the best bid or ask by pair and side.",
+	}, []string{"pair", "side"})
+	recalculationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "priceapi_recalculation_duration_seconds",
+		Help:    "Time taken to rebuild a pair's consolidated book and publish any change.",
+		Buckets: Metrics.DefaultBuckets,
+	}, []string{"pair"})
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "priceapi_ingest_queue_updates",
+		Help: "Price updates waiting in the ingest queue.",
+	}, func() float64 { return float64(queuedUpdatesTotal.Load()) })
+)
+
+// Provider label for updates from providers we don't know about
+const unknownProvider = "unknown"
+
+// metricsProvider labels updates with their provider only if it is configured, anyone
+// can send an update so the provider name can't be trusted to bound the series.
+func metricsProvider(providerName string) string {
+	if providerName == "" {
+		return unknownProvider
+	}
+	known, err := ProviderConfig.IsKnownProvider(providerName)
+	if err != nil || !known {
+		return unknownProvider
+	}
+	return providerName
+}
+
+// recordUpdateOutcome counts a received update as accepted, or rejected with the reason
+// for the HTTP status it was rejected with.
+func recordUpdateOutcome(update *PriceUpdateRequest, status int, err error) {
+	if err == nil {
+		providerName := metricsProvider(update.Provider)
+		updatesReceived.WithLabelValues(providerName).Inc()
+		updatesAccepted.WithLabelValues(providerName).Inc()
+		return
+	}
+	recordUpdateRejected(update.Provider, rejectReason(status))
+}
+
+// recordUpdateRejected counts an update received and rejected before validation.
+func recordUpdateRejected(providerName string, reason string) {
+	providerName = metricsProvider(providerName)
+	updatesReceived.WithLabelValues(providerName).Inc()
+	updatesRejected.WithLabelValues(providerName, reason).Inc()
+}
+
+func rejectReason(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return RejectInvalid
+	case http.StatusConflict:
+		return RejectOutOfOrder
+	case http.StatusUnprocessableEntity:
+		return RejectOutlier
+	case http.StatusInternalServerError:
+		return RejectInternal
+	}
+	return strconv.Itoa(status)
+}
+
+// observeRecalculation records how long a pair's recalculation took.
+func observeRecalculation(pairName string, start time.Time) {
+	recalculationDuration.WithLabelValues(pairName).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,89 @@
+package PriceAPI
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/Metrics"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateMetrics(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestUpdateMetrics")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("ICP", "DKK"))
+	ProviderConfig.SetPairEnabled("MetricsProvider", "ICP/DKK", true)
+	disableOutlierFilter(t, engine)
+
+	router := gin.Default()
+	router.POST("/prices", engine.ProcessPriceUpdateRequest)
+	router.GET("/metrics", Metrics.Handler)
+
+	received := testutil.ToFloat64(updatesReceived.WithLabelValues("MetricsProvider"))
+	accepted := testutil.ToFloat64(updatesAccepted.WithLabelValues("MetricsProvider"))
+	invalid := testutil.ToFloat64(updatesRejected.WithLabelValues("MetricsProvider", RejectInvalid))
+	outOfOrder := testutil.ToFloat64(updatesRejected.WithLabelValues("MetricsProvider", RejectOutOfOrder))
+	bidChanges := testutil.ToFloat64(bestPriceChanges.WithLabelValues("ICP/DKK", "Bid"))
+	recalculations := Metrics.HistogramCount(recalculationDuration, "ICP/DKK")
+
+	timestamp := time.Now().UnixMilli()
+	send := func(bid string, ask string, timestamp int64) int {
+		rr := httptest.NewRecorder()
+		body := `{"provider":"MetricsProvider","base":"ICP","quote":"DKK","bid":"` + bid + `","bid_amount":"1","ask":"` + ask + `","ask_amount":"1","timestamp":` + strconv.FormatInt(timestamp, 10) + `}`
+		req, _ := http.NewRequest("POST", "/prices", bytes.NewBufferString(body))
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send("9", "10", timestamp+1))
+	// A crossed quote is invalid and an older timestamp is out of order
+	assert.Equal(t, http.StatusBadRequest, send("11", "10", timestamp+2))
+	assert.Equal(t, http.StatusConflict, send("9", "10", timestamp))
+
+	assert.Equal(t, received+3, testutil.ToFloat64(updatesReceived.WithLabelValues("MetricsProvider")))
+	assert.Equal(t, accepted+1, testutil.ToFloat64(updatesAccepted.WithLabelValues("MetricsProvider")))
+	assert.Equal(t, invalid+1, testutil.ToFloat64(updatesRejected.WithLabelValues("MetricsProvider", RejectInvalid)))
+	assert.Equal(t, outOfOrder+1, testutil.ToFloat64(updatesRejected.WithLabelValues("MetricsProvider", RejectOutOfOrder)))
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(bestPriceChanges.WithLabelValues("ICP/DKK", "Bid")) > bidChanges && Metrics.HistogramCount(recalculationDuration, "ICP/DKK") > recalculations
+	}, time.Second, 10*time.Millisecond)
+
+	// Providers that haven't been configured share one series however many names are sent
+	unknownInvalid := testutil.ToFloat64(updatesRejected.WithLabelValues(unknownProvider, RejectInvalid))
+	rr := httptest.NewRecorder()
+	body := `{"provider":"UnconfiguredProvider","base":"ICP","quote":"DKK","bid":"11","bid_amount":"1","ask":"10","ask_amount":"1","timestamp":` + strconv.FormatInt(timestamp, 10) + `}`
+	req, _ := http.NewRequest("POST", "/prices", bytes.NewBufferString(body))
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, unknownInvalid+1, testutil.ToFloat64(updatesRejected.WithLabelValues(unknownProvider, RejectInvalid)))
+
+	rr = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/metrics", nil)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `priceapi_updates_rejected_total{provider="MetricsProvider",reason="out_of_order"}`)
+	assert.Contains(t, rr.Body.String(), `priceapi_recalculation_duration_seconds_count{pair="ICP/DKK"}`)
+	assert.Contains(t, rr.Body.String(), "# TYPE priceapi_ingest_queue_updates gauge\n")
+	assert.NotContains(t, rr.Body.String(), "UnconfiguredProvider")
+}
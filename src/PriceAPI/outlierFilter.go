@@ -0,0 +1,303 @@
+package PriceAPI
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/shopspring/decimal"
+)
+
+// Outlier filter settings are stored with the rest of the provider config so the
+// ProviderConfigAPI can change them
+type (
+	OutlierReference     = ProviderConfig.OutlierReference
+	OutlierFilterConfig  = ProviderConfig.OutlierFilterConfig
+	OutlierFilterConfigs = ProviderConfig.OutlierFilterConfigs
+)
+
+const (
+	MedianReference          = ProviderConfig.MedianReference
+	ConsolidatedMidReference = ProviderConfig.ConsolidatedMidReference
+)
+
+// Outlier reasons, counted separately for each provider
+const (
+	OutlierDeviation   = "deviation"
+	OutlierSpreadWidth = "spread_width"
+)
+
+// OutlierStats counts a provider's quarantined quotes by reason.
+type OutlierStats struct {
+	Deviation   uint64 `json:"deviation"`
+	SpreadWidth uint64 `json:"spread_width"`
+}
+
+// QuarantinedQuote is a provider's last quote for a pair that was rejected as an outlier.
+type QuarantinedQuote struct {
+	*PriceUpdateRequest
+	Reason string `json:"reason"`
+	Detail string `json:"detail"`
+	// Nil when the quote was quarantined without a reference, e.g. for its spread
+	ReferencePrice *decimal.Decimal `json:"reference_price,omitempty"`
+	QuarantinedAt  time.Time        `json:"quarantined_at"`
+}
+
+// NewDefaultOutlierFilterConfig returns the settings used until they are changed.
+func NewDefaultOutlierFilterConfig() *OutlierFilterConfig {
+	return ProviderConfig.NewDefaultOutlierFilterConfig()
+}
+
+// SetOutlierFilterConfig saves the outlier filter settings for every pair without its own.
+func (engine *PriceEngine) SetOutlierFilterConfig(config *OutlierFilterConfig) error {
+	return engine.setOutlierFilterConfigs(&OutlierFilterConfigs{Default: config})
+}
+
+// SetPairOutlierFilterConfig saves the outlier filter settings for a single pair, nil
+// removes the override.
+func (engine *PriceEngine) SetPairOutlierFilterConfig(pairName string, config *OutlierFilterConfig) error {
+	return engine.setOutlierFilterConfigs(&OutlierFilterConfigs{Pairs: map[string]*OutlierFilterConfig{pairName: config}})
+}
+
+func (engine *PriceEngine) setOutlierFilterConfigs(configs *OutlierFilterConfigs) error {
+	return ProviderConfig.SetOutlierFilterConfigs(configs)
+}
+
+// getOutlierFilterConfig returns a pair's settings from the outlier filter cache, it
+// doesn't take outlierMu so it never holds up quarantining or the stats.
+func (engine *PriceEngine) getOutlierFilterConfig(pairName string) *OutlierFilterConfig {
+	config, err := ProviderConfig.GetOutlierFilterConfig(pairName)
+	if err != nil {
+		// Filter with the defaults rather than letting outliers through
+		fmt.Println("Error loading outlier filter settings:", err)
+		return NewDefaultOutlierFilterConfig()
+	}
+	return config
+}
+
+// quoteMid returns the middle of a quote, or its only side if it is one sided.
+func quoteMid(bid decimal.Decimal, ask decimal.Decimal) (decimal.Decimal, bool) {
+	switch {
+	case bid.IsPositive() && ask.IsPositive():
+		return bid.Add(ask).Div(decimal.NewFromInt(2)), true
+	case bid.IsPositive():
+		return bid, true
+	case ask.IsPositive():
+		return ask, true
+	}
+	return decimal.Zero, false
+}
+
+// getOutlierReference returns the price to compare a provider's quote to, or false if
+// there isn't enough market data to judge it.
+func (engine *PriceEngine) getOutlierReference(config *OutlierFilterConfig, update *PriceUpdateRequest) (decimal.Decimal, bool) {
+	pairName := update.GetPairName()
+	if config.Reference == ConsolidatedMidReference {
+		var bid, ask decimal.Decimal
+		if bestBid := engine.GetBestBidPrice(pairName); bestBid != nil {
+			bid = bestBid.Price
+		}
+		if bestAsk := engine.GetBestAskPrice(pairName); bestAsk != nil {
+			ask = bestAsk.Price
+		}
+		return quoteMid(bid, ask)
+	}
+
+	// Only quotes that could be chosen as the best price are used
+	now := engine.now()
+	mids := make([]decimal.Decimal, 0)
+	for _, other := range engine.getPairUpdateRequests(pairName) {
+		if other.Provider == update.Provider || engine.isQuoteStale(other, now) {
+			continue
+		}
+		if isEnabled, err := engine.Eligibility.GetProviderPairEnabled(other.Provider, pairName); err != nil || !isEnabled {
+			continue
+		}
+		if mid, ok := quoteMid(other.Bid, other.Ask); ok {
+			mids = append(mids, mid)
+		}
+	}
+	if len(mids) == 0 || len(mids) < config.MinProviders {
+		return decimal.Zero, false
+	}
+	sort.Slice(mids, func(i, j int) bool { return mids[i].LessThan(mids[j]) })
+	middle := len(mids) / 2
+	if len(mids)%2 == 0 {
+		return mids[middle-1].Add(mids[middle]).Div(decimal.NewFromInt(2)), true
+	}
+	return mids[middle], true
+}
+
+// checkOutlier quarantines an update whose spread is implausibly wide or whose prices
+// are too far from the reference price.
+func (engine *PriceEngine) checkOutlier(update *PriceUpdateRequest) error {
+	config := engine.getOutlierFilterConfig(update.GetPairName())
+	if !config.Enabled {
+		return nil
+	}
+	if reason, detail, reference := engine.findOutlier(config, update); reason != "" {
+		return engine.quarantineQuote(update, reason, detail, reference)
+	}
+
+	// The provider is back in line so its last quarantined quote is no longer relevant
+	engine.outlierMu.Lock()
+	delete(engine.quarantinedQuotes[update.Provider], update.GetPairName())
+	engine.outlierMu.Unlock()
+	return nil
+}
+
+// findOutlier returns why an update is an outlier and the reference it was compared to,
+// or an empty reason if it isn't one.
+func (engine *PriceEngine) findOutlier(config *OutlierFilterConfig, update *PriceUpdateRequest) (string, string, *decimal.Decimal) {
+	if config.MaxSpreadBps.IsPositive() && update.Bid.IsPositive() && update.Ask.IsPositive() {
+		mid, _ := quoteMid(update.Bid, update.Ask)
+		spreadBps := update.GetSpread().Div(mid).Mul(basisPoints)
+		if spreadBps.GreaterThan(config.MaxSpreadBps) {
+			return OutlierSpreadWidth, fmt.Sprintf("spread is %s bps, the maximum is %s bps", spreadBps.Round(1), config.MaxSpreadBps), nil
+		}
+	}
+
+	if !config.MaxDeviationBps.IsPositive() {
+		return "", "", nil
+	}
+	reference, ok := engine.getOutlierReference(config, update)
+	if !ok {
+		return "", "", nil
+	}
+	for _, side := range []struct {
+		name  string
+		price decimal.Decimal
+	}{{"bid", update.Bid}, {"ask", update.Ask}} {
+		if !side.price.IsPositive() {
+			continue
+		}
+		deviationBps := side.price.Sub(reference).Abs().Div(reference).Mul(basisPoints)
+		if deviationBps.GreaterThan(config.MaxDeviationBps) {
+			return OutlierDeviation, fmt.Sprintf("%s %s is %s bps from the %s reference %s, the maximum is %s bps",
+				side.name, side.price, deviationBps.Round(1), config.Reference, reference, config.MaxDeviationBps), &reference
+		}
+	}
+	return "", "", nil
+}
+
+// quarantineQuote records and counts a quote rejected as an outlier, returning the
+// error to reject it with.
+func (engine *PriceEngine) quarantineQuote(update *PriceUpdateRequest, reason string, detail string, reference *decimal.Decimal) error {
+	fmt.Printf("Quarantined %s quote from %s (%s): %s\n", update.GetPairName(), update.Provider, reason, detail)
+
+	engine.outlierMu.Lock()
+	defer engine.outlierMu.Unlock()
+	if engine.quarantinedQuotes[update.Provider] == nil {
+		engine.quarantinedQuotes[update.Provider] = make(map[string]*QuarantinedQuote)
+	}
+	engine.quarantinedQuotes[update.Provider][update.GetPairName()] = &QuarantinedQuote{
+		PriceUpdateRequest: update,
+		Reason:             reason,
+		Detail:             detail,
+		ReferencePrice:     reference,
+		QuarantinedAt:      engine.now(),
+	}
+	stats := engine.outlierStats[update.Provider]
+	if stats == nil {
+		stats = &OutlierStats{}
+		engine.outlierStats[update.Provider] = stats
+	}
+	if reason == OutlierSpreadWidth {
+		stats.SpreadWidth++
+	} else {
+		stats.Deviation++
+	}
+	return fmt.Errorf("quote quarantined as an outlier: %s", detail)
+}
+
+// getQuarantinedQuotes returns the quarantined quotes sorted by pair and provider,
+// optionally filtered by either.
+func (engine *PriceEngine) getQuarantinedQuotes(pairName string, providerName string) []*QuarantinedQuote {
+	engine.outlierMu.RLock()
+	defer engine.outlierMu.RUnlock()
+	quotes := make([]*QuarantinedQuote, 0)
+	for quoteProvider, providerQuotes := range engine.quarantinedQuotes {
+		if providerName != "" && providerName != quoteProvider {
+			continue
+		}
+		for quotePair, quote := range providerQuotes {
+			if pairName != "" && pairName != quotePair {
+				continue
+			}
+			quotes = append(quotes, quote)
+		}
+	}
+	sort.Slice(quotes, func(i, j int) bool {
+		if quotes[i].GetPairName() != quotes[j].GetPairName() {
+			return quotes[i].GetPairName() < quotes[j].GetPairName()
+		}
+		return quotes[i].Provider < quotes[j].Provider
+	})
+	return quotes
+}
+
+// getOutlierStats returns a copy of the outlier counters for every provider.
+func (engine *PriceEngine) getOutlierStats() map[string]OutlierStats {
+	engine.outlierMu.RLock()
+	defer engine.outlierMu.RUnlock()
+	stats := make(map[string]OutlierStats, len(engine.outlierStats))
+	for providerName, providerStats := range engine.outlierStats {
+		stats[providerName] = *providerStats
+	}
+	return stats
+}
+
+// GetOutlierFilterConfigs returns the default and per pair outlier filter settings.
+func (engine *PriceEngine) GetOutlierFilterConfigs(c *gin.Context) {
+	configs, err := ProviderConfig.GetOutlierFilterConfigs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, configs)
+}
+
+// SetOutlierFilterConfigs saves the default and/or per pair outlier filter settings.
+func (engine *PriceEngine) SetOutlierFilterConfigs(c *gin.Context) {
+	var req OutlierFilterConfigs
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate everything before changing anything
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := engine.setOutlierFilterConfigs(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// GetQuarantinedQuotes returns each provider's last quarantined quote for every pair.
+// Results can be filtered with the pair (e.g. BTC/USD) and provider query params.
+func (engine *PriceEngine) GetQuarantinedQuotes(c *gin.Context) {
+	c.JSON(http.StatusOK, engine.getQuarantinedQuotes(c.Query("pair"), c.Query("provider")))
+}
+
+// GetOutlierStats returns the outlier counters for every provider.
+func (engine *PriceEngine) GetOutlierStats(c *gin.Context) {
+	c.JSON(http.StatusOK, engine.getOutlierStats())
+}
+
+// GetProviderOutlierStats returns the outlier counters for a single provider.
+func (engine *PriceEngine) GetProviderOutlierStats(c *gin.Context) {
+	providerName := c.Param("providerName")
+	stats, ok := engine.getOutlierStats()[providerName]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no outliers received from %s", providerName)})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
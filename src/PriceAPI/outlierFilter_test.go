@@ -0,0 +1,175 @@
+package PriceAPI
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// disableOutlierFilter turns the filter off for tests whose quotes are chosen to exercise
+// something else and are too wide to pass it.
+func disableOutlierFilter(t *testing.T, engine *PriceEngine) {
+	config := NewDefaultOutlierFilterConfig()
+	config.Enabled = false
+	if err := engine.SetOutlierFilterConfig(config); err != nil {
+		t.Fatalf("Error disabling outlier filter: %v", err)
+	}
+}
+
+func TestOutlierFilter(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestOutlierFilter")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("FIL", "MXN"))
+	providers := []string{"OutlierProviderA", "OutlierProviderB", "OutlierProviderC", "OutlierProviderD"}
+	for _, providerName := range providers {
+		ProviderConfig.SetPairEnabled(providerName, "FIL/MXN", true)
+	}
+
+	router := gin.Default()
+	router.POST("/prices", engine.ProcessPriceUpdateRequest)
+	router.PUT("/outliers/config", engine.SetOutlierFilterConfigs)
+	router.GET("/outliers/quarantine", engine.GetQuarantinedQuotes)
+	router.GET("/outliers/stats/:providerName", engine.GetProviderOutlierStats)
+
+	serve := func(method string, path string, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	timestamp := time.Now().UnixMilli()
+	send := func(provider string, bid string, ask string) *httptest.ResponseRecorder {
+		timestamp++
+		return serve("POST", "/prices", `{"provider":"`+provider+`","base":"FIL","quote":"MXN","bid":"`+bid+`","bid_amount":"1","ask":"`+ask+`","ask_amount":"1","timestamp":`+strconv.FormatInt(timestamp, 10)+`}`)
+	}
+	waitForQuotes := func(count int) {
+		assert.Eventually(t, func() bool {
+			return len(engine.getPairUpdateRequests("FIL/MXN")) == count
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	rr := serve("PUT", "/outliers/config", `{"pairs":{"FIL/MXN":{"enabled":true,"reference":"bogus","min_providers":2}}}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = serve("PUT", "/outliers/config", `{"pairs":{"FIL/MXN":{"enabled":true,"reference":"median","max_deviation_bps":500,"max_spread_bps":"1000","min_providers":2}}}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Without two other providers there is no reference so everything is accepted
+	for _, providerName := range providers[:3] {
+		assert.Equal(t, http.StatusOK, send(providerName, "100", "101").Code)
+	}
+	waitForQuotes(3)
+
+	// A fat finger quote is quarantined against the median of the others
+	rr = send("OutlierProviderD", "1", "1.01")
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Contains(t, rr.Body.String(), "bid 1 is 9900.5 bps from the median reference 100.5")
+	// As is an implausibly wide spread
+	rr = send("OutlierProviderD", "90", "110")
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	rr = serve("GET", "/outliers/quarantine?provider=OutlierProviderD", "")
+	// QuarantinedQuote can't be decoded as it embeds the update's own UnmarshalJSON
+	var quarantined []struct {
+		Provider       string  `json:"provider"`
+		Bid            string  `json:"bid"`
+		Reason         string  `json:"reason"`
+		ReferencePrice *string `json:"reference_price"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &quarantined); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Equal(t, 1, len(quarantined)) {
+		assert.Equal(t, OutlierSpreadWidth, quarantined[0].Reason)
+		assert.Equal(t, "90", quarantined[0].Bid)
+		assert.Nil(t, quarantined[0].ReferencePrice)
+	}
+	rr = serve("GET", "/outliers/stats/OutlierProviderD", "")
+	var stats OutlierStats
+	if err := json.Unmarshal(rr.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, OutlierStats{Deviation: 1, SpreadWidth: 1}, stats)
+
+	// Quarantined quotes never reach the book
+	assert.Equal(t, "100", engine.GetBestBidPrice("FIL/MXN").Price.String())
+
+	// A quote back in line is accepted and clears the quarantine
+	assert.Equal(t, http.StatusOK, send("OutlierProviderD", "100.2", "100.8").Code)
+	assert.Empty(t, engine.getQuarantinedQuotes("FIL/MXN", "OutlierProviderD"))
+	waitForQuotes(4)
+	assert.Eventually(t, func() bool {
+		bestBid := engine.GetBestBidPrice("FIL/MXN")
+		return bestBid != nil && bestBid.Price.String() == "100.2"
+	}, time.Second, 10*time.Millisecond)
+
+	// The consolidated mid of 100.2 and 100.8 can be used instead
+	rr = serve("PUT", "/outliers/config", `{"pairs":{"FIL/MXN":{"enabled":true,"reference":"mid","max_deviation_bps":100,"min_providers":1}}}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = send("OutlierProviderA", "103", "103.5")
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Contains(t, rr.Body.String(), "mid reference 100.5")
+	assert.Equal(t, http.StatusOK, send("OutlierProviderA", "100.4", "100.6").Code)
+}
+
+func TestOutlierFilterConfigPersisted(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestOutlierFilterConfigPersisted")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	// The filter is on by default with a 500 bps band around the median
+	config := engine.getOutlierFilterConfig("SOL/SEK")
+	assert.True(t, config.Enabled)
+	assert.Equal(t, MedianReference, config.Reference)
+	assert.Equal(t, "500", config.MaxDeviationBps.String())
+
+	// Changes made through the engine are saved for every engine
+	pairConfig := NewDefaultOutlierFilterConfig()
+	pairConfig.MaxDeviationBps = decimal.NewFromInt(200)
+	assert.NoError(t, engine.SetPairOutlierFilterConfig("SOL/SEK", pairConfig))
+	otherEngine := NewPriceEngine()
+	defer otherEngine.Close()
+	assert.Equal(t, "200", otherEngine.getOutlierFilterConfig("SOL/SEK").MaxDeviationBps.String())
+
+	// And changes saved elsewhere, such as by the ProviderConfigAPI, are picked up
+	defaultConfig := NewDefaultOutlierFilterConfig()
+	defaultConfig.Enabled = false
+	assert.NoError(t, ProviderConfig.SetOutlierFilterConfigs(&OutlierFilterConfigs{
+		Default: defaultConfig,
+		Pairs:   map[string]*OutlierFilterConfig{"SOL/SEK": nil},
+	}))
+	assert.False(t, engine.getOutlierFilterConfig("SOL/SEK").Enabled)
+}
@@ -0,0 +1,121 @@
+package PriceAPI
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// pairBook is a pair's provider quotes and its published best price. Writes to a pair
+// only lock its own book, and reading the best price never locks.
+type pairBook struct {
+	base  string
+	quote string
+	// Replaced as a whole on every change and never modified, nil until either side is set
+	best atomic.Pointer[BestPrice]
+	// Guards quotes and publishing best
+	mu sync.Mutex
+	// Last update from each provider
+	quotes map[string]*PriceUpdateRequest
+	// Set once the book has been dropped from the engine for being empty, writers that
+	// still hold it must look the pair up again
+	dropped bool
+}
+
+func newPairBook(pairName string) *pairBook {
+	base, quote, _ := strings.Cut(pairName, "/")
+	return &pairBook{base: base, quote: quote, quotes: make(map[string]*PriceUpdateRequest)}
+}
+
+// publish replaces the best price, the caller must hold the book's lock.
+func (book *pairBook) publish(bid *PriceUpdate, ask *PriceUpdate) {
+	if bid == nil && ask == nil {
+		book.best.Store(nil)
+		return
+	}
+	book.best.Store(NewBestPrice(book.base, book.quote, bid, ask))
+}
+
+// bestSides returns the current best bid and ask, either of which may be nil.
+func (book *pairBook) bestSides() (*PriceUpdate, *PriceUpdate) {
+	if best := book.best.Load(); best != nil {
+		return best.Bid, best.Ask
+	}
+	return nil, nil
+}
+
+// getBooks returns every pair's book. The map is replaced rather than modified when a
+// pair is added so it can be read without a lock.
+func (engine *PriceEngine) getBooks() map[string]*pairBook {
+	return *engine.books.Load()
+}
+
+func (engine *PriceEngine) getBook(pairName string) *pairBook {
+	return engine.getBooks()[pairName]
+}
+
+// getOrCreateBook returns a pair's book, adding it the first time the pair is seen.
+func (engine *PriceEngine) getOrCreateBook(pairName string) *pairBook {
+	if book := engine.getBook(pairName); book != nil {
+		return book
+	}
+	engine.booksMu.Lock()
+	defer engine.booksMu.Unlock()
+	books := engine.getBooks()
+	if book := books[pairName]; book != nil {
+		return book
+	}
+	updated := make(map[string]*pairBook, len(books)+1)
+	for name, book := range books {
+		updated[name] = book
+	}
+	book := newPairBook(pairName)
+	updated[pairName] = book
+	engine.books.Store(&updated)
+	return book
+}
+
+// lockBook returns a pair's book with its lock held, adding the book if needed.
+func (engine *PriceEngine) lockBook(pairName string) *pairBook {
+	for {
+		book := engine.getOrCreateBook(pairName)
+		book.mu.Lock()
+		if !book.dropped {
+			return book
+		}
+		book.mu.Unlock()
+	}
+}
+
+// dropEmptyBooks removes the books of pairs with no quotes and no best price left, so
+// pairs that stop being quoted don't stay listed forever.
+func (engine *PriceEngine) dropEmptyBooks() {
+	engine.booksMu.Lock()
+	defer engine.booksMu.Unlock()
+	books := engine.getBooks()
+	remaining := make(map[string]*pairBook, len(books))
+	for pairName, book := range books {
+		book.mu.Lock()
+		if len(book.quotes) == 0 && book.best.Load() == nil {
+			book.dropped = true
+		} else {
+			remaining[pairName] = book
+		}
+		book.mu.Unlock()
+	}
+	if len(remaining) != len(books) {
+		engine.books.Store(&remaining)
+	}
+}
+
+// getPairList returns every pair that has been quoted, sorted by name.
+func (engine *PriceEngine) getPairList() []string {
+	books := engine.getBooks()
+	pairNames := make([]string, 0, len(books))
+	for pairName := range books {
+		pairNames = append(pairNames, pairName)
+	}
+	sort.Strings(pairNames)
+	return pairNames
+}
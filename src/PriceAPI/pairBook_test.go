@@ -0,0 +1,195 @@
+package PriceAPI
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBestPriceSnapshotsAreConsistent(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	// Every snapshot has an ask one above its bid, readers must never see a bid from one and an ask from another
+	book := engine.getOrCreateBook("EGLD/QAR")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := int64(1); i <= 2000; i++ {
+			book.mu.Lock()
+			book.publish(&PriceUpdate{Provider: "ProviderA", Base: "EGLD", Quote: "QAR", Price: decimal.NewFromInt(i)},
+				&PriceUpdate{Provider: "ProviderB", Base: "EGLD", Quote: "QAR", Price: decimal.NewFromInt(i + 1)})
+			book.mu.Unlock()
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for reader := 0; reader < 4; reader++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if bestPrice := engine.getBestPrice("EGLD", "QAR"); bestPrice != nil {
+					if !assert.Equal(t, "1", bestPrice.Spread.String()) {
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, "2000", engine.GetBestBidPrice("EGLD/QAR").Price.String())
+
+	// Clearing both sides removes the pair from the best prices but not from the pair list
+	engine.setBestBidPrice("EGLD/QAR", nil)
+	engine.setBestAskPrice("EGLD/QAR", nil)
+	assert.Nil(t, engine.getBestPrice("EGLD", "QAR"))
+	assert.NotContains(t, engine.getBestPrices(), "EGLD/QAR")
+	assert.Contains(t, engine.getPairList(), "EGLD/QAR")
+}
+
+// bestPriceStore is the part of the engine the benchmarks compare
+type bestPriceStore interface {
+	GetBestBidPrice(pairName string) *PriceUpdate
+	setBestBidPrice(pairName string, newPrice *PriceUpdate)
+}
+
+// lockedBestPrices is the previous design, every pair behind one RWMutex
+type lockedBestPrices struct {
+	bestBidStore map[string]*PriceUpdate
+	mu           sync.RWMutex
+}
+
+func (store *lockedBestPrices) GetBestBidPrice(pairName string) *PriceUpdate {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	return store.bestBidStore[pairName]
+}
+
+func (store *lockedBestPrices) setBestBidPrice(pairName string, newPrice *PriceUpdate) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.bestBidStore[pairName] = newPrice
+}
+
+const benchmarkPairs = 64
+
+var benchmarkPairNames = func() []string {
+	pairNames := make([]string, benchmarkPairs)
+	for i := range pairNames {
+		pairNames[i] = fmt.Sprintf("P%02d/USD", i)
+	}
+	return pairNames
+}()
+
+func benchmarkPrice(pairName string, price int64) *PriceUpdate {
+	return &PriceUpdate{Provider: "ProviderA", Base: pairName[:3], Quote: "USD", Price: decimal.NewFromInt(price), Amount: decimal.NewFromInt(1)}
+}
+
+// benchmarkStores runs a benchmark against the locked design and the engine's snapshots
+func benchmarkStores(b *testing.B, run func(b *testing.B, store bestPriceStore)) {
+	b.Run("locked", func(b *testing.B) {
+		run(b, &lockedBestPrices{bestBidStore: make(map[string]*PriceUpdate)})
+	})
+	b.Run("snapshot", func(b *testing.B) {
+		engine := NewPriceEngine()
+		defer engine.Close()
+		run(b, engine)
+	})
+}
+
+// withBackground calls work in a loop on a goroutine until the benchmark finishes
+func withBackground(b *testing.B, goroutines int, work func(i int64)) func() {
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int64(0); !stop.Load(); i++ {
+				work(i)
+			}
+		}()
+	}
+	return func() {
+		stop.Store(true)
+		wg.Wait()
+	}
+}
+
+// BenchmarkBestPriceReads reads best bids from every CPU while another goroutine keeps writing
+func BenchmarkBestPriceReads(b *testing.B) {
+	benchmarkStores(b, func(b *testing.B, store bestPriceStore) {
+		for _, pairName := range benchmarkPairNames {
+			store.setBestBidPrice(pairName, benchmarkPrice(pairName, 1))
+		}
+		stop := withBackground(b, 1, func(i int64) {
+			pairName := benchmarkPairNames[i%benchmarkPairs]
+			store.setBestBidPrice(pairName, benchmarkPrice(pairName, i))
+		})
+		defer stop()
+
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				if store.GetBestBidPrice(benchmarkPairNames[i%benchmarkPairs]) == nil {
+					b.Fatal("missing best bid")
+				}
+			}
+		})
+	})
+}
+
+// BenchmarkBestPriceWrites writes best bids from every CPU, each to its own pairs, while
+// other goroutines keep reading
+func BenchmarkBestPriceWrites(b *testing.B) {
+	benchmarkStores(b, func(b *testing.B, store bestPriceStore) {
+		for _, pairName := range benchmarkPairNames {
+			store.setBestBidPrice(pairName, benchmarkPrice(pairName, 1))
+		}
+		stop := withBackground(b, 4, func(i int64) {
+			store.GetBestBidPrice(benchmarkPairNames[i%benchmarkPairs])
+		})
+		defer stop()
+
+		var writers atomic.Int64
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			offset := int(writers.Add(1))
+			for i := int64(0); pb.Next(); i++ {
+				pairName := benchmarkPairNames[(offset*7+int(i%8))%benchmarkPairs]
+				store.setBestBidPrice(pairName, benchmarkPrice(pairName, i))
+			}
+		})
+	})
+}
+
+// BenchmarkBestPriceMixed makes one write for every nine reads from every CPU
+func BenchmarkBestPriceMixed(b *testing.B) {
+	benchmarkStores(b, func(b *testing.B, store bestPriceStore) {
+		for _, pairName := range benchmarkPairNames {
+			store.setBestBidPrice(pairName, benchmarkPrice(pairName, 1))
+		}
+
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := int64(0); pb.Next(); i++ {
+				pairName := benchmarkPairNames[i%benchmarkPairs]
+				if i%10 == 0 {
+					store.setBestBidPrice(pairName, benchmarkPrice(pairName, i))
+				} else {
+					store.GetBestBidPrice(pairName)
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,65 @@
+package PriceAPI
+
+import (
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+)
+
+// Number of decimal places used for pairs without their own precision
+var (
+	DefaultPricePrecision  int32 = 8
+	DefaultAmountPrecision int32 = 8
+)
+
+type instrumentPrecision struct {
+	price  int32
+	amount int32
+}
+
+// SetInstrumentPrecision sets the number of decimal places the engine uses to display
+// prices and amounts for a pair, overriding its instrument.
+func (engine *PriceEngine) SetInstrumentPrecision(pairName string, pricePrecision int32, amountPrecision int32) {
+	engine.precisionMu.Lock()
+	defer engine.precisionMu.Unlock()
+	engine.instrumentPrecisions[pairName] = &instrumentPrecision{price: pricePrecision, amount: amountPrecision}
+}
+
+// getInstrumentPrecision returns a pair's precision, from SetInstrumentPrecision or else
+// its instrument in the instrument cache. Nil if it has neither.
+func (engine *PriceEngine) getInstrumentPrecision(pairName string) *instrumentPrecision {
+	engine.precisionMu.RLock()
+	precision := engine.instrumentPrecisions[pairName]
+	engine.precisionMu.RUnlock()
+	if precision != nil {
+		return precision
+	}
+	if instrument := ProviderConfig.GetCachedInstrument(pairName); instrument != nil {
+		return &instrumentPrecision{price: instrument.PricePrecision, amount: instrument.AmountPrecision()}
+	}
+	return nil
+}
+
+// GetPricePrecision returns the number of decimal places for prices on a pair.
+func (engine *PriceEngine) GetPricePrecision(pairName string) int32 {
+	return engine.getInstrumentPrecision(pairName).getPrice()
+}
+
+// GetAmountPrecision returns the number of decimal places for amounts on a pair.
+func (engine *PriceEngine) GetAmountPrecision(pairName string) int32 {
+	return engine.getInstrumentPrecision(pairName).getAmount()
+}
+
+// getPrice returns the price precision, or the default for a pair without one.
+func (precision *instrumentPrecision) getPrice() int32 {
+	if precision == nil {
+		return DefaultPricePrecision
+	}
+	return precision.price
+}
+
+// getAmount returns the amount precision, or the default for a pair without one.
+func (precision *instrumentPrecision) getAmount() int32 {
+	if precision == nil {
+		return DefaultAmountPrecision
+	}
+	return precision.amount
+}
@@ -0,0 +1,56 @@
+package PriceAPI
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentPrecision(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestInstrumentPrecision")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("DOT", "JPY"))
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	// Without the instrument cache the database isn't read just to display a price
+	assert.Equal(t, DefaultPricePrecision, engine.GetPricePrecision("DOT/JPY"))
+
+	// Precision comes from the cached instrument and follows it when it changes
+	if err := ProviderConfig.StartInstrumentCache(time.Hour); err != nil {
+		t.Fatalf("Error starting instrument cache: %v", err)
+	}
+	assert.Equal(t, int32(2), engine.GetPricePrecision("DOT/JPY"))
+	assert.Equal(t, int32(8), engine.GetAmountPrecision("DOT/JPY"))
+	instrument := ProviderConfig.NewDefaultInstrument("DOT", "JPY")
+	instrument.PricePrecision = 3
+	ProviderConfig.SetInstrument(instrument)
+	assert.Equal(t, int32(3), engine.GetPricePrecision("DOT/JPY"))
+	assert.Equal(t, DefaultPricePrecision, engine.GetPricePrecision("DOT/NZD"))
+
+	// Precision set on one engine overrides the instrument for that engine only
+	otherEngine := NewPriceEngine()
+	defer otherEngine.Close()
+	otherEngine.SetInstrumentPrecision("DOT/JPY", 0, 1)
+	assert.Equal(t, int32(0), otherEngine.GetPricePrecision("DOT/JPY"))
+	assert.Equal(t, int32(3), engine.GetPricePrecision("DOT/JPY"))
+	event := otherEngine.newBestPriceEvent("DOT/JPY", &PriceUpdate{Provider: "ProviderA", Base: "DOT", Quote: "JPY", Price: decimal.RequireFromString("812.5"), Amount: decimal.NewFromInt(3)}, "Bid")
+	assert.True(t, strings.HasPrefix(event.String(), "Bid - ProviderA - 813 - 3.0 - "))
+	event = engine.newBestPriceEvent("DOT/JPY", event.Price, "Bid")
+	assert.True(t, strings.HasPrefix(event.String(), "Bid - ProviderA - 812.500 - 3.00000000 - "))
+}
@@ -1,210 +1,318 @@
 package PriceAPI
 
 import (
+	"context"
 	"fmt"
 	"net/http"
-	"sync"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/hongkongkiwi/chaostheory/src/Helpers"
 	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/hongkongkiwi/chaostheory/src/Tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var PriceUpdatesLogFile = "./logs/best_prices.log"
-
 type PriceUpdateType = string
 
-var (
-	bestBidStore            = make(map[string]*PriceUpdate)
-	bestAskStore            = make(map[string]*PriceUpdate)
-	providerLastUpdateStore = make(map[string]map[string]*PriceUpdateRequest)
-	// Many readers, one writer
-	mu sync.RWMutex
-)
-
-func GetBestBidPrice(pairName string) *PriceUpdate {
-	mu.RLock()
-	defer mu.RUnlock()
-	return bestBidStore[pairName]
+// GetBestBidPrice returns the best bid for a pair without locking, or nil if there isn't one.
+func (engine *PriceEngine) GetBestBidPrice(pairName string) *PriceUpdate {
+	if book := engine.getBook(pairName); book != nil {
+		bid, _ := book.bestSides()
+		return bid
+	}
+	return nil
 }
 
-func GetBestAskPrice(pairName string) *PriceUpdate {
-	mu.RLock()
-	defer mu.RUnlock()
-	return bestAskStore[pairName]
+// GetBestAskPrice returns the best ask for a pair without locking, or nil if there isn't one.
+func (engine *PriceEngine) GetBestAskPrice(pairName string) *PriceUpdate {
+	if book := engine.getBook(pairName); book != nil {
+		_, ask := book.bestSides()
+		return ask
+	}
+	return nil
 }
 
-func updateBestBidPrice(newPrice *PriceUpdate) {
-	mu.Lock()
-	defer mu.Unlock()
-	// Should handle this better
-	if newPrice == nil {
-		return
+// getBestPrice returns the best bid and ask for a pair, or nil if the pair has never been quoted.
+// The result is shared with other readers and must not be modified.
+func (engine *PriceEngine) getBestPrice(base string, quote string) *BestPrice {
+	if book := engine.getBook(fmt.Sprintf("%s/%s", base, quote)); book != nil {
+		return book.best.Load()
 	}
-	bestBidStore[newPrice.GetPairName()] = newPrice
+	return nil
 }
 
-func updateBestAskPrice(newPrice *PriceUpdate) {
-	mu.Lock()
-	defer mu.Unlock()
-	// Should handle this better
-	if newPrice == nil {
-		return
+// getBestPrices returns the best bid and ask for every pair that has been quoted.
+// The results are shared with other readers and must not be modified.
+func (engine *PriceEngine) getBestPrices() map[string]*BestPrice {
+	bestPrices := make(map[string]*BestPrice)
+	for pairName, book := range engine.getBooks() {
+		if best := book.best.Load(); best != nil {
+			bestPrices[pairName] = best
+		}
 	}
-	bestAskStore[newPrice.GetPairName()] = newPrice
+	return bestPrices
+}
+
+// setBestBidPrice replaces the best bid for a pair, a nil price clears it.
+func (engine *PriceEngine) setBestBidPrice(pairName string, newPrice *PriceUpdate) {
+	book := engine.lockBook(pairName)
+	defer book.mu.Unlock()
+	_, ask := book.bestSides()
+	book.publish(newPrice, ask)
 }
 
-func saveProviderUpdateRequest(update *PriceUpdateRequest) {
-	mu.Lock()
-	defer mu.Unlock()
+// setBestAskPrice replaces the best ask for a pair, a nil price clears it.
+func (engine *PriceEngine) setBestAskPrice(pairName string, newPrice *PriceUpdate) {
+	book := engine.lockBook(pairName)
+	defer book.mu.Unlock()
+	bid, _ := book.bestSides()
+	book.publish(bid, newPrice)
+}
+
+func (engine *PriceEngine) saveProviderUpdateRequest(update *PriceUpdateRequest) {
 	// Should handle this better
 	if update == nil {
 		return
 	}
-	if providerLastUpdateStore[update.Provider] == nil {
-		providerLastUpdateStore[update.Provider] = make(map[string]*PriceUpdateRequest)
+	if update.ReceivedAt.IsZero() {
+		update.ReceivedAt = engine.now()
+	}
+	book := engine.lockBook(update.GetPairName())
+	defer book.mu.Unlock()
+	// Out of order updates are only counted, and updates are validated concurrently so a
+	// newer update may have been queued first
+	if update.outOfOrder {
+		return
+	}
+	if last := book.quotes[update.Provider]; last != nil && update.ingestSequence > 0 && last.ingestSequence > update.ingestSequence {
+		return
 	}
-	providerLastUpdateStore[update.Provider][update.GetPairName()] = update
+	book.quotes[update.Provider] = update
 }
 
-func getLastPriceUpdateRequests(providerName string) map[string]*PriceUpdateRequest {
-	mu.RLock()
-	defer mu.RUnlock()
-	if providerLastUpdateStore[providerName] != nil {
-		return make(map[string]*PriceUpdateRequest)
+// validatePriceUpdateRequest checks a price update can be accepted, returning the HTTP
+// status to reply with if it can't. The outcome counts towards the pair's health and metrics.
+func (engine *PriceEngine) validatePriceUpdateRequest(update *PriceUpdateRequest) (int, error) {
+	status, err := engine.checkPriceUpdateRequest(update)
+	recordUpdateOutcome(update, status, err)
+	// Updates without a provider and pair, or that failed on our side, aren't the provider's fault
+	if update.Provider != "" && update.Base != "" && update.Quote != "" && status != http.StatusInternalServerError {
+		engine.recordHealthUpdate(update, err == nil)
 	}
-	return providerLastUpdateStore[providerName]
+	return status, err
 }
 
-func getProviderList() []string {
-	mu.RLock()
-	defer mu.RUnlock()
-	providerNames := make([]string, 0, len(providerLastUpdateStore))
-	for providerName := range providerLastUpdateStore {
-		providerNames = append(providerNames, providerName)
+func (engine *PriceEngine) checkPriceUpdateRequest(update *PriceUpdateRequest) (int, error) {
+	if update.Provider == "" || update.Base == "" || update.Quote == "" {
+		fmt.Printf("Missing provider, base, or quote fields in PriceUpdateRequest.")
+		return http.StatusBadRequest, fmt.Errorf("Missing provider, base, or quote fields.")
+	}
+
+	if update.ReceivedAt.IsZero() {
+		update.ReceivedAt = engine.now()
+	}
+	stamped, err := validateTimestamp(update)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	// Calculate spread between bid and ask prices, one sided quotes have no spread
+	if update.Bid.IsPositive() && update.Ask.IsPositive() && update.GetSpread().IsNegative() {
+		// Arbitrage opportunity detected reject update
+		fmt.Printf("Arbitrage opportunity detected for update on provider %s, dropping PriceUpdate\n", update.Provider)
+		return http.StatusBadRequest, fmt.Errorf("Arbitrage opportunity detected. Dropping PriceUpdate")
+	}
+
+	// Prices and amounts must fit the pair's instrument
+	instrument, err := ProviderConfig.GetInstrument(update.GetPairName())
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := validateInstrumentUpdate(instrument, update); err != nil {
+		return http.StatusBadRequest, err
+	}
+	// Quotes far from the rest of the market are quarantined rather than becoming the best price
+	if err := engine.checkOutlier(update); err != nil {
+		return http.StatusUnprocessableEntity, err
+	}
+	// Drop out of order and duplicate updates, this must be the last check as it
+	// records the update as the provider's newest
+	if status, err := engine.checkUpdateSequence(update); err != nil {
+		return status, err
+	}
+	engine.recordLatency(update, stamped)
+	return http.StatusOK, nil
+}
+
+// applyPriceUpdates saves validated updates and recalculates the best prices once for
+// every pair with an update from an enabled provider.
+func (engine *PriceEngine) applyPriceUpdates(updates []*PriceUpdateRequest) {
+	if len(updates) == 0 {
+		return
+	}
+	// Continue the first update's trace and link to the others
+	links := make([]trace.Link, 0, len(updates)-1)
+	for _, update := range updates[1:] {
+		if update.spanContext.IsValid() {
+			links = append(links, trace.Link{SpanContext: update.spanContext})
+		}
+	}
+	ctx, span := Tracing.Start(updates[0].traceContext(), "PriceAPI.applyPriceUpdates",
+		trace.WithLinks(links...), trace.WithAttributes(attribute.Int("updates", len(updates))))
+	defer span.End()
+
+	pairNames := make([]string, 0)
+	recalculate := make(map[string]bool)
+	for _, update := range updates {
+		pairName := update.GetPairName()
+		// Save this update so we can use it for recalculation later
+		engine.saveProviderUpdateRequest(update)
+
+		isEnabled, _ := engine.Eligibility.GetProviderPairEnabled(update.Provider, pairName)
+		// Only update the best price if this provider is enabled
+		if !isEnabled {
+			// We only log if the provider is enabled
+			fmt.Printf("Provider %s is disabled, not updating price for %s\n", update.Provider, pairName)
+			continue
+		}
+		if !recalculate[pairName] {
+			recalculate[pairName] = true
+			pairNames = append(pairNames, pairName)
+		}
+	}
+
+	// Rebuild the book for each pair so that if a provider was the best
+	// and has moved away the next best provider is promoted
+	for _, pairName := range pairNames {
+		engine.recalculateBestPricesForPair(ctx, pairName, engine.now())
 	}
-	return providerNames
 }
 
-// ProcessPriceUpdate handles updating the best bid and ask prices.
-func ProcessPriceUpdateRequest(c *gin.Context) {
+// ProcessPriceUpdate handles updating the best bid and ask prices. The reply is sent once
+// the update is queued, or once the best price has been recalculated with ack=applied.
+func (engine *PriceEngine) ProcessPriceUpdateRequest(c *gin.Context) {
+	waitApplied, err := getAck(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	// Populate our PriceUpdateRequest from received JSON
 	var updatePriceReq PriceUpdateRequest
 	if err := c.BindJSON(&updatePriceReq); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	updatePriceReq.ReceivedAt = engine.now()
+	updatePriceReq.spanContext = trace.SpanContextFromContext(c.Request.Context())
 
-	if updatePriceReq.Provider == "" || updatePriceReq.Base == "" || updatePriceReq.Quote == "" {
-		fmt.Printf("Missing provider, base, or quote fields in PriceUpdateRequest.")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing provider, base, or quote fields."})
+	if err := checkAuthenticatedProvider(c, &updatePriceReq); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		return
 	}
-
-	// Calculate spread between bid and ask prices
-	if updatePriceReq.GetSpread() < 0 {
-		// Arbitrage opportunity detected reject update
-		fmt.Printf("Arbitrage opportunity detected for update on provider %s, dropping PriceUpdate\n", updatePriceReq.Provider)
-		c.JSON(http.StatusBadRequest, "Arbitrage opportunity detected. Dropping PriceUpdate")
+	if retryAfter, err := engine.checkRateLimit(&updatePriceReq); err != nil {
+		c.Header("Retry-After", retryAfterSeconds(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	// Reserve space before validating so a full queue doesn't use up the sequence number
+	if !engine.reserveIngestQueue(1) {
+		engine.recordQueueFull(updatePriceReq.Provider)
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "ingest queue is full"})
+		return
+	}
+	if status, err := engine.validatePriceUpdateRequest(&updatePriceReq); err != nil {
+		engine.releaseIngestQueue(1)
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	// We can send a result back at this point
+	if !engine.enqueuePriceUpdates([]*PriceUpdateRequest{&updatePriceReq}, waitApplied) {
+		abortEngineClosed(c)
+		return
+	}
 	c.Status(http.StatusOK)
+}
 
-	// I am unsure if this needs to be in another thread
-	// depends on how Gin works it's contexts but it
-	// should be safe to do so
-	go func() {
-		pairName := updatePriceReq.GetPairName()
-		// Save this update so we can use it for recalculation later
-		saveProviderUpdateRequest(&updatePriceReq)
+// GetBestPricesForPair returns the current best bid and ask for a single currency pair.
+func (engine *PriceEngine) GetBestPricesForPair(c *gin.Context) {
+	base := c.Param("base")
+	quote := c.Param("quote")
+	if base == "" || quote == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty base or quote param"})
+		return
+	}
 
-		isEnabled, _ := ProviderConfig.GetProviderPairEnabled(updatePriceReq.Provider, pairName)
-		// Only update the best price if this provider is enabled
-		if isEnabled {
-			bestBidPrice := GetBestBidPrice(pairName)
-			// Update the best bid and ask prices based on whether this new price is better than the last
-			if bestBidPrice == nil || updatePriceReq.Bid > bestBidPrice.Price {
-				bidPriceUpdate := updatePriceReq.NewPriceUpdateBid()
-				updateBestBidPrice(bidPriceUpdate)
-				emitPriceUpdate(bidPriceUpdate, "Bid")
-			}
-
-			bestAskPrice := GetBestAskPrice(pairName)
-			if bestAskPrice == nil || updatePriceReq.Ask > bestAskPrice.Price {
-				askPriceUpdate := updatePriceReq.NewPriceUpdateAsk()
-				updateBestBidPrice(askPriceUpdate)
-				emitPriceUpdate(askPriceUpdate, "Ask")
-			}
-		} else {
-			// We only log if the provider is enabled
-			fmt.Printf("Provider %s is disabled, not updating price for %s\n", updatePriceReq.Provider, pairName)
-		}
-	}()
+	bestPrice := engine.getBestPrice(base, quote)
+	if bestPrice == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no prices received for %s/%s", base, quote)})
+		return
+	}
+	c.JSON(http.StatusOK, bestPrice)
+}
+
+// GetBestPrices returns the current best bid and ask for all quoted currency pairs.
+func (engine *PriceEngine) GetBestPrices(c *gin.Context) {
+	c.JSON(http.StatusOK, engine.getBestPrices())
+}
+
+// GetEligibilityCacheStats returns the provider eligibility cache hit, miss and refresh counters.
+func (engine *PriceEngine) GetEligibilityCacheStats(c *gin.Context) {
+	stats := ProviderConfig.GetEligibilityCacheStats()
+	if stats == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "eligibility cache is not running"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
 }
 
 // recalculatePriceUpdates chooses the best bid and ask prices based on all enabled
 // price updates generally this is called when a provider is enabled or disabled
-// as it's a bit more expensive than simply checking the previous best price
-func ReCalculateBestPrices(c *gin.Context) {
-	// We can return a result quickly
-	c.Status(http.StatusOK)
-
-	// I am unsure if this needs to be in another thread
-	// depends on how Gin works it's contexts but it
-	// should be safe to do so
-	go func() {
-		newAskUpdates := make(map[string]*PriceUpdate)
-		newBidUpdates := make(map[string]*PriceUpdate)
-
-		// Loop through all providers we have recevied prices from
-		for _, providerName := range getProviderList() {
-			// Loop through all currency pairs attached to that provider
-			for pairName, updatePriceReq := range getLastPriceUpdateRequests(providerName) {
-				if newAskUpdates[pairName] == nil || updatePriceReq.Ask > newAskUpdates[pairName].Price {
-					newAskUpdates[pairName] = updatePriceReq.NewPriceUpdateAsk()
-				}
-
-				if newBidUpdates[pairName] == nil || updatePriceReq.Bid > newBidUpdates[pairName].Price {
-					newBidUpdates[pairName] = updatePriceReq.NewPriceUpdateBid()
-				}
-			}
-		}
+// as it's a bit more expensive than simply checking the previous best price.
+// Each pair is recalculated after any updates already queued for it, with
+// ack=applied the reply waits until every pair has been.
+func (engine *PriceEngine) ReCalculateBestPrices(c *gin.Context) {
+	waitApplied, err := getAck(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Detached from the request so queued recalculations outlive it
+	ctx, span := Tracing.Start(Tracing.ContextWithSpanContext(trace.SpanContextFromContext(c.Request.Context())), "PriceAPI.recalculateBestPrices")
+	defer span.End()
 
-		// Update all new ask updates
-		for pairName, update := range newAskUpdates {
-			// Check again that it's better (a better update may have come in)
-			if update.Price > GetBestAskPrice(pairName).Price {
-				updateBestAskPrice(update)
-				emitPriceUpdate(update, "Ask")
-			}
-		}
+	// The config change that triggered this may have come from another process
+	// so make sure the eligibility cache has picked it up first
+	if err := engine.Eligibility.Refresh(); err != nil {
+		fmt.Println("Error refreshing eligibility cache:", err)
+		Tracing.RecordError(span, err)
+	}
 
-		// Update all new bid updates
-		for pairName, update := range newBidUpdates {
-			// Check again that it's better (a better update may have come in)
-			if update.Price > GetBestBidPrice(pairName).Price {
-				updateBestBidPrice(update)
-				emitPriceUpdate(update, "Bid")
-			}
-		}
-	}()
+	// Rebuild the book for every pair we have received prices for
+	if !engine.enqueueRecalculation(ctx, engine.getPairList(), engine.now(), waitApplied) {
+		abortEngineClosed(c)
+		return
+	}
+	c.Status(http.StatusOK)
 }
 
 // emitPriceUpdateUpdate is called when we have a new best price update
-// to communicate. In this case we just append to a log file.
-func emitPriceUpdate(update *PriceUpdate, updateType string) {
-	var logEntry string
-	if update != nil {
-		logEntry = fmt.Sprintf("%s - %s - %.2f - %.2f - %s\n", updateType, update.Provider, update.Price, update.Amount, time.Unix(update.Timestamp/1000, 0))
-	} else {
-		logEntry = fmt.Sprintf("%s - No best price available\n", updateType)
-	}
-	fmt.Println(logEntry)
-	// Append to our log file
-	if err := Helpers.AppendToFile(PriceUpdatesLogFile, logEntry); err != nil {
-		fmt.Println("Error appending to log file:", err)
-	}
+// to communicate. We push it to any stream subscribers and every registered sink.
+func (engine *PriceEngine) emitPriceUpdate(ctx context.Context, pairName string, update *PriceUpdate, updateType PriceUpdateType) {
+	bestPriceChanges.WithLabelValues(pairName, updateType).Inc()
+	engine.streamHub.broadcast(pairName, update, updateType)
+	engine.emitPriceEvent(ctx, engine.newBestPriceEvent(pairName, update, updateType))
+}
+
+// newBestPriceEvent creates a best price event that is logged to the engine's precision for the pair.
+func (engine *PriceEngine) newBestPriceEvent(pairName string, update *PriceUpdate, updateType PriceUpdateType) *PriceEvent {
+	event := NewBestPriceEvent(pairName, update, updateType, engine.now())
+	event.precision = engine.getInstrumentPrecision(pairName)
+	return event
+}
+
+// emitPriceEvent publishes any event to every registered sink as part of the trace in the context.
+func (engine *PriceEngine) emitPriceEvent(ctx context.Context, event *PriceEvent) {
+	event.spanContext = trace.SpanContextFromContext(ctx)
+	engine.Sinks.Publish(event)
 }
@@ -3,26 +3,22 @@ package PriceAPI
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/assert/v2"
 	"github.com/hongkongkiwi/chaostheory/src/Helpers"
 	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/shopspring/decimal"
 )
 
 func TestProcessPriceUpdate(t *testing.T) {
-	tmpFile, tmpFileErr := os.CreateTemp("", "templogfile")
-	if tmpFileErr != nil {
-		fmt.Println("Error creating temporary file:", tmpFileErr)
-		return
-	}
-	defer os.Remove(tmpFile.Name()) // Clean up: delete the temporary file when done
-	PriceUpdatesLogFile = tmpFile.Name()
+	engine := NewPriceEngine()
+	defer engine.Close()
 
 	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestProcessPriceUpdate")
 	if tempErr != nil {
@@ -37,16 +33,18 @@ func TestProcessPriceUpdate(t *testing.T) {
 		ProviderConfig.CloseDB()
 		os.Remove(tmpDBFileName.Name())
 	}()
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("BTC", "USD"))
+
 	// Create a test PriceUpdateRequest
 	update := PriceUpdateRequest{
 		Provider:  "TestProvider",
 		Base:      "BTC",
 		Quote:     "USD",
-		Bid:       45000,
-		BidAmount: 1,
-		Ask:       45500,
-		AskAmount: 1,
-		Timestamp: 1615299600, // 2021-03-09 00:00:00 UTC
+		Bid:       decimal.NewFromInt(45000),
+		BidAmount: decimal.NewFromInt(1),
+		Ask:       decimal.NewFromInt(45500),
+		AskAmount: decimal.NewFromInt(1),
+		Timestamp: 1615299600000, // 2021-03-09 14:20:00 UTC
 	}
 	body, _ := json.Marshal(update)
 
@@ -64,20 +62,34 @@ func TestProcessPriceUpdate(t *testing.T) {
 	c.Request = req
 
 	// Call the handler function to process the price update
-	ProcessPriceUpdateRequest(c)
+	engine.ProcessPriceUpdateRequest(c)
 
 	// Check the response status code
 	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Updates that don't fit the instrument are rejected
+	invalidUpdates := map[string]PriceUpdateRequest{
+		"unregistered": {Provider: "TestProvider", Base: "BTC", Quote: "NZD", Bid: decimal.NewFromInt(45000), Ask: decimal.NewFromInt(45500)},
+		"off tick":     {Provider: "TestProvider", Base: "BTC", Quote: "USD", Bid: decimal.RequireFromString("45000.00001"), Ask: decimal.NewFromInt(45500)},
+		"negative":     {Provider: "TestProvider", Base: "BTC", Quote: "USD", Bid: decimal.NewFromInt(45000), BidAmount: decimal.NewFromInt(-1), Ask: decimal.NewFromInt(45500)},
+		"seconds":      {Provider: "TestProvider", Base: "BTC", Quote: "USD", Bid: decimal.NewFromInt(45000), Ask: decimal.NewFromInt(45500), Timestamp: 1615299600},
+		"future":       {Provider: "TestProvider", Base: "BTC", Quote: "USD", Bid: decimal.NewFromInt(45000), Ask: decimal.NewFromInt(45500), Timestamp: time.Now().Add(time.Hour).UnixMilli()},
+	}
+	for name, invalidUpdate := range invalidUpdates {
+		body, _ := json.Marshal(invalidUpdate)
+		rr := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rr)
+		c.Request, _ = http.NewRequest("POST", "/price/update", bytes.NewBuffer(body))
+		engine.ProcessPriceUpdateRequest(c)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s update to be rejected; got %d", name, rr.Code)
+		}
+	}
 }
 
 func TestReCalculateBestPrices(t *testing.T) {
-	tmpFile, tmpFileErr := os.CreateTemp("", "templogfile")
-	if tmpFileErr != nil {
-		fmt.Println("Error creating temporary file:", tmpFileErr)
-		return
-	}
-	defer os.Remove(tmpFile.Name()) // Clean up: delete the temporary file when done
-	PriceUpdatesLogFile = tmpFile.Name()
+	engine := NewPriceEngine()
+	defer engine.Close()
 
 	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestReCalculateBestPrices")
 	if tempErr != nil {
@@ -108,8 +120,66 @@ func TestReCalculateBestPrices(t *testing.T) {
 	c.Request = req
 
 	// Call the handler function to recalculate best prices
-	ReCalculateBestPrices(c)
+	engine.ReCalculateBestPrices(c)
 
 	// Check the response status code
 	assert.Equal(t, http.StatusOK, rr.Code)
 }
+
+func TestGetBestPricesForPair(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	engine.setBestBidPrice("BTC/USD", &PriceUpdate{Provider: "ProviderA", Base: "BTC", Quote: "USD", Price: decimal.NewFromInt(45000), Amount: decimal.NewFromInt(1), Timestamp: 1615299600})
+	engine.setBestAskPrice("BTC/USD", &PriceUpdate{Provider: "ProviderB", Base: "BTC", Quote: "USD", Price: decimal.NewFromInt(45500), Amount: decimal.NewFromInt(2), Timestamp: 1615299600})
+
+	// Initialize a new Gin router
+	router := gin.Default()
+	router.GET("/prices", engine.GetBestPrices)
+	router.GET("/prices/:base/:quote", engine.GetBestPricesForPair)
+
+	// A quoted pair returns both sides and the spread
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/prices/BTC/USD", nil)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var bestPrice BestPrice
+	if err := json.Unmarshal(rr.Body.Bytes(), &bestPrice); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "ProviderA", bestPrice.Bid.Provider)
+	assert.Equal(t, "ProviderB", bestPrice.Ask.Provider)
+	assert.Equal(t, "500", bestPrice.Spread.String())
+
+	// A pair that has never been quoted returns 404
+	rr = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/prices/ETH/USD", nil)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// All pairs are returned keyed by pair name
+	rr = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/prices", nil)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var bestPrices map[string]*BestPrice
+	if err := json.Unmarshal(rr.Body.Bytes(), &bestPrices); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(bestPrices))
+	assert.Equal(t, "45000", bestPrices["BTC/USD"].Bid.Price.String())
+}
+
+func TestPriceUpdateRequestDecimals(t *testing.T) {
+	// Prices can be sent as JSON numbers or strings and are kept exactly
+	body := `{"provider":"ProviderA","base":"USD","quote":"JPY","bid":110.07,"bid_amount":"1000","ask":"110.08","ask_amount":1000}`
+	var update PriceUpdateRequest
+	if err := json.Unmarshal([]byte(body), &update); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "110.07", update.Bid.String())
+	assert.Equal(t, "110.08", update.Ask.String())
+	assert.Equal(t, "0.01", update.GetSpread().String())
+}
@@ -0,0 +1,116 @@
+package PriceAPI
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Largest number of updates accepted in a single batch
+var MaxPriceBatchSize = 1000
+
+// PriceBatchResult is whether a single update in a batch was accepted.
+type PriceBatchResult struct {
+	Index    int    `json:"index"`
+	Provider string `json:"provider"`
+	Pair     string `json:"pair"`
+	Accepted bool   `json:"accepted"`
+	Error    string `json:"error,omitempty"`
+}
+
+// PriceBatchResponse reports the outcome of every update in a batch, in request order.
+type PriceBatchResponse struct {
+	Accepted int                 `json:"accepted"`
+	Rejected int                 `json:"rejected"`
+	Results  []*PriceBatchResult `json:"results"`
+}
+
+// ProcessPriceUpdateBatchRequest accepts an array of price updates, validating each one
+// on its own. Best prices are recalculated once for each pair the batch touches, with
+// ack=applied the reply waits until they have been.
+func (engine *PriceEngine) ProcessPriceUpdateBatchRequest(c *gin.Context) {
+	waitApplied, err := getAck(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var updates []*PriceUpdateRequest
+	if err := c.BindJSON(&updates); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty batch"})
+		return
+	}
+	if len(updates) > MaxPriceBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("batch has %d updates, the maximum is %d", len(updates), MaxPriceBatchSize)})
+		return
+	}
+
+	// Reserve space for the whole batch before validating so a full queue doesn't use up
+	// sequence numbers
+	if !engine.reserveIngestQueue(len(updates)) {
+		for _, update := range updates {
+			if update != nil {
+				engine.recordQueueFull(update.Provider)
+			}
+		}
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "ingest queue is full"})
+		return
+	}
+
+	receivedAt := engine.now()
+	retryAfter := time.Duration(0)
+	response := &PriceBatchResponse{Results: make([]*PriceBatchResult, 0, len(updates))}
+	accepted := make([]*PriceUpdateRequest, 0, len(updates))
+	for i, update := range updates {
+		result := &PriceBatchResult{Index: i}
+		response.Results = append(response.Results, result)
+		if update == nil {
+			result.Error = "empty update"
+			response.Rejected++
+			continue
+		}
+		result.Provider = update.Provider
+		result.Pair = update.GetPairName()
+
+		update.ReceivedAt = receivedAt
+		update.spanContext = trace.SpanContextFromContext(c.Request.Context())
+		if err := checkAuthenticatedProvider(c, update); err != nil {
+			result.Error = err.Error()
+			response.Rejected++
+			continue
+		}
+		if wait, err := engine.checkRateLimit(update); err != nil {
+			result.Error = err.Error()
+			response.Rejected++
+			retryAfter = max(retryAfter, wait)
+			continue
+		}
+		if _, err := engine.validatePriceUpdateRequest(update); err != nil {
+			result.Error = err.Error()
+			response.Rejected++
+			continue
+		}
+		result.Accepted = true
+		response.Accepted++
+		accepted = append(accepted, update)
+	}
+
+	engine.releaseIngestQueue(len(updates) - len(accepted))
+	if len(accepted) > 0 && !engine.enqueuePriceUpdates(accepted, waitApplied) {
+		abortEngineClosed(c)
+		return
+	}
+
+	// Rate limited updates can be sent again after this long
+	if retryAfter > 0 {
+		c.Header("Retry-After", retryAfterSeconds(retryAfter))
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,79 @@
+package PriceAPI
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessPriceUpdateBatchRequest(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestProcessPriceUpdateBatchRequest")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("ADA", "SGD"))
+	ProviderConfig.SetPairEnabled("ProviderA", "ADA/SGD", true)
+	ProviderConfig.SetPairEnabled("ProviderB", "ADA/SGD", true)
+
+	router := gin.Default()
+	router.POST("/prices/batch", engine.ProcessPriceUpdateBatchRequest)
+
+	updates := []*PriceUpdateRequest{
+		{Provider: "ProviderA", Base: "ADA", Quote: "SGD", Bid: decimal.RequireFromString("0.51"), BidAmount: decimal.NewFromInt(100), Ask: decimal.RequireFromString("0.53"), AskAmount: decimal.NewFromInt(100), Timestamp: time.Now().UnixMilli()},
+		{Provider: "ProviderB", Base: "ADA", Quote: "SGD", Bid: decimal.RequireFromString("0.52"), BidAmount: decimal.NewFromInt(50), Ask: decimal.RequireFromString("0.54"), AskAmount: decimal.NewFromInt(50), Timestamp: time.Now().UnixMilli()},
+		// Not a registered instrument
+		{Provider: "ProviderA", Base: "ADA", Quote: "NZD", Bid: decimal.RequireFromString("0.51"), Ask: decimal.RequireFromString("0.53")},
+		// Crossed
+		{Provider: "ProviderB", Base: "ADA", Quote: "SGD", Bid: decimal.RequireFromString("0.55"), Ask: decimal.RequireFromString("0.54")},
+	}
+	body, _ := json.Marshal(updates)
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/prices/batch", bytes.NewBuffer(body))
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response PriceBatchResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, response.Accepted)
+	assert.Equal(t, 2, response.Rejected)
+	assert.True(t, response.Results[0].Accepted)
+	assert.True(t, response.Results[1].Accepted)
+	assert.False(t, response.Results[2].Accepted)
+	assert.Equal(t, "ADA/NZD", response.Results[2].Pair)
+	assert.NotEmpty(t, response.Results[2].Error)
+	assert.False(t, response.Results[3].Accepted)
+
+	// Both accepted updates are considered when the pair is recalculated
+	assert.Eventually(t, func() bool {
+		bestBid, bestAsk := engine.GetBestBidPrice("ADA/SGD"), engine.GetBestAskPrice("ADA/SGD")
+		return bestBid != nil && bestBid.Provider == "ProviderB" && bestAsk != nil && bestAsk.Provider == "ProviderA"
+	}, time.Second, 10*time.Millisecond)
+
+	// Empty batches are rejected outright
+	rr = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/prices/batch", bytes.NewBufferString("[]"))
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,155 @@
+package PriceAPI
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+)
+
+// EligibilitySource decides whether a provider's quotes for a pair can be chosen as the best price.
+type EligibilitySource interface {
+	GetProviderPairEnabled(providerName string, pairName string) (bool, error)
+	// Refresh picks up changes made by another process, it is called before every pair
+	// is recalculated
+	Refresh() error
+}
+
+// ProviderConfigEligibility reads eligibility from the provider database, through the
+// eligibility cache when it is running.
+type ProviderConfigEligibility struct{}
+
+func (ProviderConfigEligibility) GetProviderPairEnabled(providerName string, pairName string) (bool, error) {
+	return ProviderConfig.GetProviderPairEnabled(providerName, pairName)
+}
+
+func (ProviderConfigEligibility) Refresh() error {
+	return ProviderConfig.RefreshEligibilityCache()
+}
+
+// PriceEngine chooses the best bid and ask for every pair from its providers' quotes.
+// Each engine owns its books and ingest state so several can run in one process, and
+// can be used directly as a library or served with its gin handlers and gRPC service.
+//
+// Instruments, rate limits, credentials and outlier filter settings are not part of the
+// engine. They are process global ProviderConfig state, read from the provider database
+// (or its caches), so every engine in a process shares them and a change made through
+// one engine applies to all of them.
+type PriceEngine struct {
+	// Decides which providers can set the best price
+	Eligibility EligibilitySource
+	// Every best price, arbitrage and sequence gap event is published to these
+	Sinks *SinkRegistry
+	// Used for receive times, event times, quote expiry, rate limits and health checks
+	Clock func() time.Time
+	// When true price updates must be authenticated with a provider credential
+	ProviderAuthEnabled bool
+	// When true pairs scoring below HealthDisableThreshold are disabled, then re-enabled
+	// once they score HealthReEnableThreshold after the cool down
+	HealthAutoDisable bool
+
+	// Every pair's best price and provider quotes, by pair name
+	books atomic.Pointer[map[string]*pairBook]
+	// Serialises adding pairs
+	booksMu sync.Mutex
+
+	streamHub *streamHubState
+
+	openArbitrage map[string]*ArbitrageOpportunity
+	arbitrageMu   sync.RWMutex
+
+	seenSignatures map[string]time.Time
+	nextPrune      time.Time
+	signaturesMu   sync.Mutex
+
+	healthRecords map[string]map[string]*pairHealth
+	healthMu      sync.Mutex
+
+	// Validated updates and recalculations waiting to be applied, sharded by pair
+	ingestShards []chan *ingestTask
+	// Updates reserved or waiting in the queue
+	queuedUpdates    atomic.Int64
+	ingestQueueLimit atomic.Int64
+	ingestStats      map[string]*IngestStats
+	ingestStatsMu    sync.Mutex
+	stopIngest       chan struct{}
+	closeOnce        sync.Once
+
+	quarantinedQuotes map[string]map[string]*QuarantinedQuote
+	outlierStats      map[string]*OutlierStats
+	outlierMu         sync.RWMutex
+
+	// Quote TTLs control how long a provider quote is used for best price selection,
+	// a zero duration means quotes never expire
+	defaultQuoteTTL time.Duration
+	pairQuoteTTLs   map[string]time.Duration
+	ttlMu           sync.RWMutex
+
+	// Buckets for provider limits are keyed by provider name, pair limits by provider
+	// and pair name. The map is replaced when the limits change, rateLimitMu only
+	// orders the replacements.
+	rateLimitBuckets      atomic.Pointer[map[string]*tokenBucket]
+	rateLimitMu           sync.Mutex
+	unsubscribeRateLimits func()
+
+	// When true out of order and duplicate updates are rejected, otherwise they are
+	// accepted and only counted
+	rejectOutOfOrderUpdates bool
+	pairSequences           map[string]map[string]*pairSequence
+	sequenceStats           map[string]*SequenceStats
+	// Order updates were accepted in, used so a slow goroutine can't overwrite a newer quote
+	ingestSequence uint64
+	sequenceMu     sync.Mutex
+
+	latencyStats map[string]*LatencyStats
+	latencyMu    sync.Mutex
+
+	// Display precision set for pairs, overriding their instruments
+	instrumentPrecisions map[string]*instrumentPrecision
+	precisionMu          sync.RWMutex
+}
+
+// NewPriceEngine returns an engine with no sinks that reads eligibility from the
+// provider database and uses the system clock. Its ingest workers run until Close.
+func NewPriceEngine() *PriceEngine {
+	engine := &PriceEngine{
+		Eligibility:             ProviderConfigEligibility{},
+		Sinks:                   NewSinkRegistry(),
+		Clock:                   time.Now,
+		streamHub:               newStreamHub(),
+		openArbitrage:           make(map[string]*ArbitrageOpportunity),
+		seenSignatures:          make(map[string]time.Time),
+		healthRecords:           make(map[string]map[string]*pairHealth),
+		ingestStats:             make(map[string]*IngestStats),
+		stopIngest:              make(chan struct{}),
+		quarantinedQuotes:       make(map[string]map[string]*QuarantinedQuote),
+		outlierStats:            make(map[string]*OutlierStats),
+		pairQuoteTTLs:           make(map[string]time.Duration),
+		rejectOutOfOrderUpdates: true,
+		pairSequences:           make(map[string]map[string]*pairSequence),
+		sequenceStats:           make(map[string]*SequenceStats),
+		latencyStats:            make(map[string]*LatencyStats),
+		instrumentPrecisions:    make(map[string]*instrumentPrecision),
+	}
+	engine.books.Store(&map[string]*pairBook{})
+	engine.rateLimitBuckets.Store(&map[string]*tokenBucket{})
+	engine.unsubscribeRateLimits = ProviderConfig.SubscribeRateLimitChanges(engine.applyRateLimits)
+	engine.ingestQueueLimit.Store(ingestQueueCapacity)
+	engine.startIngestWorkers(IngestWorkers)
+	return engine
+}
+
+func (engine *PriceEngine) now() time.Time {
+	return engine.Clock()
+}
+
+// Close stops the ingest workers, stops following rate limit changes and closes every
+// sink. Updates and recalculations still waiting in the queue are dropped.
+func (engine *PriceEngine) Close() {
+	engine.closeOnce.Do(func() {
+		close(engine.stopIngest)
+		engine.unsubscribeRateLimits()
+		engine.Sinks.Close()
+	})
+}
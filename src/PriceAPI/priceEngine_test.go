@@ -0,0 +1,63 @@
+package PriceAPI
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubEligibility enables the providers in the map for every pair
+type stubEligibility map[string]bool
+
+func (s stubEligibility) GetProviderPairEnabled(providerName string, pairName string) (bool, error) {
+	return s[providerName], nil
+}
+
+func (s stubEligibility) Refresh() error { return nil }
+
+func TestPriceEnginesAreIsolated(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	engineA := NewPriceEngine()
+	defer engineA.Close()
+	engineA.Eligibility = stubEligibility{"EngineProviderA": true, "EngineProviderB": true}
+	engineB := NewPriceEngine()
+	defer engineB.Close()
+	engineB.Eligibility = stubEligibility{"EngineProviderB": true}
+	engineB.Clock = func() time.Time { return now }
+	engineB.SetQuoteTTL(time.Minute)
+
+	sinkA := NewChannelSink("engineA", 100)
+	sinkB := NewChannelSink("engineB", 100)
+	assert.NoError(t, engineA.Sinks.Register(sinkA))
+	assert.NoError(t, engineB.Sinks.Register(sinkB))
+
+	for _, engine := range []*PriceEngine{engineA, engineB} {
+		engine.saveProviderUpdateRequest(&PriceUpdateRequest{Provider: "EngineProviderA", Base: "KSM", Quote: "ISK", Bid: decimal.NewFromInt(100), BidAmount: decimal.NewFromInt(1), Timestamp: 1})
+		engine.saveProviderUpdateRequest(&PriceUpdateRequest{Provider: "EngineProviderB", Base: "KSM", Quote: "ISK", Bid: decimal.NewFromInt(99), BidAmount: decimal.NewFromInt(1), Timestamp: 1})
+		engine.recalculateBestPricesForPair(context.Background(), "KSM/ISK", engine.now())
+	}
+
+	// Each engine chooses from its own providers
+	assert.Equal(t, "EngineProviderA", engineA.GetBestBidPrice("KSM/ISK").Provider)
+	assert.Equal(t, "EngineProviderB", engineB.GetBestBidPrice("KSM/ISK").Provider)
+	assert.Equal(t, "EngineProviderA", waitForEvent(t, sinkA, BestPriceEventType).Price.Provider)
+	if event := waitForEvent(t, sinkB, BestPriceEventType); assert.NotNil(t, event) {
+		assert.Equal(t, "EngineProviderB", event.Price.Provider)
+		// Events are stamped with the engine's clock too
+		assert.Equal(t, now, event.EmittedAt)
+	}
+
+	// Quotes are stamped with the engine's clock and only expire in the engine with a TTL
+	quotes := engineB.getProviderQuotes("KSM/ISK", "EngineProviderB", now)
+	if assert.Len(t, quotes, 1) {
+		assert.Equal(t, now, quotes[0].ReceivedAt)
+	}
+	now = now.Add(2 * time.Minute)
+	engineA.sweepStaleQuotes(now)
+	engineB.sweepStaleQuotes(now)
+	assert.Nil(t, engineB.GetBestBidPrice("KSM/ISK"))
+	assert.Equal(t, "EngineProviderA", engineA.GetBestBidPrice("KSM/ISK").Provider)
+}
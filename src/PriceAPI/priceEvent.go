@@ -0,0 +1,81 @@
+package PriceAPI
+
+import (
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type PriceEventType = string
+
+const (
+	// A best bid or ask has changed, or there is no longer one available
+	BestPriceEventType PriceEventType = "BestPrice"
+	// An arbitrage opportunity has been detected across providers or pairs
+	ArbitrageOpenedEventType PriceEventType = "ArbitrageOpened"
+	// A previously detected arbitrage opportunity is no longer available
+	ArbitrageClosedEventType PriceEventType = "ArbitrageClosed"
+	// Updates from a provider have skipped one or more sequence numbers
+	SequenceGapEventType PriceEventType = "SequenceGap"
+)
+
+// PriceEvent is published to every registered PriceSink.
+type PriceEvent struct {
+	Type      PriceEventType        `json:"type"`
+	Pair      string                `json:"pair,omitempty"`
+	Side      PriceUpdateType       `json:"side,omitempty"`
+	Price     *PriceUpdate          `json:"price"`
+	Arbitrage *ArbitrageOpportunity `json:"arbitrage,omitempty"`
+	Gap       *SequenceGap          `json:"gap,omitempty"`
+	EmittedAt time.Time             `json:"emitted_at"`
+	// Span the event was emitted in, so sinks can continue the trace
+	spanContext trace.SpanContext
+	// Decimal places the price is logged to, the defaults when nil
+	precision *instrumentPrecision
+}
+
+// NewBestPriceEvent creates an event for a best price change, a nil update means no best price is available.
+func NewBestPriceEvent(pairName string, update *PriceUpdate, updateType PriceUpdateType, emittedAt time.Time) *PriceEvent {
+	return &PriceEvent{
+		Type:      BestPriceEventType,
+		Pair:      pairName,
+		Side:      updateType,
+		Price:     update,
+		EmittedAt: emittedAt,
+	}
+}
+
+// NewArbitrageEvent creates an event for an arbitrage opportunity opening or closing.
+func NewArbitrageEvent(eventType PriceEventType, opportunity *ArbitrageOpportunity, emittedAt time.Time) *PriceEvent {
+	return &PriceEvent{
+		Type:      eventType,
+		Pair:      opportunity.Pair,
+		Arbitrage: opportunity,
+		EmittedAt: emittedAt,
+	}
+}
+
+// NewSequenceGapEvent creates a provider health event for missed sequence numbers.
+func NewSequenceGapEvent(gap *SequenceGap, emittedAt time.Time) *PriceEvent {
+	return &PriceEvent{
+		Type:      SequenceGapEventType,
+		Pair:      gap.Pair,
+		Gap:       gap,
+		EmittedAt: emittedAt,
+	}
+}
+
+// String formats the event as a single line for the text log.
+func (e *PriceEvent) String() string {
+	if e.Arbitrage != nil {
+		return fmt.Sprintf("%s - %s - %s - %s %s\n", e.Type, e.Arbitrage.Type, e.Arbitrage.ID, e.Arbitrage.TheoreticalProfit.String(), e.Arbitrage.ProfitCurrency)
+	}
+	if e.Gap != nil {
+		return fmt.Sprintf("%s - %s - %s - expected %d received %d\n", e.Type, e.Gap.Provider, e.Gap.Pair, e.Gap.Expected, e.Gap.Received)
+	}
+	if e.Price != nil {
+		return fmt.Sprintf("%s - %s - %s - %s - %s\n", e.Side, e.Price.Provider, e.Price.Price.StringFixed(e.precision.getPrice()), e.Price.Amount.StringFixed(e.precision.getAmount()), time.UnixMilli(e.Price.Timestamp))
+	}
+	return fmt.Sprintf("%s - %s - No best price available\n", e.Side, e.Pair)
+}
@@ -1,14 +1,18 @@
 package PriceAPI
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
 
 type PriceUpdate struct {
-	Provider  string
-	Base      string
-	Quote     string
-	Price     float64
-	Amount    float64
-	Timestamp int64
+	Provider  string          `json:"provider"`
+	Base      string          `json:"base"`
+	Quote     string          `json:"quote"`
+	Price     decimal.Decimal `json:"price"`
+	Amount    decimal.Decimal `json:"amount"`
+	Timestamp int64           `json:"timestamp"`
 }
 
 // GetPairName returns the pair name based on the Base and Quote fields of the PriceUpdateRequest.
@@ -18,3 +22,13 @@ type PriceUpdate struct {
 func (p *PriceUpdate) GetPairName() string {
 	return fmt.Sprintf("%s/%s", p.Base, p.Quote)
 }
+
+// Equal reports whether two price updates are identical, comparing prices and amounts exactly.
+func (p *PriceUpdate) Equal(other *PriceUpdate) bool {
+	return p.Provider == other.Provider &&
+		p.Base == other.Base &&
+		p.Quote == other.Quote &&
+		p.Timestamp == other.Timestamp &&
+		p.Price.Equal(other.Price) &&
+		p.Amount.Equal(other.Amount)
+}
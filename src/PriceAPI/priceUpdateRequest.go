@@ -1,16 +1,42 @@
 package PriceAPI
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/Tracing"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/trace"
+)
 
 type PriceUpdateRequest struct {
-	Provider  string  `json:"provider"`
-	Base      string  `json:"base"`
-	Quote     string  `json:"quote"`
-	Bid       float64 `json:"bid"`
-	BidAmount float64 `json:"bid_amount"`
-	Ask       float64 `json:"ask"`
-	AskAmount float64 `json:"ask_amount"`
-	Timestamp int64   `json:"timestamp"`
+	Provider  string          `json:"provider"`
+	Base      string          `json:"base"`
+	Quote     string          `json:"quote"`
+	Bid       decimal.Decimal `json:"bid"`
+	BidAmount decimal.Decimal `json:"bid_amount"`
+	Ask       decimal.Decimal `json:"ask"`
+	AskAmount decimal.Decimal `json:"ask_amount"`
+	Timestamp int64           `json:"timestamp"`
+	// Optional per provider, per pair sequence number, 0 means not set
+	Sequence uint64 `json:"sequence,omitempty"`
+	// When the PriceAPI received this update, set on ingest
+	ReceivedAt time.Time `json:"-"`
+	// Order the update was accepted in, set by checkUpdateSequence
+	ingestSequence uint64
+	// Whether sequence numbers were skipped before this update, set by checkUpdateSequence
+	sequenceGap bool
+	// Whether the update is older than the provider's last one, set by checkUpdateSequence
+	// when out of order updates are flagged rather than rejected. It never reaches the book.
+	outOfOrder bool
+	// Span the update was received in, so its trace continues once it is dequeued
+	spanContext trace.SpanContext
+}
+
+// traceContext returns a context continuing the span the update was received in.
+func (req *PriceUpdateRequest) traceContext() context.Context {
+	return Tracing.ContextWithSpanContext(req.spanContext)
 }
 
 func (req *PriceUpdateRequest) NewPriceUpdateAsk() *PriceUpdate {
@@ -43,6 +69,6 @@ func (p *PriceUpdateRequest) GetPairName() string {
 	return fmt.Sprintf("%s/%s", p.Base, p.Quote)
 }
 
-func (p *PriceUpdateRequest) GetSpread() float64 {
-	return p.Ask - p.Bid
+func (p *PriceUpdateRequest) GetSpread() decimal.Decimal {
+	return p.Ask.Sub(p.Bid)
 }
@@ -0,0 +1,221 @@
+package PriceAPI
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How long an expired quote is kept (and shown as stale) before it is evicted entirely
+var StaleQuoteRetention = time.Hour
+
+// QuoteTTLsRequest holds the default and per pair quote TTLs as duration strings such as "30s"
+type QuoteTTLsRequest struct {
+	Default string            `json:"default"`
+	Pairs   map[string]string `json:"pairs"`
+}
+
+// ProviderQuote is a provider's last update request along with its expiry status.
+type ProviderQuote struct {
+	*PriceUpdateRequest
+	ReceivedAt time.Time  `json:"received_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Stale      bool       `json:"stale"`
+}
+
+// SetQuoteTTL sets the TTL used by every pair without its own TTL,
+// a zero duration means quotes never expire.
+func (engine *PriceEngine) SetQuoteTTL(ttl time.Duration) {
+	engine.ttlMu.Lock()
+	defer engine.ttlMu.Unlock()
+	engine.defaultQuoteTTL = ttl
+}
+
+// SetPairQuoteTTL sets the TTL for a single pair, overriding the default.
+// A negative duration removes the override.
+func (engine *PriceEngine) SetPairQuoteTTL(pairName string, ttl time.Duration) {
+	engine.ttlMu.Lock()
+	defer engine.ttlMu.Unlock()
+	if ttl < 0 {
+		delete(engine.pairQuoteTTLs, pairName)
+		return
+	}
+	engine.pairQuoteTTLs[pairName] = ttl
+}
+
+func (engine *PriceEngine) getQuoteTTL(pairName string) time.Duration {
+	engine.ttlMu.RLock()
+	defer engine.ttlMu.RUnlock()
+	if ttl, ok := engine.pairQuoteTTLs[pairName]; ok {
+		return ttl
+	}
+	return engine.defaultQuoteTTL
+}
+
+// getQuoteExpiry returns when a quote expires, or nil if its pair has no TTL.
+func (engine *PriceEngine) getQuoteExpiry(update *PriceUpdateRequest) *time.Time {
+	ttl := engine.getQuoteTTL(update.GetPairName())
+	if ttl <= 0 {
+		return nil
+	}
+	expiresAt := update.ReceivedAt.Add(ttl)
+	return &expiresAt
+}
+
+// isQuoteStale reports whether a quote has expired and should no longer be used.
+func (engine *PriceEngine) isQuoteStale(update *PriceUpdateRequest, now time.Time) bool {
+	expiresAt := engine.getQuoteExpiry(update)
+	return expiresAt != nil && !now.Before(*expiresAt)
+}
+
+// sweepStaleQuotes re-runs best price selection for every pair whose best bid or ask
+// came from a quote that has expired by now, and evicts quotes that have been stale for
+// longer than StaleQuoteRetention. Returns once the recalculations have been applied and
+// the books left empty have been dropped.
+func (engine *PriceEngine) sweepStaleQuotes(now time.Time) {
+	affectedPairs := make(map[string]bool)
+	for pairName, book := range engine.getBooks() {
+		bid, ask := book.bestSides()
+		book.mu.Lock()
+		for providerName, update := range book.quotes {
+			expiresAt := engine.getQuoteExpiry(update)
+			if expiresAt == nil || now.Before(*expiresAt) {
+				continue
+			}
+			// Expired quotes only change the best price if they are the best price
+			if (bid != nil && bid.Provider == providerName) || (ask != nil && ask.Provider == providerName) {
+				affectedPairs[pairName] = true
+			}
+			if now.Sub(*expiresAt) >= StaleQuoteRetention {
+				delete(book.quotes, providerName)
+			}
+		}
+		book.mu.Unlock()
+	}
+
+	pairNames := make([]string, 0, len(affectedPairs))
+	for pairName := range affectedPairs {
+		fmt.Printf("Best price for %s has expired, recalculating\n", pairName)
+		pairNames = append(pairNames, pairName)
+	}
+	engine.enqueueRecalculation(context.Background(), pairNames, now, true)
+	engine.dropEmptyBooks()
+}
+
+// StartQuoteSweeper checks for expired quotes every interval until the returned stop function is called.
+func (engine *PriceEngine) StartQuoteSweeper(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				engine.sweepStaleQuotes(engine.now())
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// getProviderQuotes returns every provider's last quote, optionally filtered by pair and provider.
+func (engine *PriceEngine) getProviderQuotes(pairName string, providerName string, now time.Time) []*ProviderQuote {
+	quotes := make([]*ProviderQuote, 0)
+	for quotePair, book := range engine.getBooks() {
+		if pairName != "" && pairName != quotePair {
+			continue
+		}
+		book.mu.Lock()
+		for quoteProvider, update := range book.quotes {
+			if providerName != "" && providerName != quoteProvider {
+				continue
+			}
+			quotes = append(quotes, &ProviderQuote{
+				PriceUpdateRequest: update,
+				ReceivedAt:         update.ReceivedAt,
+				ExpiresAt:          engine.getQuoteExpiry(update),
+				Stale:              engine.isQuoteStale(update, now),
+			})
+		}
+		book.mu.Unlock()
+	}
+	sort.Slice(quotes, func(i, j int) bool {
+		if quotes[i].GetPairName() != quotes[j].GetPairName() {
+			return quotes[i].GetPairName() < quotes[j].GetPairName()
+		}
+		return quotes[i].Provider < quotes[j].Provider
+	})
+	return quotes
+}
+
+// GetProviderQuotes returns the last quote from each provider including whether it has gone stale.
+// Results can be filtered with the pair (e.g. BTC/USD) and provider query params.
+func (engine *PriceEngine) GetProviderQuotes(c *gin.Context) {
+	c.JSON(http.StatusOK, engine.getProviderQuotes(c.Query("pair"), c.Query("provider"), engine.now()))
+}
+
+// GetQuoteTTLs returns the current default and per pair quote TTLs.
+func (engine *PriceEngine) GetQuoteTTLs(c *gin.Context) {
+	engine.ttlMu.RLock()
+	defer engine.ttlMu.RUnlock()
+	pairs := make(map[string]string)
+	for pairName, ttl := range engine.pairQuoteTTLs {
+		pairs[pairName] = ttl.String()
+	}
+	c.JSON(http.StatusOK, &QuoteTTLsRequest{Default: engine.defaultQuoteTTL.String(), Pairs: pairs})
+}
+
+// SetQuoteTTLs updates the default and/or per pair quote TTLs then re-runs best price selection.
+// A pair TTL of "" removes the override for that pair.
+func (engine *PriceEngine) SetQuoteTTLs(c *gin.Context) {
+	var req QuoteTTLsRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate everything before changing anything
+	var defaultTTL time.Duration
+	var err error
+	if req.Default != "" {
+		if defaultTTL, err = time.ParseDuration(req.Default); err != nil || defaultTTL < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid default ttl %q", req.Default)})
+			return
+		}
+	}
+	pairTTLs := make(map[string]time.Duration)
+	for pairName, ttlStr := range req.Pairs {
+		if ttlStr == "" {
+			pairTTLs[pairName] = -1
+			continue
+		}
+		ttl, err := time.ParseDuration(ttlStr)
+		if err != nil || ttl < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid ttl %q for %s", ttlStr, pairName)})
+			return
+		}
+		pairTTLs[pairName] = ttl
+	}
+
+	if req.Default != "" {
+		engine.SetQuoteTTL(defaultTTL)
+	}
+	for pairName, ttl := range pairTTLs {
+		engine.SetPairQuoteTTL(pairName, ttl)
+	}
+
+	c.Status(http.StatusOK)
+
+	// Quotes may have become stale or fresh under the new TTLs
+	engine.enqueueRecalculation(context.Background(), engine.getPairList(), engine.now(), false)
+}
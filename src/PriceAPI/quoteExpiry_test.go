@@ -0,0 +1,103 @@
+package PriceAPI
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSweepStaleQuotes(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestSweepStaleQuotes")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	ProviderConfig.SetPairEnabled("ProviderA", "ETH/GBP", true)
+	ProviderConfig.SetPairEnabled("ProviderB", "ETH/GBP", true)
+
+	engine.SetPairQuoteTTL("ETH/GBP", time.Minute)
+
+	now := time.Now()
+	staleQuote := &PriceUpdateRequest{Provider: "ProviderA", Base: "ETH", Quote: "GBP", Bid: decimal.NewFromInt(100), BidAmount: decimal.NewFromInt(1), Ask: decimal.NewFromInt(101), AskAmount: decimal.NewFromInt(1), Timestamp: 1, ReceivedAt: now.Add(-2 * time.Minute)}
+	freshQuote := &PriceUpdateRequest{Provider: "ProviderB", Base: "ETH", Quote: "GBP", Bid: decimal.NewFromInt(99), BidAmount: decimal.NewFromInt(1), Ask: decimal.NewFromInt(102), AskAmount: decimal.NewFromInt(1), Timestamp: 1, ReceivedAt: now}
+	engine.saveProviderUpdateRequest(staleQuote)
+	engine.saveProviderUpdateRequest(freshQuote)
+
+	// Pretend ProviderA was the best on both sides before it went quiet
+	engine.setBestBidPrice("ETH/GBP", staleQuote.NewPriceUpdateBid())
+	engine.setBestAskPrice("ETH/GBP", staleQuote.NewPriceUpdateAsk())
+
+	engine.sweepStaleQuotes(now)
+
+	assert.Equal(t, "ProviderB", engine.GetBestBidPrice("ETH/GBP").Provider)
+	assert.Equal(t, "ProviderB", engine.GetBestAskPrice("ETH/GBP").Provider)
+
+	// The expired quote is still visible but flagged as stale
+	quotes := engine.getProviderQuotes("ETH/GBP", "", now)
+	assert.Equal(t, 2, len(quotes))
+	assert.Equal(t, "ProviderA", quotes[0].Provider)
+	assert.True(t, quotes[0].Stale)
+	assert.False(t, quotes[1].Stale)
+
+	// Once the retention period passes every quote is evicted and there is no best price left
+	engine.sweepStaleQuotes(now.Add(StaleQuoteRetention + 2*time.Minute))
+	quotes = engine.getProviderQuotes("ETH/GBP", "", now)
+	assert.Equal(t, 0, len(quotes))
+	assert.Nil(t, engine.GetBestBidPrice("ETH/GBP"))
+	assert.Nil(t, engine.GetBestAskPrice("ETH/GBP"))
+	// And the empty book is dropped so the pair is no longer listed
+	assert.NotContains(t, engine.getPairList(), "ETH/GBP")
+	assert.NotContains(t, engine.getBestPrices(), "ETH/GBP")
+
+	// A quote evicted by a sweep can come back
+	engine.saveProviderUpdateRequest(freshQuote)
+	assert.Contains(t, engine.getPairList(), "ETH/GBP")
+}
+
+func TestSweepStaleQuotesUsesSweepTime(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestSweepStaleQuotesUsesSweepTime")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	ProviderConfig.SetPairEnabled("ProviderA", "ETH/CHF", true)
+	engine.SetPairQuoteTTL("ETH/CHF", time.Minute)
+
+	// The quote is fresh by the engine's clock but has expired by the time the sweep is for
+	now := time.Now()
+	quote := &PriceUpdateRequest{Provider: "ProviderA", Base: "ETH", Quote: "CHF", Bid: decimal.NewFromInt(100), BidAmount: decimal.NewFromInt(1), Ask: decimal.NewFromInt(101), AskAmount: decimal.NewFromInt(1), Timestamp: 1, ReceivedAt: now}
+	engine.saveProviderUpdateRequest(quote)
+	engine.setBestBidPrice("ETH/CHF", quote.NewPriceUpdateBid())
+	engine.setBestAskPrice("ETH/CHF", quote.NewPriceUpdateAsk())
+
+	engine.sweepStaleQuotes(now.Add(2 * time.Minute))
+	assert.Nil(t, engine.GetBestBidPrice("ETH/CHF"))
+	assert.Nil(t, engine.GetBestAskPrice("ETH/CHF"))
+	// The quote is kept until the retention period passes so the book stays
+	assert.Contains(t, engine.getPairList(), "ETH/CHF")
+}
@@ -0,0 +1,109 @@
+package PriceAPI
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+)
+
+// tokenBucket allows rate updates a second with bursts of up to burst updates
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+func newTokenBucket(limit *ProviderConfig.RateLimit, now time.Time) *tokenBucket {
+	return &tokenBucket{rate: limit.Rate, burst: float64(limit.Burst), tokens: float64(limit.Burst), last: now}
+}
+
+// refill adds the tokens earned since the bucket was last used.
+func (bucket *tokenBucket) refill(now time.Time) {
+	if now.After(bucket.last) {
+		bucket.tokens = math.Min(bucket.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*bucket.rate)
+		bucket.last = now
+	}
+}
+
+// wait returns how long until the bucket has a token.
+func (bucket *tokenBucket) wait() time.Duration {
+	if bucket.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - bucket.tokens) / bucket.rate * float64(time.Second))
+}
+
+func rateLimitKey(providerName string, pairName string) string {
+	if pairName == "" {
+		return providerName
+	}
+	return providerName + "|" + pairName
+}
+
+// applyRateLimits swaps in buckets for new limits, it is called by ProviderConfig in
+// the background so checking a limit never reads the database. Buckets whose limit
+// hasn't changed keep their tokens.
+func (engine *PriceEngine) applyRateLimits(limits []*ProviderConfig.RateLimit) {
+	now := engine.now()
+	engine.rateLimitMu.Lock()
+	defer engine.rateLimitMu.Unlock()
+	current := engine.getRateLimitBuckets()
+	buckets := make(map[string]*tokenBucket, len(limits))
+	for _, limit := range limits {
+		key := rateLimitKey(limit.Provider, limit.Pair)
+		if bucket := current[key]; bucket != nil && bucket.rate == limit.Rate && bucket.burst == float64(limit.Burst) {
+			buckets[key] = bucket
+			continue
+		}
+		buckets[key] = newTokenBucket(limit, now)
+	}
+	engine.rateLimitBuckets.Store(&buckets)
+}
+
+func (engine *PriceEngine) getRateLimitBuckets() map[string]*tokenBucket {
+	return *engine.rateLimitBuckets.Load()
+}
+
+// checkRateLimit takes a token from the provider's limit and the pair's limit. If either
+// is empty the update is rejected, nothing is taken, and how long to wait is returned.
+func (engine *PriceEngine) checkRateLimit(update *PriceUpdateRequest) (time.Duration, error) {
+	now := engine.now()
+	rateLimitBuckets := engine.getRateLimitBuckets()
+
+	// Always the provider's bucket before the pair's so two updates can't deadlock
+	buckets := make([]*tokenBucket, 0, 2)
+	for _, key := range []string{rateLimitKey(update.Provider, ""), rateLimitKey(update.Provider, update.GetPairName())} {
+		if bucket := rateLimitBuckets[key]; bucket != nil {
+			bucket.mu.Lock()
+			defer bucket.mu.Unlock()
+			buckets = append(buckets, bucket)
+		}
+	}
+
+	retryAfter := time.Duration(0)
+	for _, bucket := range buckets {
+		bucket.refill(now)
+		if wait := bucket.wait(); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter > 0 {
+		engine.recordIngestRejection(update.Provider, func(stats *IngestStats) { stats.RateLimited++ })
+		recordUpdateRejected(update.Provider, RejectRateLimited)
+		return retryAfter, fmt.Errorf("%s is over its rate limit for %s", update.Provider, update.GetPairName())
+	}
+	for _, bucket := range buckets {
+		bucket.tokens--
+	}
+	return 0, nil
+}
+
+// retryAfterSeconds rounds a wait up to the whole seconds used by the Retry-After header.
+func retryAfterSeconds(wait time.Duration) string {
+	return fmt.Sprintf("%d", int64(math.Max(1, math.Ceil(wait.Seconds()))))
+}
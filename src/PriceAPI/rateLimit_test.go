@@ -0,0 +1,178 @@
+package PriceAPI
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	bucket := newTokenBucket(&ProviderConfig.RateLimit{Rate: 2, Burst: 3}, now)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, time.Duration(0), bucket.wait())
+		bucket.tokens--
+	}
+	// Empty, the next token arrives in half a second
+	assert.Equal(t, 500*time.Millisecond, bucket.wait())
+	bucket.refill(now.Add(250 * time.Millisecond))
+	assert.Equal(t, 250*time.Millisecond, bucket.wait())
+	// Tokens never go past the burst
+	bucket.refill(now.Add(time.Minute))
+	assert.Equal(t, 3.0, bucket.tokens)
+}
+
+func TestRateLimitingAndBackpressure(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestRateLimitingAndBackpressure")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("NEAR", "ZAR"))
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("NEAR", "PLN"))
+	for _, providerName := range []string{"LimitProviderA", "LimitProviderB"} {
+		ProviderConfig.SetPairEnabled(providerName, "NEAR/ZAR", true)
+		ProviderConfig.SetPairEnabled(providerName, "NEAR/PLN", true)
+	}
+
+	// A slow refill so no tokens come back during the test, A is limited across its
+	// pairs and B only on NEAR/PLN
+	ProviderConfig.SetRateLimit(&ProviderConfig.RateLimit{Provider: "LimitProviderA", Rate: 0.01, Burst: 2})
+	ProviderConfig.SetRateLimit(&ProviderConfig.RateLimit{Provider: "LimitProviderB", Pair: "NEAR/PLN", Rate: 0.01, Burst: 1})
+	defer func() {
+		ProviderConfig.DeleteRateLimit("LimitProviderA", "")
+		ProviderConfig.DeleteRateLimit("LimitProviderB", "NEAR/PLN")
+	}()
+
+	router := gin.Default()
+	router.POST("/prices", engine.ProcessPriceUpdateRequest)
+	router.POST("/prices/batch", engine.ProcessPriceUpdateBatchRequest)
+	router.GET("/ingest/stats/:providerName", engine.GetProviderIngestStats)
+
+	timestamp := time.Now().UnixMilli()
+	newUpdate := func(provider string, quote string) string {
+		timestamp++
+		return `{"provider":"` + provider + `","base":"NEAR","quote":"` + quote + `","bid":"10","bid_amount":"1","timestamp":` + strconv.FormatInt(timestamp, 10) + `}`
+	}
+	send := func(path string, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Provider limits cover every pair
+	assert.Equal(t, http.StatusOK, send("/prices", newUpdate("LimitProviderA", "ZAR")).Code)
+	assert.Equal(t, http.StatusOK, send("/prices", newUpdate("LimitProviderA", "PLN")).Code)
+	rr := send("/prices", newUpdate("LimitProviderA", "ZAR"))
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	retryAfter, _ := strconv.Atoi(rr.Header().Get("Retry-After"))
+	assert.Greater(t, retryAfter, 1)
+
+	// Pair limits only cover their pair
+	rr = send("/prices/batch", "["+newUpdate("LimitProviderB", "PLN")+","+newUpdate("LimitProviderB", "PLN")+","+newUpdate("LimitProviderB", "ZAR")+"]")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+	var response PriceBatchResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, response.Accepted)
+	if assert.Equal(t, 3, len(response.Results)) {
+		assert.False(t, response.Results[1].Accepted)
+		assert.Contains(t, response.Results[1].Error, "rate limit")
+	}
+
+	// A full queue turns updates away before they are validated
+	engine.SetIngestQueueLimit(0)
+	rr = send("/prices", newUpdate("LimitProviderB", "ZAR"))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+	rr = send("/prices/batch", "["+newUpdate("LimitProviderB", "ZAR")+"]")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	engine.SetIngestQueueLimit(ingestQueueCapacity)
+	// The rejected update's timestamp wasn't recorded so it can be sent again
+	timestamp--
+	assert.Equal(t, http.StatusOK, send("/prices", newUpdate("LimitProviderB", "ZAR")).Code)
+
+	getStats := func(providerName string) IngestStats {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/ingest/stats/"+providerName, nil)
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var stats IngestStats
+		if err := json.Unmarshal(rr.Body.Bytes(), &stats); err != nil {
+			t.Fatal(err)
+		}
+		return stats
+	}
+	assert.Equal(t, IngestStats{RateLimited: 1}, getStats("LimitProviderA"))
+	assert.Equal(t, IngestStats{RateLimited: 1, QueueFull: 2}, getStats("LimitProviderB"))
+
+	// Accepted updates are applied from the queue
+	assert.Eventually(t, func() bool {
+		bestBid := engine.GetBestBidPrice("NEAR/ZAR")
+		return bestBid != nil && engine.queuedUpdates.Load() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRateLimitChangesKeepTokens(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestRateLimitChangesKeepTokens")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	now := time.Now()
+	engine.Clock = func() time.Time { return now }
+	update := &PriceUpdateRequest{Provider: "LimitProviderC", Base: "NEAR", Quote: "TRY"}
+	ProviderConfig.SetRateLimit(&ProviderConfig.RateLimit{Provider: "LimitProviderC", Rate: 0.01, Burst: 2})
+	_, err := engine.checkRateLimit(update)
+	assert.NoError(t, err)
+
+	// Adding another provider's limit swaps the buckets but keeps the used token
+	ProviderConfig.SetRateLimit(&ProviderConfig.RateLimit{Provider: "LimitProviderD", Rate: 0.01, Burst: 2})
+	_, err = engine.checkRateLimit(update)
+	assert.NoError(t, err)
+	_, err = engine.checkRateLimit(update)
+	assert.Error(t, err)
+
+	// Changing the limit starts a fresh bucket
+	ProviderConfig.SetRateLimit(&ProviderConfig.RateLimit{Provider: "LimitProviderC", Rate: 0.01, Burst: 1})
+	_, err = engine.checkRateLimit(update)
+	assert.NoError(t, err)
+
+	// Closed engines stop following changes
+	engine.Close()
+	ProviderConfig.DeleteRateLimit("LimitProviderC", "")
+	_, err = engine.checkRateLimit(update)
+	assert.Error(t, err)
+}
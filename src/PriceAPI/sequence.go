@@ -0,0 +1,142 @@
+package PriceAPI
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SequenceStats counts how a provider's updates have arrived.
+type SequenceStats struct {
+	Accepted   uint64 `json:"accepted"`
+	Duplicates uint64 `json:"duplicates"`
+	OutOfOrder uint64 `json:"out_of_order"`
+	Gaps       uint64 `json:"gaps"`
+	// Number of sequence numbers skipped over by gaps
+	Missed uint64 `json:"missed"`
+	Resets uint64 `json:"resets"`
+}
+
+// SequenceGap is a run of sequence numbers that never arrived from a provider.
+type SequenceGap struct {
+	Provider string `json:"provider"`
+	Pair     string `json:"pair"`
+	Expected uint64 `json:"expected"`
+	Received uint64 `json:"received"`
+	Missed   uint64 `json:"missed"`
+}
+
+// pairSequence is the newest update accepted from a provider for a pair
+type pairSequence struct {
+	sequence  uint64
+	timestamp int64
+}
+
+// SetRejectOutOfOrderUpdates sets whether out of order and duplicate updates are
+// rejected or accepted and only counted.
+func (engine *PriceEngine) SetRejectOutOfOrderUpdates(reject bool) {
+	engine.sequenceMu.Lock()
+	defer engine.sequenceMu.Unlock()
+	engine.rejectOutOfOrderUpdates = reject
+}
+
+// checkUpdateSequence records an update's sequence number and timestamp, rejecting it
+// if it is older than or the same as the last one accepted for its provider and pair.
+// Updates with a sequence number are ordered by it, otherwise by timestamp. A sequence
+// number of 1 with a newer timestamp is treated as the provider restarting.
+func (engine *PriceEngine) checkUpdateSequence(update *PriceUpdateRequest) (int, error) {
+	pairName := update.GetPairName()
+	var gap *SequenceGap
+
+	engine.sequenceMu.Lock()
+	stats := engine.sequenceStats[update.Provider]
+	if stats == nil {
+		stats = &SequenceStats{}
+		engine.sequenceStats[update.Provider] = stats
+	}
+	if engine.pairSequences[update.Provider] == nil {
+		engine.pairSequences[update.Provider] = make(map[string]*pairSequence)
+	}
+	last := engine.pairSequences[update.Provider][pairName]
+
+	var err error
+	switch {
+	case last == nil:
+	case update.Sequence > 0 && last.sequence > 0:
+		if update.Sequence == 1 && last.sequence > 1 && update.Timestamp > last.timestamp {
+			stats.Resets++
+		} else if update.Sequence == last.sequence {
+			stats.Duplicates++
+			err = fmt.Errorf("duplicate sequence %d for %s on %s", update.Sequence, pairName, update.Provider)
+		} else if update.Sequence < last.sequence {
+			stats.OutOfOrder++
+			err = fmt.Errorf("sequence %d for %s on %s is older than %d", update.Sequence, pairName, update.Provider, last.sequence)
+		} else if update.Sequence > last.sequence+1 {
+			stats.Gaps++
+			stats.Missed += update.Sequence - last.sequence - 1
+			gap = &SequenceGap{
+				Provider: update.Provider,
+				Pair:     pairName,
+				Expected: last.sequence + 1,
+				Received: update.Sequence,
+				Missed:   update.Sequence - last.sequence - 1,
+			}
+		}
+	case update.Timestamp > 0 && update.Timestamp < last.timestamp:
+		stats.OutOfOrder++
+		err = fmt.Errorf("timestamp %d for %s on %s is older than %d", update.Timestamp, pairName, update.Provider, last.timestamp)
+	}
+
+	if err != nil && engine.rejectOutOfOrderUpdates {
+		engine.sequenceMu.Unlock()
+		return http.StatusConflict, err
+	}
+	// Flagged updates are still accepted but never move the sequence backwards, and are
+	// never saved over the provider's newer quote
+	if err == nil {
+		engine.pairSequences[update.Provider][pairName] = &pairSequence{sequence: update.Sequence, timestamp: update.Timestamp}
+		engine.ingestSequence++
+		update.ingestSequence = engine.ingestSequence
+	}
+	stats.Accepted++
+	update.outOfOrder = err != nil
+	update.sequenceGap = gap != nil
+	engine.sequenceMu.Unlock()
+
+	if err != nil {
+		fmt.Printf("Accepting out of order update: %v\n", err)
+	}
+	if gap != nil {
+		fmt.Printf("Sequence gap for %s on %s, expected %d but received %d\n", pairName, update.Provider, gap.Expected, gap.Received)
+		engine.emitPriceEvent(update.traceContext(), NewSequenceGapEvent(gap, engine.now()))
+	}
+	return http.StatusOK, nil
+}
+
+// getSequenceStats returns a copy of the sequence counters for every provider.
+func (engine *PriceEngine) getSequenceStats() map[string]SequenceStats {
+	engine.sequenceMu.Lock()
+	defer engine.sequenceMu.Unlock()
+	stats := make(map[string]SequenceStats, len(engine.sequenceStats))
+	for providerName, providerStats := range engine.sequenceStats {
+		stats[providerName] = *providerStats
+	}
+	return stats
+}
+
+// GetSequenceStats returns the sequence counters for every provider.
+func (engine *PriceEngine) GetSequenceStats(c *gin.Context) {
+	c.JSON(http.StatusOK, engine.getSequenceStats())
+}
+
+// GetProviderSequenceStats returns the sequence counters for a single provider.
+func (engine *PriceEngine) GetProviderSequenceStats(c *gin.Context) {
+	providerName := c.Param("providerName")
+	stats, ok := engine.getSequenceStats()[providerName]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no updates received from %s", providerName)})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
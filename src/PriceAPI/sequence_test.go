@@ -0,0 +1,181 @@
+package PriceAPI
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceUpdateSequence(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestPriceUpdateSequence")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("LINK", "AUD"))
+	ProviderConfig.SetPairEnabled("SeqProviderA", "LINK/AUD", true)
+	disableOutlierFilter(t, engine)
+
+	events := NewChannelSink("sequence", 100)
+	engine.Sinks.Register(events)
+
+	router := gin.Default()
+	router.POST("/prices", engine.ProcessPriceUpdateRequest)
+	router.GET("/sequence/stats/:providerName", engine.GetProviderSequenceStats)
+
+	// Timestamps are sent as milliseconds after now
+	now := time.Now().UnixMilli()
+	send := func(sequence uint64, timestamp int64, bid string) int {
+		update := &PriceUpdateRequest{
+			Provider: "SeqProviderA", Base: "LINK", Quote: "AUD",
+			Bid: decimal.RequireFromString(bid), BidAmount: decimal.NewFromInt(10),
+			Ask: decimal.RequireFromString("20"), AskAmount: decimal.NewFromInt(10),
+			Timestamp: now + timestamp, Sequence: sequence,
+		}
+		body, _ := json.Marshal(update)
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/prices", bytes.NewBuffer(body))
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send(1, 1000, "15"))
+	assert.Equal(t, http.StatusOK, send(2, 1001, "15.1"))
+	// Duplicate and older sequence numbers are dropped
+	assert.Equal(t, http.StatusConflict, send(2, 1002, "15.2"))
+	assert.Equal(t, http.StatusConflict, send(1, 1000, "15.3"))
+
+	// Skipping sequence numbers is accepted but reported
+	assert.Equal(t, http.StatusOK, send(5, 1004, "15.4"))
+	assert.Eventually(t, func() bool {
+		bestBid := engine.GetBestBidPrice("LINK/AUD")
+		return bestBid != nil && bestBid.Price.String() == "15.4"
+	}, time.Second, 10*time.Millisecond)
+	gapEvent := waitForEvent(t, events, SequenceGapEventType)
+	if assert.NotNil(t, gapEvent) && assert.NotNil(t, gapEvent.Gap) {
+		assert.Equal(t, SequenceGap{Provider: "SeqProviderA", Pair: "LINK/AUD", Expected: 3, Received: 5, Missed: 2}, *gapEvent.Gap)
+	}
+
+	// Restarting at 1 with a newer timestamp is allowed
+	assert.Equal(t, http.StatusOK, send(1, 1005, "15.5"))
+
+	// Without sequence numbers updates are ordered by timestamp
+	assert.Equal(t, http.StatusOK, send(0, 1006, "15.6"))
+	assert.Equal(t, http.StatusConflict, send(0, 1000, "15.7"))
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/sequence/stats/SeqProviderA", nil)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var stats SequenceStats
+	if err := json.Unmarshal(rr.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, SequenceStats{Accepted: 5, Duplicates: 1, OutOfOrder: 2, Gaps: 1, Missed: 2, Resets: 1}, stats)
+
+	rr = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/sequence/stats/SeqProviderZ", nil)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Flagging accepts out of order updates but still counts them
+	engine.SetRejectOutOfOrderUpdates(false)
+	assert.Equal(t, http.StatusOK, send(0, 999, "15.8"))
+	assert.Equal(t, uint64(3), engine.getSequenceStats()["SeqProviderA"].OutOfOrder)
+}
+
+func TestFlaggedOutOfOrderUpdateKeepsNewerQuote(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestFlaggedOutOfOrderUpdateKeepsNewerQuote")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("LINK", "SGD"))
+	ProviderConfig.SetPairEnabled("SeqProviderC", "LINK/SGD", true)
+	engine.SetRejectOutOfOrderUpdates(false)
+
+	now := time.Now().UnixMilli()
+	newer := &PriceUpdateRequest{
+		Provider: "SeqProviderC", Base: "LINK", Quote: "SGD",
+		Bid: decimal.RequireFromString("15"), BidAmount: decimal.NewFromInt(10),
+		Ask: decimal.RequireFromString("15.1"), AskAmount: decimal.NewFromInt(10),
+		Timestamp: now, Sequence: 5,
+	}
+	older := &PriceUpdateRequest{
+		Provider: "SeqProviderC", Base: "LINK", Quote: "SGD",
+		Bid: decimal.RequireFromString("14"), BidAmount: decimal.NewFromInt(10),
+		Ask: decimal.RequireFromString("14.1"), AskAmount: decimal.NewFromInt(10),
+		Timestamp: now + 1, Sequence: 3,
+	}
+	for _, update := range []*PriceUpdateRequest{newer, older} {
+		status, err := engine.validatePriceUpdateRequest(update)
+		assert.Equal(t, http.StatusOK, status, "%v", err)
+		engine.applyPriceUpdates([]*PriceUpdateRequest{update})
+	}
+
+	// Sequence 3 was flagged and counted but sequence 5's prices are still the best
+	assert.Equal(t, uint64(1), engine.getSequenceStats()["SeqProviderC"].OutOfOrder)
+	assert.Equal(t, newer, engine.getProviderUpdateRequest("SeqProviderC", "LINK/SGD"))
+	if bestBid := engine.GetBestBidPrice("LINK/SGD"); assert.NotNil(t, bestBid) {
+		assert.Equal(t, "15", bestBid.Price.String())
+	}
+	if bestAsk := engine.GetBestAskPrice("LINK/SGD"); assert.NotNil(t, bestAsk) {
+		assert.Equal(t, "15.1", bestAsk.Price.String())
+	}
+}
+
+func TestSaveProviderUpdateRequestKeepsNewest(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	newer := &PriceUpdateRequest{Provider: "SeqProviderB", Base: "LINK", Quote: "EUR", ingestSequence: 2}
+	older := &PriceUpdateRequest{Provider: "SeqProviderB", Base: "LINK", Quote: "EUR", ingestSequence: 1}
+	engine.saveProviderUpdateRequest(newer)
+	engine.saveProviderUpdateRequest(older)
+
+	assert.Equal(t, newer, engine.getProviderUpdateRequest("SeqProviderB", "LINK/EUR"))
+}
+
+// waitForEvent returns the next event of the given type from a channel sink
+func waitForEvent(t *testing.T, sink *ChannelSink, eventType PriceEventType) *PriceEvent {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case event := <-sink.Events():
+			if event.Type == eventType {
+				return event
+			}
+		case <-timeout:
+			t.Errorf("timed out waiting for %s event", eventType)
+			return nil
+		}
+	}
+}
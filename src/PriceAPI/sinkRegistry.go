@@ -0,0 +1,172 @@
+package PriceAPI
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Number of events each sink can have waiting before new events are dropped
+var SinkQueueSize = 1024
+
+// PriceSink receives every event published by a PriceEngine.
+// Write is called from the sink's own goroutine so a slow sink only delays itself.
+type PriceSink interface {
+	Name() string
+	Write(event *PriceEvent) error
+	Close() error
+}
+
+// SinkStats are the delivery counters for a single sink.
+type SinkStats struct {
+	Name          string    `json:"name"`
+	QueueLength   int       `json:"queue_length"`
+	QueueSize     int       `json:"queue_size"`
+	Delivered     uint64    `json:"delivered"`
+	Errors        uint64    `json:"errors"`
+	Dropped       uint64    `json:"dropped"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorTime time.Time `json:"last_error_time,omitempty"`
+}
+
+type sinkWorker struct {
+	sink      PriceSink
+	queue     chan *PriceEvent
+	done      chan struct{}
+	delivered atomic.Uint64
+	errors    atomic.Uint64
+	dropped   atomic.Uint64
+	// Guards the last error fields
+	mu            sync.Mutex
+	lastError     string
+	lastErrorTime time.Time
+}
+
+// SinkRegistry fans each published event out to every registered sink.
+type SinkRegistry struct {
+	workers map[string]*sinkWorker
+	mu      sync.RWMutex
+}
+
+func NewSinkRegistry() *SinkRegistry {
+	return &SinkRegistry{
+		workers: make(map[string]*sinkWorker),
+	}
+}
+
+// Register adds a sink and starts its delivery goroutine. Sink names must be unique.
+func (r *SinkRegistry) Register(sink PriceSink) error {
+	if sink == nil {
+		return fmt.Errorf("sink is nil")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.workers[sink.Name()] != nil {
+		return fmt.Errorf("sink %s is already registered", sink.Name())
+	}
+	worker := &sinkWorker{
+		sink:  sink,
+		queue: make(chan *PriceEvent, SinkQueueSize),
+		done:  make(chan struct{}),
+	}
+	r.workers[sink.Name()] = worker
+	go worker.run()
+	return nil
+}
+
+// Unregister removes a sink, waits for its queued events to be delivered and closes it.
+func (r *SinkRegistry) Unregister(name string) error {
+	r.mu.Lock()
+	worker := r.workers[name]
+	delete(r.workers, name)
+	r.mu.Unlock()
+	if worker == nil {
+		return fmt.Errorf("sink %s is not registered", name)
+	}
+	return worker.stop()
+}
+
+// Publish queues an event on every sink without blocking. If a sink's queue
+// is full the event is dropped for that sink and counted.
+func (r *SinkRegistry) Publish(event *PriceEvent) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, worker := range r.workers {
+		select {
+		case worker.queue <- event:
+		default:
+			worker.dropped.Add(1)
+		}
+	}
+}
+
+// Stats returns the counters for every registered sink sorted by name.
+func (r *SinkRegistry) Stats() []*SinkStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	stats := make([]*SinkStats, 0, len(r.workers))
+	for _, worker := range r.workers {
+		stats = append(stats, worker.stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// Close unregisters every sink, delivering anything still queued.
+func (r *SinkRegistry) Close() {
+	r.mu.Lock()
+	workers := r.workers
+	r.workers = make(map[string]*sinkWorker)
+	r.mu.Unlock()
+	for _, worker := range workers {
+		if err := worker.stop(); err != nil {
+			fmt.Printf("Error closing sink %s: %v\n", worker.sink.Name(), err)
+		}
+	}
+}
+
+func (w *sinkWorker) run() {
+	defer close(w.done)
+	for event := range w.queue {
+		if err := w.sink.Write(event); err != nil {
+			w.errors.Add(1)
+			w.mu.Lock()
+			w.lastError = err.Error()
+			w.lastErrorTime = time.Now()
+			w.mu.Unlock()
+			continue
+		}
+		w.delivered.Add(1)
+	}
+}
+
+func (w *sinkWorker) stop() error {
+	close(w.queue)
+	<-w.done
+	return w.sink.Close()
+}
+
+func (w *sinkWorker) stats() *SinkStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return &SinkStats{
+		Name:          w.sink.Name(),
+		QueueLength:   len(w.queue),
+		QueueSize:     cap(w.queue),
+		Delivered:     w.delivered.Load(),
+		Errors:        w.errors.Load(),
+		Dropped:       w.dropped.Load(),
+		LastError:     w.lastError,
+		LastErrorTime: w.lastErrorTime,
+	}
+}
+
+// GetSinkStats returns the delivery counters for every registered sink.
+func (engine *PriceEngine) GetSinkStats(c *gin.Context) {
+	c.JSON(http.StatusOK, engine.Sinks.Stats())
+}
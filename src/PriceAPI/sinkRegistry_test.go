@@ -0,0 +1,87 @@
+package PriceAPI
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingSink struct{}
+
+func (s *failingSink) Name() string                  { return "failing" }
+func (s *failingSink) Write(event *PriceEvent) error { return fmt.Errorf("sink unavailable") }
+func (s *failingSink) Close() error                  { return nil }
+
+// blockingSink never finishes a write until released
+type blockingSink struct {
+	release chan struct{}
+}
+
+func (s *blockingSink) Name() string { return "blocking" }
+func (s *blockingSink) Write(event *PriceEvent) error {
+	<-s.release
+	return nil
+}
+func (s *blockingSink) Close() error { return nil }
+
+func TestSinkRegistryFanOut(t *testing.T) {
+	registry := NewSinkRegistry()
+	first := NewChannelSink("first", 10)
+	second := NewChannelSink("second", 10)
+	assert.Nil(t, registry.Register(first))
+	assert.Nil(t, registry.Register(second))
+	assert.NotNil(t, registry.Register(NewChannelSink("first", 10)), "Duplicate sink names should be rejected")
+
+	event := NewBestPriceEvent("BTC/USD", &PriceUpdate{Provider: "ProviderA", Base: "BTC", Quote: "USD", Price: decimal.NewFromInt(45000), Amount: decimal.NewFromInt(1)}, "Bid", time.Now())
+	registry.Publish(event)
+
+	assert.Equal(t, event, <-first.Events())
+	assert.Equal(t, event, <-second.Events())
+
+	registry.Close()
+	stats := registry.Stats()
+	assert.Equal(t, 0, len(stats))
+}
+
+func TestSinkRegistryIsolatesFailingSinks(t *testing.T) {
+	SinkQueueSize = 1
+	defer func() { SinkQueueSize = 1024 }()
+
+	registry := NewSinkRegistry()
+	blocking := &blockingSink{release: make(chan struct{})}
+	healthy := NewChannelSink("healthy", 10)
+	registry.Register(blocking)
+	registry.Register(&failingSink{})
+	registry.Register(healthy)
+
+	// Publishing never blocks even though one sink is stuck
+	for i := 0; i < 5; i++ {
+		registry.Publish(NewBestPriceEvent("BTC/USD", nil, "Bid", time.Now()))
+		<-healthy.Events()
+	}
+
+	getStats := func() map[string]*SinkStats {
+		stats := make(map[string]*SinkStats)
+		for _, stat := range registry.Stats() {
+			stats[stat.Name] = stat
+		}
+		return stats
+	}
+	// Counters are updated after each write returns so give the workers a moment
+	assert.Eventually(t, func() bool {
+		stats := getStats()
+		return stats["channel:healthy"].Delivered == 5 && stats["failing"].Errors+stats["failing"].Dropped == 5
+	}, time.Second, 10*time.Millisecond)
+
+	stats := getStats()
+	assert.True(t, stats["blocking"].Dropped > 0, "Stuck sink should drop events")
+	assert.Equal(t, uint64(0), stats["channel:healthy"].Dropped)
+	assert.Equal(t, "sink unavailable", stats["failing"].LastError)
+
+	close(blocking.release)
+	registry.Close()
+	assert.Equal(t, 0, len(registry.Stats()))
+}
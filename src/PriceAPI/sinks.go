@@ -0,0 +1,133 @@
+package PriceAPI
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/Tracing"
+	"github.com/parnurzeal/gorequest"
+)
+
+// TextLogSink appends the human readable event line to a log file.
+type TextLogSink struct {
+	FileName string
+}
+
+func NewTextLogSink(fileName string) *TextLogSink {
+	return &TextLogSink{FileName: fileName}
+}
+
+func (s *TextLogSink) Name() string { return "textlog:" + s.FileName }
+
+func (s *TextLogSink) Write(event *PriceEvent) error {
+	return Helpers.AppendToFile(s.FileName, event.String())
+}
+
+func (s *TextLogSink) Close() error { return nil }
+
+// JSONLinesSink appends each event as a single line of JSON to a file.
+type JSONLinesSink struct {
+	FileName string
+}
+
+func NewJSONLinesSink(fileName string) *JSONLinesSink {
+	return &JSONLinesSink{FileName: fileName}
+}
+
+func (s *JSONLinesSink) Name() string { return "jsonl:" + s.FileName }
+
+func (s *JSONLinesSink) Write(event *PriceEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return Helpers.AppendToFile(s.FileName, string(line)+"\n")
+}
+
+func (s *JSONLinesSink) Close() error { return nil }
+
+// StdoutSink prints the human readable event line.
+type StdoutSink struct{}
+
+func NewStdoutSink() *StdoutSink {
+	return &StdoutSink{}
+}
+
+func (s *StdoutSink) Name() string { return "stdout" }
+
+func (s *StdoutSink) Write(event *PriceEvent) error {
+	_, err := fmt.Println(event.String())
+	return err
+}
+
+func (s *StdoutSink) Close() error { return nil }
+
+// WebhookSink POSTs each event as JSON to a URL, any non 2xx response is an error.
+type WebhookSink struct {
+	URL     string
+	Timeout time.Duration
+}
+
+func NewWebhookSink(url string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{URL: url, Timeout: timeout}
+}
+
+func (s *WebhookSink) Name() string { return "webhook:" + s.URL }
+
+func (s *WebhookSink) Write(event *PriceEvent) error {
+	request := gorequest.New().
+		Timeout(s.Timeout).
+		Post(s.URL).
+		Type("json").
+		Send(event)
+	// Continue the trace the event was emitted in
+	_, span := Tracing.StartClient(Tracing.ContextWithSpanContext(event.spanContext), request)
+	resp, _, errs := request.End()
+	Tracing.EndClient(span, resp, errs)
+	if len(errs) > 0 {
+		return fmt.Errorf("request error: %v", errs[0])
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *WebhookSink) Close() error { return nil }
+
+// ChannelSink delivers events to an in-process channel. Events are dropped
+// with an error if the consumer isn't keeping up.
+type ChannelSink struct {
+	name   string
+	events chan *PriceEvent
+}
+
+func NewChannelSink(name string, bufferSize int) *ChannelSink {
+	return &ChannelSink{
+		name:   name,
+		events: make(chan *PriceEvent, bufferSize),
+	}
+}
+
+// Events returns the channel to consume from, it is closed when the sink is unregistered.
+func (s *ChannelSink) Events() <-chan *PriceEvent {
+	return s.events
+}
+
+func (s *ChannelSink) Name() string { return "channel:" + s.name }
+
+func (s *ChannelSink) Write(event *PriceEvent) error {
+	select {
+	case s.events <- event:
+		return nil
+	default:
+		return fmt.Errorf("channel %s is full", s.name)
+	}
+}
+
+func (s *ChannelSink) Close() error {
+	close(s.events)
+	return nil
+}
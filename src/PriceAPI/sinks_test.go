@@ -0,0 +1,78 @@
+package PriceAPI
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextLogAndJSONLinesSinks(t *testing.T) {
+	textFile, err := os.CreateTemp("", "test_sink_*.log")
+	if err != nil {
+		t.Fatalf("Error creating temporary file: %v", err)
+	}
+	defer os.Remove(textFile.Name())
+	jsonFile, err := os.CreateTemp("", "test_sink_*.jsonl")
+	if err != nil {
+		t.Fatalf("Error creating temporary file: %v", err)
+	}
+	defer os.Remove(jsonFile.Name())
+
+	event := NewBestPriceEvent("BTC/USD", &PriceUpdate{Provider: "ProviderA", Base: "BTC", Quote: "USD", Price: decimal.NewFromInt(45000), Amount: decimal.NewFromInt(1)}, "Bid", time.Now())
+	assert.Nil(t, NewTextLogSink(textFile.Name()).Write(event))
+	assert.Nil(t, NewJSONLinesSink(jsonFile.Name()).Write(event))
+
+	content, _ := os.ReadFile(textFile.Name())
+	assert.True(t, strings.HasPrefix(string(content), "Bid - ProviderA - 45000.00000000 - 1.00000000"))
+
+	// Prices are logged to the pair's precision rather than truncated
+	engine := NewPriceEngine()
+	defer engine.Close()
+	engine.SetInstrumentPrecision("XLM/USD", 5, 0)
+	xlmEvent := engine.newBestPriceEvent("XLM/USD", &PriceUpdate{Provider: "ProviderA", Base: "XLM", Quote: "USD", Price: decimal.RequireFromString("0.12345"), Amount: decimal.NewFromInt(2500)}, "Ask")
+	assert.Nil(t, NewTextLogSink(textFile.Name()).Write(xlmEvent))
+	content, _ = os.ReadFile(textFile.Name())
+	assert.Contains(t, string(content), "Ask - ProviderA - 0.12345 - 2500 - ")
+
+	content, _ = os.ReadFile(jsonFile.Name())
+	var decoded PriceEvent
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, BestPriceEventType, decoded.Type)
+	assert.Equal(t, "BTC/USD", decoded.Pair)
+	assert.Equal(t, "45000", decoded.Price.Price.String())
+}
+
+func TestWebhookSink(t *testing.T) {
+	received := make(chan *PriceEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event PriceEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received <- &event
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, time.Second)
+	assert.Nil(t, sink.Write(NewBestPriceEvent("BTC/USD", nil, "Ask", time.Now())))
+	event := <-received
+	assert.Equal(t, "BTC/USD", event.Pair)
+	assert.Nil(t, event.Price)
+
+	// Non 2xx responses are errors
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failingServer.Close()
+	assert.NotNil(t, NewWebhookSink(failingServer.URL, time.Second).Write(NewBestPriceEvent("BTC/USD", nil, "Ask", time.Now())))
+}
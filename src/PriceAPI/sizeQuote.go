@@ -0,0 +1,122 @@
+package PriceAPI
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// Customer buys the base currency, filled from provider asks
+	SideBuy = "buy"
+	// Customer sells the base currency, filled from provider bids
+	SideSell = "sell"
+)
+
+// SizeAllocation is the part of a size quote filled by a single provider.
+type SizeAllocation struct {
+	Provider  string          `json:"provider"`
+	Price     decimal.Decimal `json:"price"`
+	Amount    decimal.Decimal `json:"amount"`
+	Timestamp int64           `json:"timestamp"`
+}
+
+// SizeQuote is the price for a given amount, filled across providers best price first.
+type SizeQuote struct {
+	Base            string            `json:"base"`
+	Quote           string            `json:"quote"`
+	Side            string            `json:"side"`
+	RequestedAmount decimal.Decimal   `json:"requested_amount"`
+	FilledAmount    decimal.Decimal   `json:"filled_amount"`
+	TotalCost       decimal.Decimal   `json:"total_cost"`
+	VWAP            *decimal.Decimal  `json:"vwap"`
+	FullyFilled     bool              `json:"fully_filled"`
+	Allocations     []*SizeAllocation `json:"allocations"`
+}
+
+// calculateSizeQuote walks the book on the given side filling the requested amount
+// from each provider in price order until it is filled or the book runs out. The VWAP is
+// rounded to the pair's price precision.
+func (engine *PriceEngine) calculateSizeQuote(book *ConsolidatedBook, side string, amount decimal.Decimal) (*SizeQuote, error) {
+	var levels []*PriceUpdate
+	switch side {
+	case SideBuy:
+		levels = book.Asks
+	case SideSell:
+		levels = book.Bids
+	default:
+		return nil, fmt.Errorf("side must be %s or %s", SideBuy, SideSell)
+	}
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("amount must be greater than 0")
+	}
+
+	sizeQuote := &SizeQuote{
+		Base:            book.Base,
+		Quote:           book.Quote,
+		Side:            side,
+		RequestedAmount: amount,
+		Allocations:     make([]*SizeAllocation, 0),
+	}
+
+	remaining := amount
+	for _, level := range levels {
+		if !remaining.IsPositive() {
+			break
+		}
+		if !level.Amount.IsPositive() {
+			continue
+		}
+		fillAmount := decimal.Min(level.Amount, remaining)
+		sizeQuote.Allocations = append(sizeQuote.Allocations, &SizeAllocation{
+			Provider:  level.Provider,
+			Price:     level.Price,
+			Amount:    fillAmount,
+			Timestamp: level.Timestamp,
+		})
+		sizeQuote.FilledAmount = sizeQuote.FilledAmount.Add(fillAmount)
+		sizeQuote.TotalCost = sizeQuote.TotalCost.Add(fillAmount.Mul(level.Price))
+		remaining = remaining.Sub(fillAmount)
+	}
+
+	if sizeQuote.FilledAmount.IsPositive() {
+		pairName := fmt.Sprintf("%s/%s", book.Base, book.Quote)
+		vwap := sizeQuote.TotalCost.DivRound(sizeQuote.FilledAmount, engine.GetPricePrecision(pairName))
+		sizeQuote.VWAP = &vwap
+	}
+	sizeQuote.FullyFilled = !remaining.IsPositive()
+	return sizeQuote, nil
+}
+
+// GetPriceForSize returns the VWAP and per provider allocation to buy or sell an amount of a pair.
+func (engine *PriceEngine) GetPriceForSize(c *gin.Context) {
+	base := c.Param("base")
+	quote := c.Param("quote")
+	if base == "" || quote == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty base or quote param"})
+		return
+	}
+
+	side := strings.ToLower(c.Query("side"))
+	amount, err := decimal.NewFromString(c.Query("amount"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be a number"})
+		return
+	}
+
+	pairName := fmt.Sprintf("%s/%s", base, quote)
+	if len(engine.getPairUpdateRequests(pairName)) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no prices received for %s", pairName)})
+		return
+	}
+
+	sizeQuote, err := engine.calculateSizeQuote(engine.buildConsolidatedBook(pairName, engine.now()), side, amount)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sizeQuote)
+}
@@ -0,0 +1,88 @@
+package PriceAPI
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateSizeQuote(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	book := &ConsolidatedBook{
+		Base:  "BTC",
+		Quote: "USD",
+		Bids: []*PriceUpdate{
+			{Provider: "ProviderA", Price: decimal.NewFromInt(100), Amount: decimal.NewFromInt(10)},
+			{Provider: "ProviderB", Price: decimal.NewFromInt(99), Amount: decimal.NewFromInt(30)},
+		},
+		Asks: []*PriceUpdate{
+			{Provider: "ProviderB", Price: decimal.NewFromInt(101), Amount: decimal.NewFromInt(10)},
+			{Provider: "ProviderA", Price: decimal.NewFromInt(102), Amount: decimal.NewFromInt(0)},
+			{Provider: "ProviderC", Price: decimal.NewFromInt(103), Amount: decimal.NewFromInt(30)},
+		},
+	}
+
+	// Buying walks up the asks, skipping providers with nothing available
+	sizeQuote, err := engine.calculateSizeQuote(book, SideBuy, decimal.NewFromInt(20))
+	assert.Nil(t, err)
+	assert.True(t, sizeQuote.FullyFilled)
+	assert.True(t, decimal.NewFromInt(20).Equal(sizeQuote.FilledAmount))
+	assert.Equal(t, 2, len(sizeQuote.Allocations))
+	assert.Equal(t, "ProviderB", sizeQuote.Allocations[0].Provider)
+	assert.True(t, decimal.NewFromInt(10).Equal(sizeQuote.Allocations[0].Amount))
+	assert.Equal(t, "ProviderC", sizeQuote.Allocations[1].Provider)
+	assert.True(t, decimal.NewFromInt(10).Equal(sizeQuote.Allocations[1].Amount))
+	assert.Equal(t, "102", sizeQuote.VWAP.String())
+
+	// Selling more than the book holds is only partially filled
+	sizeQuote, err = engine.calculateSizeQuote(book, SideSell, decimal.NewFromInt(50))
+	assert.Nil(t, err)
+	assert.False(t, sizeQuote.FullyFilled)
+	assert.True(t, decimal.NewFromInt(40).Equal(sizeQuote.FilledAmount))
+	// (100 * 10 + 99 * 30) / 40
+	assert.Equal(t, "99.25", sizeQuote.VWAP.String())
+
+	// The VWAP is rounded to the pair's price precision
+	engine.SetInstrumentPrecision("BTC/USD", 1, 8)
+	sizeQuote, err = engine.calculateSizeQuote(book, SideSell, decimal.NewFromInt(35))
+	assert.Nil(t, err)
+	// (100 * 10 + 99 * 25) / 35 = 99.2857...
+	assert.Equal(t, "99.3", sizeQuote.VWAP.String())
+
+	// Nothing to fill against means no VWAP
+	sizeQuote, err = engine.calculateSizeQuote(&ConsolidatedBook{Base: "BTC", Quote: "USD"}, SideBuy, decimal.NewFromInt(1))
+	assert.Nil(t, err)
+	assert.False(t, sizeQuote.FullyFilled)
+	assert.Nil(t, sizeQuote.VWAP)
+
+	_, err = engine.calculateSizeQuote(book, "hold", decimal.NewFromInt(1))
+	assert.NotNil(t, err)
+	_, err = engine.calculateSizeQuote(book, SideBuy, decimal.Zero)
+	assert.NotNil(t, err)
+}
+
+func TestGetPriceForSize(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	router := gin.Default()
+	router.GET("/prices/:base/:quote/quote", engine.GetPriceForSize)
+
+	// Invalid amounts are rejected
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/prices/DOGE/CHF/quote?side=buy&amount=abc", nil)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Pairs that have never been quoted are not found
+	rr = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/prices/DOGE/CHF/quote?side=buy&amount=50", nil)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
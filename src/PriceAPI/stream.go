@@ -0,0 +1,325 @@
+package PriceAPI
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/hongkongkiwi/chaostheory/src/PriceProto"
+)
+
+// Size of each connection's outgoing message buffer, a client that falls this
+// far behind is disconnected so it can never block price ingest
+var StreamSendBufferSize = 256
+
+const (
+	streamWriteTimeout = 10 * time.Second
+	streamPongTimeout  = 60 * time.Second
+	streamPingInterval = 50 * time.Second
+)
+
+// StreamSubscribeRequest is sent by a client to change the pairs it receives.
+// An empty list of pairs subscribes to every pair.
+type StreamSubscribeRequest struct {
+	Action string   `json:"action"` // subscribe or unsubscribe
+	Pairs  []string `json:"pairs"`
+}
+
+// StreamMessage is sent to clients, either a snapshot of the current best prices
+// or a single best bid/ask change.
+type StreamMessage struct {
+	Type   string                `json:"type"` // snapshot, update or error
+	Pair   string                `json:"pair,omitempty"`
+	Side   string                `json:"side,omitempty"`
+	Price  *PriceUpdate          `json:"price,omitempty"`
+	Prices map[string]*BestPrice `json:"prices,omitempty"`
+	Error  string                `json:"error,omitempty"`
+}
+
+// streamPayload is a message queued for stream clients. It is encoded at most once for
+// each transport however many clients it is sent to.
+type streamPayload struct {
+	message   *StreamMessage
+	jsonOnce  sync.Once
+	json      []byte
+	jsonErr   error
+	protoOnce sync.Once
+	proto     *PriceProto.BestPriceMessage
+}
+
+func newStreamPayload(message *StreamMessage) *streamPayload {
+	return &streamPayload{message: message}
+}
+
+// encodeJSON returns the message as it is sent to WebSocket clients.
+func (payload *streamPayload) encodeJSON() ([]byte, error) {
+	payload.jsonOnce.Do(func() {
+		payload.json, payload.jsonErr = json.Marshal(payload.message)
+	})
+	return payload.json, payload.jsonErr
+}
+
+type streamClient struct {
+	conn *websocket.Conn
+	send chan *streamPayload
+	// Guards send so nothing is queued after the channel is closed
+	sendMu sync.Mutex
+	closed bool
+	// Subscribed pairs, nil means all pairs
+	pairs map[string]bool
+	mu    sync.RWMutex
+}
+
+type streamHubState struct {
+	clients map[*streamClient]bool
+	mu      sync.RWMutex
+}
+
+func newStreamHub() *streamHubState {
+	return &streamHubState{clients: make(map[*streamClient]bool)}
+}
+
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Consumers are internal pricing engines so we don't restrict origins
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+func newStreamClient(conn *websocket.Conn) *streamClient {
+	return &streamClient{
+		conn: conn,
+		send: make(chan *streamPayload, StreamSendBufferSize),
+	}
+}
+
+// close stops the client's write loop, it is safe to call more than once
+func (client *streamClient) close() {
+	client.sendMu.Lock()
+	defer client.sendMu.Unlock()
+	if !client.closed {
+		client.closed = true
+		close(client.send)
+	}
+}
+
+func (client *streamClient) isSubscribed(pairName string) bool {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	return client.pairs == nil || client.pairs[pairName]
+}
+
+// subscribe adds pairs to the subscription, no pairs means subscribe to everything
+func (client *streamClient) subscribe(pairNames []string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if len(pairNames) == 0 {
+		client.pairs = nil
+		return
+	}
+	if client.pairs == nil {
+		client.pairs = make(map[string]bool)
+	}
+	for _, pairName := range pairNames {
+		client.pairs[pairName] = true
+	}
+}
+
+// unsubscribe removes pairs from the subscription, no pairs means unsubscribe from everything
+func (client *streamClient) unsubscribe(pairNames []string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if len(pairNames) == 0 || client.pairs == nil {
+		client.pairs = make(map[string]bool)
+	}
+	for _, pairName := range pairNames {
+		delete(client.pairs, pairName)
+	}
+}
+
+// trySend queues a message without blocking, returning false if the buffer is full
+func (client *streamClient) trySend(payload *streamPayload) bool {
+	client.sendMu.Lock()
+	defer client.sendMu.Unlock()
+	if client.closed {
+		return false
+	}
+	select {
+	case client.send <- payload:
+		return true
+	default:
+		return false
+	}
+}
+
+// register adds a client subscribed to the given pairs, or all pairs if none are given,
+// and queues a snapshot of them. The hub stays locked until the snapshot is queued so no
+// change can be queued ahead of it.
+func (hub *streamHubState) register(client *streamClient, pairNames []string, getBestPrices func() map[string]*BestPrice) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	client.subscribe(pairNames)
+	hub.clients[client] = true
+	client.sendSnapshot(getBestPrices(), pairNames)
+}
+
+// subscribe adds pairs to a registered client's subscription and queues a snapshot of
+// them, locking the hub in the same way as register.
+func (hub *streamHubState) subscribe(client *streamClient, pairNames []string, getBestPrices func() map[string]*BestPrice) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	client.subscribe(pairNames)
+	client.sendSnapshot(getBestPrices(), pairNames)
+}
+
+func (hub *streamHubState) unregister(client *streamClient) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if hub.clients[client] {
+		delete(hub.clients, client)
+		client.close()
+	}
+}
+
+// broadcast sends a best price change to every client subscribed to the pair.
+// Clients whose buffers are full are disconnected rather than waited on.
+func (hub *streamHubState) broadcast(pairName string, update *PriceUpdate, updateType string) {
+	payload := newStreamPayload(&StreamMessage{
+		Type:  "update",
+		Pair:  pairName,
+		Side:  updateType,
+		Price: update,
+	})
+
+	slowClients := make([]*streamClient, 0)
+	hub.mu.RLock()
+	for client := range hub.clients {
+		if client.isSubscribed(pairName) && !client.trySend(payload) {
+			slowClients = append(slowClients, client)
+		}
+	}
+	hub.mu.RUnlock()
+
+	for _, client := range slowClients {
+		fmt.Println("Disconnecting slow stream consumer")
+		hub.unregister(client)
+	}
+}
+
+// sendSnapshot queues the given best prices for the given pairs, or all pairs if none are given
+func (client *streamClient) sendSnapshot(bestPrices map[string]*BestPrice, pairNames []string) bool {
+	if len(pairNames) > 0 {
+		snapshot := make(map[string]*BestPrice)
+		for _, pairName := range pairNames {
+			if bestPrice := bestPrices[pairName]; bestPrice != nil {
+				snapshot[pairName] = bestPrice
+			}
+		}
+		bestPrices = snapshot
+	}
+	return client.trySend(newStreamPayload(&StreamMessage{Type: "snapshot", Prices: bestPrices}))
+}
+
+// StreamBestPrices upgrades the request to a WebSocket and streams best price changes.
+// Pairs can be given as a comma separated pairs query param, or changed later by
+// sending a StreamSubscribeRequest.
+func (engine *PriceEngine) StreamBestPrices(c *gin.Context) {
+	conn, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already written an error response
+		fmt.Println("Error upgrading stream connection:", err)
+		return
+	}
+
+	client := newStreamClient(conn)
+	var pairNames []string
+	if pairsParam := c.Query("pairs"); pairsParam != "" {
+		for _, pairName := range strings.Split(pairsParam, ",") {
+			if pairName = strings.TrimSpace(pairName); pairName != "" {
+				pairNames = append(pairNames, pairName)
+			}
+		}
+	}
+	engine.streamHub.register(client, pairNames, engine.getBestPrices)
+
+	go client.writeLoop()
+	client.readLoop(engine)
+}
+
+// readLoop handles subscription requests until the connection is closed
+func (client *streamClient) readLoop(engine *PriceEngine) {
+	defer func() {
+		engine.streamHub.unregister(client)
+	}()
+
+	client.conn.SetReadLimit(4096)
+	client.conn.SetReadDeadline(time.Now().Add(streamPongTimeout))
+	client.conn.SetPongHandler(func(string) error {
+		return client.conn.SetReadDeadline(time.Now().Add(streamPongTimeout))
+	})
+
+	for {
+		_, data, err := client.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var req StreamSubscribeRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			client.sendError("invalid subscribe request")
+			continue
+		}
+
+		switch req.Action {
+		case "subscribe":
+			engine.streamHub.subscribe(client, req.Pairs, engine.getBestPrices)
+		case "unsubscribe":
+			client.unsubscribe(req.Pairs)
+		default:
+			client.sendError(fmt.Sprintf("unknown action %q", req.Action))
+		}
+	}
+}
+
+// writeLoop drains the send buffer onto the connection and keeps it alive with pings
+func (client *streamClient) writeLoop() {
+	ticker := time.NewTicker(streamPingInterval)
+	defer func() {
+		ticker.Stop()
+		client.conn.Close()
+	}()
+
+	for {
+		select {
+		case payload, ok := <-client.send:
+			client.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if !ok {
+				// We were disconnected by the hub
+				client.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "disconnected"))
+				return
+			}
+			message, err := payload.encodeJSON()
+			if err != nil {
+				fmt.Println("Error encoding stream message:", err)
+				continue
+			}
+			if err := client.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			client.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (client *streamClient) sendError(errMsg string) {
+	client.trySend(newStreamPayload(&StreamMessage{Type: "error", Error: errMsg}))
+}
@@ -0,0 +1,145 @@
+package PriceAPI
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamBestPrices(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	engine.setBestBidPrice("XRP/JPY", &PriceUpdate{Provider: "ProviderA", Base: "XRP", Quote: "JPY", Price: decimal.NewFromInt(45000), Amount: decimal.NewFromInt(1), Timestamp: 1})
+	engine.setBestBidPrice("BCH/JPY", &PriceUpdate{Provider: "ProviderA", Base: "BCH", Quote: "JPY", Price: decimal.NewFromInt(3000), Amount: decimal.NewFromInt(1), Timestamp: 1})
+
+	router := gin.New()
+	router.GET("/stream", engine.StreamBestPrices)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/stream?pairs=XRP/JPY"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Error dialing stream: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// The first message is a snapshot of the subscribed pairs only
+	var snapshot StreamMessage
+	if err := conn.ReadJSON(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "snapshot", snapshot.Type)
+	assert.Equal(t, 1, len(snapshot.Prices))
+	assert.Equal(t, "45000", snapshot.Prices["XRP/JPY"].Bid.Price.String())
+
+	// Changes for unsubscribed pairs are filtered out
+	engine.emitPriceUpdate(context.Background(), "BCH/JPY", &PriceUpdate{Provider: "ProviderB", Base: "BCH", Quote: "JPY", Price: decimal.NewFromInt(3001), Amount: decimal.NewFromInt(1), Timestamp: 2}, "Bid")
+	engine.emitPriceUpdate(context.Background(), "XRP/JPY", &PriceUpdate{Provider: "ProviderB", Base: "XRP", Quote: "JPY", Price: decimal.NewFromInt(45001), Amount: decimal.NewFromInt(1), Timestamp: 2}, "Bid")
+
+	var update StreamMessage
+	if err := conn.ReadJSON(&update); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "update", update.Type)
+	assert.Equal(t, "XRP/JPY", update.Pair)
+	assert.Equal(t, "Bid", update.Side)
+	assert.Equal(t, "ProviderB", update.Price.Provider)
+}
+
+func TestStreamSlowConsumerDisconnected(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	// A client with a one message buffer that never reads
+	client := &streamClient{send: make(chan *streamPayload, 1)}
+	engine.streamHub.register(client, nil, func() map[string]*BestPrice { return nil })
+	<-client.send
+
+	update := &PriceUpdate{Provider: "ProviderA", Base: "XRP", Quote: "JPY", Price: decimal.NewFromInt(45000), Amount: decimal.NewFromInt(1), Timestamp: 1}
+	engine.streamHub.broadcast("XRP/JPY", update, "Bid")
+	engine.streamHub.broadcast("XRP/JPY", update, "Bid")
+
+	engine.streamHub.mu.RLock()
+	_, stillRegistered := engine.streamHub.clients[client]
+	engine.streamHub.mu.RUnlock()
+	assert.False(t, stillRegistered, "Slow consumer should be disconnected")
+
+	// The queued message is still delivered before the channel is closed
+	_, ok := <-client.send
+	assert.True(t, ok)
+	_, ok = <-client.send
+	assert.False(t, ok)
+}
+
+func TestStreamSnapshotIsFirst(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	// Changes are broadcast the whole time clients are registering
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		update := &PriceUpdate{Provider: "ProviderA", Base: "ZEC", Quote: "CHF", Price: decimal.NewFromInt(30), Amount: decimal.NewFromInt(1), Timestamp: 1}
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				engine.streamHub.broadcast("ZEC/CHF", update, "Bid")
+			}
+		}
+	}()
+
+	// A change can never be queued ahead of a new client's snapshot
+	for i := 0; i < 200; i++ {
+		client := newStreamClient(nil)
+		engine.streamHub.register(client, []string{"ZEC/CHF"}, engine.getBestPrices)
+		first := <-client.send
+		assert.Equal(t, "snapshot", first.message.Type)
+		engine.streamHub.unregister(client)
+	}
+}
+
+func TestStreamUpdateEncodedOnce(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	clientA := newStreamClient(nil)
+	clientB := newStreamClient(nil)
+	engine.streamHub.register(clientA, nil, func() map[string]*BestPrice { return nil })
+	engine.streamHub.register(clientB, nil, func() map[string]*BestPrice { return nil })
+	<-clientA.send
+	<-clientB.send
+
+	update := &PriceUpdate{Provider: "ProviderA", Base: "DOT", Quote: "CHF", Price: decimal.NewFromInt(5), Amount: decimal.NewFromInt(1), Timestamp: 1}
+	engine.streamHub.broadcast("DOT/CHF", update, "Ask")
+
+	// Every client is queued the same message so it is only encoded once per transport
+	payloadA := <-clientA.send
+	payloadB := <-clientB.send
+	assert.Same(t, payloadA, payloadB)
+
+	encodedA, err := payloadA.encodeJSON()
+	assert.NoError(t, err)
+	encodedB, err := payloadB.encodeJSON()
+	assert.NoError(t, err)
+	assert.Same(t, &encodedA[0], &encodedB[0])
+	assert.Same(t, payloadA.encodeProto(), payloadB.encodeProto())
+
+	var decoded StreamMessage
+	assert.NoError(t, json.Unmarshal(encodedA, &decoded))
+	assert.Equal(t, "DOT/CHF", decoded.Pair)
+	assert.Equal(t, "Ask", decoded.Side)
+	assert.Equal(t, "DOT/CHF", payloadA.encodeProto().GetPair())
+}
@@ -0,0 +1,171 @@
+package PriceAPI
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Units accepted in a price update's timestamp_unit, timestamps are stored as Unix milliseconds
+const (
+	TimestampUnitSeconds      = "s"
+	TimestampUnitMilliseconds = "ms"
+	TimestampUnitMicroseconds = "us"
+	TimestampUnitNanoseconds  = "ns"
+)
+
+var (
+	// How far ahead of the PriceAPI's clock a provider's timestamp can be
+	MaxTimestampSkew = time.Minute
+	// Earlier timestamps were almost certainly sent in the wrong unit
+	MinTimestamp = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// LatencyStats is the delay between a provider timestamping its updates and the PriceAPI
+// receiving them. Network latency can't be negative, so any negative delay is the
+// provider's clock running ahead of ours.
+type LatencyStats struct {
+	Samples uint64  `json:"samples"`
+	LastMs  int64   `json:"last_ms"`
+	MeanMs  float64 `json:"mean_ms"`
+	MinMs   int64   `json:"min_ms"`
+	MaxMs   int64   `json:"max_ms"`
+	// Lower bound on how far the provider's clock is ahead of ours
+	ClockSkewMs int64 `json:"clock_skew_ms"`
+	// Updates without a timestamp, these are stamped with the receive time instead
+	Unstamped uint64 `json:"unstamped"`
+	totalMs   int64
+}
+
+// UnmarshalJSON accepts the timestamp as an RFC3339 string or as a Unix epoch number in
+// the unit given by timestamp_unit, milliseconds by default.
+func (req *PriceUpdateRequest) UnmarshalJSON(data []byte) error {
+	type priceUpdateRequest PriceUpdateRequest
+	aux := struct {
+		*priceUpdateRequest
+		Timestamp     json.RawMessage `json:"timestamp"`
+		TimestampUnit string          `json:"timestamp_unit"`
+	}{priceUpdateRequest: (*priceUpdateRequest)(req)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	timestamp, err := parseTimestamp(aux.Timestamp, aux.TimestampUnit)
+	if err != nil {
+		return err
+	}
+	req.Timestamp = timestamp
+	return nil
+}
+
+// parseTimestamp converts a JSON timestamp to Unix milliseconds, a missing timestamp is 0.
+func parseTimestamp(raw json.RawMessage, unit string) (int64, error) {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return 0, nil
+	}
+	if raw[0] == '"' {
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return 0, err
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return 0, fmt.Errorf("timestamp must be RFC3339 or a Unix epoch number: %q", value)
+		}
+		return parsed.UnixMilli(), nil
+	}
+
+	value, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("timestamp must be RFC3339 or a whole Unix epoch number: %s", raw)
+	}
+	switch unit {
+	case TimestampUnitSeconds:
+		return time.Unix(value, 0).UnixMilli(), nil
+	case "", TimestampUnitMilliseconds:
+		return value, nil
+	case TimestampUnitMicroseconds:
+		return time.UnixMicro(value).UnixMilli(), nil
+	case TimestampUnitNanoseconds:
+		return time.Unix(0, value).UnixMilli(), nil
+	}
+	return 0, fmt.Errorf("unsupported timestamp_unit %q, must be s, ms, us or ns", unit)
+}
+
+// validateTimestamp checks an update's timestamp is plausible, stamping updates that
+// don't have one with the time they were received. Returns whether it was stamped.
+func validateTimestamp(update *PriceUpdateRequest) (bool, error) {
+	if update.Timestamp == 0 {
+		update.Timestamp = update.ReceivedAt.UnixMilli()
+		return true, nil
+	}
+	timestamp := time.UnixMilli(update.Timestamp)
+	if timestamp.Before(MinTimestamp) {
+		return false, fmt.Errorf("timestamp %d is before %s, timestamps are Unix milliseconds unless timestamp_unit is set", update.Timestamp, MinTimestamp.Format(time.DateOnly))
+	}
+	if timestamp.After(update.ReceivedAt.Add(MaxTimestampSkew)) {
+		return false, fmt.Errorf("timestamp %s is more than %s in the future", timestamp.UTC().Format(time.RFC3339Nano), MaxTimestampSkew)
+	}
+	return false, nil
+}
+
+// recordLatency adds an accepted update to its provider's latency stats.
+func (engine *PriceEngine) recordLatency(update *PriceUpdateRequest, stamped bool) {
+	engine.latencyMu.Lock()
+	defer engine.latencyMu.Unlock()
+	stats := engine.latencyStats[update.Provider]
+	if stats == nil {
+		stats = &LatencyStats{}
+		engine.latencyStats[update.Provider] = stats
+	}
+	if stamped {
+		stats.Unstamped++
+		return
+	}
+
+	delay := update.ReceivedAt.UnixMilli() - update.Timestamp
+	if stats.Samples == 0 || delay < stats.MinMs {
+		stats.MinMs = delay
+	}
+	if stats.Samples == 0 || delay > stats.MaxMs {
+		stats.MaxMs = delay
+	}
+	stats.Samples++
+	stats.LastMs = delay
+	stats.totalMs += delay
+	stats.MeanMs = float64(stats.totalMs) / float64(stats.Samples)
+	if stats.MinMs < 0 {
+		stats.ClockSkewMs = -stats.MinMs
+	}
+}
+
+// getLatencyStats returns a copy of the latency stats for every provider.
+func (engine *PriceEngine) getLatencyStats() map[string]LatencyStats {
+	engine.latencyMu.Lock()
+	defer engine.latencyMu.Unlock()
+	stats := make(map[string]LatencyStats, len(engine.latencyStats))
+	for providerName, providerStats := range engine.latencyStats {
+		stats[providerName] = *providerStats
+	}
+	return stats
+}
+
+// GetLatencyStats returns the latency and clock skew stats for every provider.
+func (engine *PriceEngine) GetLatencyStats(c *gin.Context) {
+	c.JSON(http.StatusOK, engine.getLatencyStats())
+}
+
+// GetProviderLatencyStats returns the latency and clock skew stats for a single provider.
+func (engine *PriceEngine) GetProviderLatencyStats(c *gin.Context) {
+	providerName := c.Param("providerName")
+	stats, ok := engine.getLatencyStats()[providerName]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no updates received from %s", providerName)})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
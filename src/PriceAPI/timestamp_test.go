@@ -0,0 +1,123 @@
+package PriceAPI
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceUpdateRequestTimestampUnits(t *testing.T) {
+	// 2024-03-01 12:30:45.123 UTC
+	const expected = int64(1709296245123)
+	tests := map[string]string{
+		"default milliseconds": `{"timestamp":1709296245123}`,
+		"milliseconds":         `{"timestamp":1709296245123,"timestamp_unit":"ms"}`,
+		"microseconds":         `{"timestamp":1709296245123456,"timestamp_unit":"us"}`,
+		"nanoseconds":          `{"timestamp":1709296245123456789,"timestamp_unit":"ns"}`,
+		"rfc3339":              `{"timestamp":"2024-03-01T12:30:45.123Z"}`,
+		"rfc3339 offset":       `{"timestamp":"2024-03-01T20:30:45.123+08:00"}`,
+	}
+	for name, data := range tests {
+		var update PriceUpdateRequest
+		if assert.Nil(t, json.Unmarshal([]byte(data), &update), name) {
+			assert.Equal(t, expected, update.Timestamp, name)
+		}
+	}
+
+	var update PriceUpdateRequest
+	assert.Nil(t, json.Unmarshal([]byte(`{"provider":"ProviderA","bid":"1.5","timestamp":1709296245,"timestamp_unit":"s"}`), &update))
+	assert.Equal(t, int64(1709296245000), update.Timestamp)
+	assert.Equal(t, "ProviderA", update.Provider)
+	assert.Equal(t, "1.5", update.Bid.String())
+
+	// Missing timestamps are left for the PriceAPI to stamp
+	update = PriceUpdateRequest{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"provider":"ProviderA"}`), &update))
+	assert.Equal(t, int64(0), update.Timestamp)
+
+	for _, data := range []string{
+		`{"timestamp":1709296245123,"timestamp_unit":"minutes"}`,
+		`{"timestamp":"yesterday"}`,
+		`{"timestamp":1709296245.5}`,
+	} {
+		assert.NotNil(t, json.Unmarshal([]byte(data), &update), data)
+	}
+}
+
+func TestLatencyStats(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestLatencyStats")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("UNI", "NOK"))
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("UNI", "SEK"))
+
+	router := gin.Default()
+	router.POST("/prices/batch", engine.ProcessPriceUpdateBatchRequest)
+	router.GET("/latency/stats/:providerName", engine.GetProviderLatencyStats)
+
+	// Sent 250ms ago, 100ms ago, 40ms in the future (our clock is behind) and without a
+	// timestamp, which goes to another pair so it isn't older than the one before it
+	now := time.Now()
+	body := []byte(`[
+		{"provider":"LatencyProvider","base":"UNI","quote":"NOK","bid":"70","bid_amount":"1","timestamp":"` + now.Add(-250*time.Millisecond).Format(time.RFC3339Nano) + `"},
+		{"provider":"LatencyProvider","base":"UNI","quote":"NOK","bid":"70","bid_amount":"1","timestamp":` + jsonInt(now.Add(-100*time.Millisecond).UnixMicro()) + `,"timestamp_unit":"us"},
+		{"provider":"LatencyProvider","base":"UNI","quote":"NOK","bid":"70","bid_amount":"1","timestamp":` + jsonInt(now.Add(40*time.Millisecond).UnixMilli()) + `},
+		{"provider":"LatencyProvider","base":"UNI","quote":"SEK","bid":"70","bid_amount":"1"}
+	]`)
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/prices/batch", bytes.NewBuffer(body))
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response PriceBatchResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 4, response.Accepted, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/latency/stats/LatencyProvider", nil)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var stats LatencyStats
+	if err := json.Unmarshal(rr.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(3), stats.Samples)
+	assert.Equal(t, uint64(1), stats.Unstamped)
+	// The batch is received a moment after now so allow some leeway
+	assert.InDelta(t, 250, stats.MaxMs, 50)
+	assert.InDelta(t, -40, stats.MinMs, 50)
+	assert.InDelta(t, -40, stats.LastMs, 50)
+	assert.InDelta(t, 103, stats.MeanMs, 50)
+	assert.Equal(t, -stats.MinMs, stats.ClockSkewMs)
+
+	rr = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/latency/stats/UnknownProvider", nil)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func jsonInt(value int64) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
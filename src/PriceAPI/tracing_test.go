@@ -0,0 +1,106 @@
+package PriceAPI
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/hongkongkiwi/chaostheory/src/Tracing"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestPriceUpdateTracing(t *testing.T) {
+	engine := NewPriceEngine()
+	defer engine.Close()
+
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestPriceUpdateTracing")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument("HBAR", "INR"))
+	ProviderConfig.SetPairEnabled("TraceProvider", "HBAR/INR", true)
+	disableOutlierFilter(t, engine)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(tracerProvider)
+	defer tracerProvider.Shutdown(context.Background())
+
+	// Best price events are sent to a webhook that records their traceparent
+	var webhookMu sync.Mutex
+	webhookTraceparents := make([]string, 0)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webhookMu.Lock()
+		defer webhookMu.Unlock()
+		webhookTraceparents = append(webhookTraceparents, r.Header.Get("traceparent"))
+	}))
+	defer webhook.Close()
+	sink := NewWebhookSink(webhook.URL, time.Second)
+	assert.NoError(t, engine.Sinks.Register(sink))
+
+	router := gin.New()
+	router.Use(Tracing.Middleware)
+	router.POST("/prices", engine.ProcessPriceUpdateRequest)
+
+	// A fresh trace ID each run so spans from an earlier run don't match
+	traceID := strconv.FormatInt(time.Now().UnixNano(), 16)
+	traceID = strings.Repeat("0", 32-len(traceID)) + traceID
+	rr := httptest.NewRecorder()
+	body := `{"provider":"TraceProvider","base":"HBAR","quote":"INR","bid":"9","bid_amount":"1","ask":"10","ask_amount":"1","timestamp":` + strconv.FormatInt(time.Now().UnixMilli(), 10) + `}`
+	req, _ := http.NewRequest("POST", "/prices", bytes.NewBufferString(body))
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// The update is applied from the ingest queue and the webhook is called from the sink's
+	// goroutine, both continue the trace from the request
+	findSpan := func(name string) *tracetest.SpanStub {
+		for _, span := range exporter.GetSpans() {
+			if span.Name == name && span.SpanContext.TraceID().String() == traceID {
+				return &span
+			}
+		}
+		return nil
+	}
+	assert.Eventually(t, func() bool {
+		return findSpan("POST "+webhook.URL) != nil
+	}, time.Second, 10*time.Millisecond)
+
+	server := findSpan("POST /prices")
+	apply := findSpan("PriceAPI.applyPriceUpdates")
+	recalculate := findSpan("PriceAPI.recalculateBestPricesForPair")
+	if !assert.NotNil(t, server) || !assert.NotNil(t, apply) || !assert.NotNil(t, recalculate) {
+		return
+	}
+	assert.Equal(t, server.SpanContext.SpanID(), apply.Parent.SpanID())
+	assert.Equal(t, apply.SpanContext.SpanID(), recalculate.Parent.SpanID())
+	assert.Equal(t, recalculate.SpanContext.SpanID(), findSpan("POST "+webhook.URL).Parent.SpanID())
+
+	webhookMu.Lock()
+	defer webhookMu.Unlock()
+	found := false
+	for _, traceparent := range webhookTraceparents {
+		found = found || strings.Contains(traceparent, traceID)
+	}
+	assert.True(t, found)
+}
@@ -0,0 +1,6 @@
+// Package PriceProto holds the protobuf messages and generated gRPC client and server
+// for the PriceAPI. Regenerate with go generate, which needs buf, protoc-gen-go and
+// protoc-gen-go-grpc on the PATH.
+package PriceProto
+
+//go:generate buf generate --template buf.gen.yaml .
@@ -0,0 +1,840 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: priceService.proto
+
+package PriceProto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Prices and amounts are decimal strings so they are never rounded
+type PriceUpdateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Provider  string `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	Base      string `protobuf:"bytes,2,opt,name=base,proto3" json:"base,omitempty"`
+	Quote     string `protobuf:"bytes,3,opt,name=quote,proto3" json:"quote,omitempty"`
+	Bid       string `protobuf:"bytes,4,opt,name=bid,proto3" json:"bid,omitempty"`
+	BidAmount string `protobuf:"bytes,5,opt,name=bid_amount,json=bidAmount,proto3" json:"bid_amount,omitempty"`
+	Ask       string `protobuf:"bytes,6,opt,name=ask,proto3" json:"ask,omitempty"`
+	AskAmount string `protobuf:"bytes,7,opt,name=ask_amount,json=askAmount,proto3" json:"ask_amount,omitempty"`
+	// Unix milliseconds, 0 means the PriceAPI stamps the update with its receive time
+	Timestamp int64 `protobuf:"varint,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Optional per provider, per pair sequence number, 0 means not set
+	Sequence uint64 `protobuf:"varint,9,opt,name=sequence,proto3" json:"sequence,omitempty"`
+}
+
+func (x *PriceUpdateRequest) Reset() {
+	*x = PriceUpdateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_priceService_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PriceUpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PriceUpdateRequest) ProtoMessage() {}
+
+func (x *PriceUpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_priceService_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PriceUpdateRequest.ProtoReflect.Descriptor instead.
+func (*PriceUpdateRequest) Descriptor() ([]byte, []int) {
+	return file_priceService_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PriceUpdateRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *PriceUpdateRequest) GetBase() string {
+	if x != nil {
+		return x.Base
+	}
+	return ""
+}
+
+func (x *PriceUpdateRequest) GetQuote() string {
+	if x != nil {
+		return x.Quote
+	}
+	return ""
+}
+
+func (x *PriceUpdateRequest) GetBid() string {
+	if x != nil {
+		return x.Bid
+	}
+	return ""
+}
+
+func (x *PriceUpdateRequest) GetBidAmount() string {
+	if x != nil {
+		return x.BidAmount
+	}
+	return ""
+}
+
+func (x *PriceUpdateRequest) GetAsk() string {
+	if x != nil {
+		return x.Ask
+	}
+	return ""
+}
+
+func (x *PriceUpdateRequest) GetAskAmount() string {
+	if x != nil {
+		return x.AskAmount
+	}
+	return ""
+}
+
+func (x *PriceUpdateRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *PriceUpdateRequest) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+type PriceUpdateResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Position of the update in the stream, starting at 0
+	Index    int64  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Provider string `protobuf:"bytes,2,opt,name=provider,proto3" json:"provider,omitempty"`
+	Pair     string `protobuf:"bytes,3,opt,name=pair,proto3" json:"pair,omitempty"`
+	Accepted bool   `protobuf:"varint,4,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Error    string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *PriceUpdateResult) Reset() {
+	*x = PriceUpdateResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_priceService_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PriceUpdateResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PriceUpdateResult) ProtoMessage() {}
+
+func (x *PriceUpdateResult) ProtoReflect() protoreflect.Message {
+	mi := &file_priceService_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PriceUpdateResult.ProtoReflect.Descriptor instead.
+func (*PriceUpdateResult) Descriptor() ([]byte, []int) {
+	return file_priceService_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PriceUpdateResult) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *PriceUpdateResult) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *PriceUpdateResult) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+func (x *PriceUpdateResult) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+func (x *PriceUpdateResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type PublishPricesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Accepted int64 `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected int64 `protobuf:"varint,2,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	// Only the first rejections are kept on long lived streams
+	Rejections []*PriceUpdateResult `protobuf:"bytes,3,rep,name=rejections,proto3" json:"rejections,omitempty"`
+}
+
+func (x *PublishPricesResponse) Reset() {
+	*x = PublishPricesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_priceService_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PublishPricesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishPricesResponse) ProtoMessage() {}
+
+func (x *PublishPricesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_priceService_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishPricesResponse.ProtoReflect.Descriptor instead.
+func (*PublishPricesResponse) Descriptor() ([]byte, []int) {
+	return file_priceService_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PublishPricesResponse) GetAccepted() int64 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *PublishPricesResponse) GetRejected() int64 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+func (x *PublishPricesResponse) GetRejections() []*PriceUpdateResult {
+	if x != nil {
+		return x.Rejections
+	}
+	return nil
+}
+
+type SubscribeBestPricesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Pairs to receive e.g. BTC/USD, no pairs means every pair
+	Pairs []string `protobuf:"bytes,1,rep,name=pairs,proto3" json:"pairs,omitempty"`
+}
+
+func (x *SubscribeBestPricesRequest) Reset() {
+	*x = SubscribeBestPricesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_priceService_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubscribeBestPricesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeBestPricesRequest) ProtoMessage() {}
+
+func (x *SubscribeBestPricesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_priceService_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeBestPricesRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeBestPricesRequest) Descriptor() ([]byte, []int) {
+	return file_priceService_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SubscribeBestPricesRequest) GetPairs() []string {
+	if x != nil {
+		return x.Pairs
+	}
+	return nil
+}
+
+type PriceUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Provider  string `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	Base      string `protobuf:"bytes,2,opt,name=base,proto3" json:"base,omitempty"`
+	Quote     string `protobuf:"bytes,3,opt,name=quote,proto3" json:"quote,omitempty"`
+	Price     string `protobuf:"bytes,4,opt,name=price,proto3" json:"price,omitempty"`
+	Amount    string `protobuf:"bytes,5,opt,name=amount,proto3" json:"amount,omitempty"`
+	Timestamp int64  `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+}
+
+func (x *PriceUpdate) Reset() {
+	*x = PriceUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_priceService_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PriceUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PriceUpdate) ProtoMessage() {}
+
+func (x *PriceUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_priceService_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PriceUpdate.ProtoReflect.Descriptor instead.
+func (*PriceUpdate) Descriptor() ([]byte, []int) {
+	return file_priceService_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PriceUpdate) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *PriceUpdate) GetBase() string {
+	if x != nil {
+		return x.Base
+	}
+	return ""
+}
+
+func (x *PriceUpdate) GetQuote() string {
+	if x != nil {
+		return x.Quote
+	}
+	return ""
+}
+
+func (x *PriceUpdate) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *PriceUpdate) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *PriceUpdate) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type BestPrice struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Base  string       `protobuf:"bytes,1,opt,name=base,proto3" json:"base,omitempty"`
+	Quote string       `protobuf:"bytes,2,opt,name=quote,proto3" json:"quote,omitempty"`
+	Bid   *PriceUpdate `protobuf:"bytes,3,opt,name=bid,proto3" json:"bid,omitempty"`
+	Ask   *PriceUpdate `protobuf:"bytes,4,opt,name=ask,proto3" json:"ask,omitempty"`
+	// Empty unless there is both a bid and an ask
+	Spread string `protobuf:"bytes,5,opt,name=spread,proto3" json:"spread,omitempty"`
+}
+
+func (x *BestPrice) Reset() {
+	*x = BestPrice{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_priceService_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BestPrice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BestPrice) ProtoMessage() {}
+
+func (x *BestPrice) ProtoReflect() protoreflect.Message {
+	mi := &file_priceService_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BestPrice.ProtoReflect.Descriptor instead.
+func (*BestPrice) Descriptor() ([]byte, []int) {
+	return file_priceService_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BestPrice) GetBase() string {
+	if x != nil {
+		return x.Base
+	}
+	return ""
+}
+
+func (x *BestPrice) GetQuote() string {
+	if x != nil {
+		return x.Quote
+	}
+	return ""
+}
+
+func (x *BestPrice) GetBid() *PriceUpdate {
+	if x != nil {
+		return x.Bid
+	}
+	return nil
+}
+
+func (x *BestPrice) GetAsk() *PriceUpdate {
+	if x != nil {
+		return x.Ask
+	}
+	return nil
+}
+
+func (x *BestPrice) GetSpread() string {
+	if x != nil {
+		return x.Spread
+	}
+	return ""
+}
+
+// BestPriceMessage mirrors the WebSocket stream messages
+type BestPriceMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// snapshot or update
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Pair string `protobuf:"bytes,2,opt,name=pair,proto3" json:"pair,omitempty"`
+	Side string `protobuf:"bytes,3,opt,name=side,proto3" json:"side,omitempty"`
+	// Unset when a side no longer has a best price
+	Price  *PriceUpdate          `protobuf:"bytes,4,opt,name=price,proto3" json:"price,omitempty"`
+	Prices map[string]*BestPrice `protobuf:"bytes,5,rep,name=prices,proto3" json:"prices,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (x *BestPriceMessage) Reset() {
+	*x = BestPriceMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_priceService_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BestPriceMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BestPriceMessage) ProtoMessage() {}
+
+func (x *BestPriceMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_priceService_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BestPriceMessage.ProtoReflect.Descriptor instead.
+func (*BestPriceMessage) Descriptor() ([]byte, []int) {
+	return file_priceService_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BestPriceMessage) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *BestPriceMessage) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+func (x *BestPriceMessage) GetSide() string {
+	if x != nil {
+		return x.Side
+	}
+	return ""
+}
+
+func (x *BestPriceMessage) GetPrice() *PriceUpdate {
+	if x != nil {
+		return x.Price
+	}
+	return nil
+}
+
+func (x *BestPriceMessage) GetPrices() map[string]*BestPrice {
+	if x != nil {
+		return x.Prices
+	}
+	return nil
+}
+
+var File_priceService_proto protoreflect.FileDescriptor
+
+var file_priceService_proto_rawDesc = []byte{
+	0x0a, 0x12, 0x70, 0x72, 0x69, 0x63, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x11, 0x63, 0x68, 0x61, 0x6f, 0x73, 0x74, 0x68, 0x65, 0x6f, 0x72,
+	0x79, 0x2e, 0x70, 0x72, 0x69, 0x63, 0x65, 0x22, 0xf6, 0x01, 0x0a, 0x12, 0x50, 0x72, 0x69, 0x63,
+	0x65, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a,
+	0x0a, 0x08, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x62, 0x61,
+	0x73, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x62, 0x61, 0x73, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x71,
+	0x75, 0x6f, 0x74, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x62, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x62, 0x69, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x69, 0x64, 0x5f, 0x61, 0x6d,
+	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x62, 0x69, 0x64, 0x41,
+	0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x73, 0x6b, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x61, 0x73, 0x6b, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x73, 0x6b, 0x5f, 0x61,
+	0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x61, 0x73, 0x6b,
+	0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65,
+	0x22, 0x8b, 0x01, 0x0a, 0x11, 0x50, 0x72, 0x69, 0x63, 0x65, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x1a, 0x0a, 0x08,
+	0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x69, 0x72,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x69, 0x72, 0x12, 0x1a, 0x0a, 0x08,
+	0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08,
+	0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x95,
+	0x01, 0x0a, 0x15, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x61, 0x63, 0x63, 0x65,
+	0x70, 0x74, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x61, 0x63, 0x63, 0x65,
+	0x70, 0x74, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x65, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x72, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x65, 0x64,
+	0x12, 0x44, 0x0a, 0x0a, 0x72, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x03,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x63, 0x68, 0x61, 0x6f, 0x73, 0x74, 0x68, 0x65, 0x6f,
+	0x72, 0x79, 0x2e, 0x70, 0x72, 0x69, 0x63, 0x65, 0x2e, 0x50, 0x72, 0x69, 0x63, 0x65, 0x55, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x0a, 0x72, 0x65, 0x6a, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x32, 0x0a, 0x1a, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72,
+	0x69, 0x62, 0x65, 0x42, 0x65, 0x73, 0x74, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x61, 0x69, 0x72, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x05, 0x70, 0x61, 0x69, 0x72, 0x73, 0x22, 0x9f, 0x01, 0x0a, 0x0b, 0x50,
+	0x72, 0x69, 0x63, 0x65, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72,
+	0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x72,
+	0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x62, 0x61, 0x73, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x62, 0x61, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x71, 0x75,
+	0x6f, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x71, 0x75, 0x6f, 0x74, 0x65,
+	0x12, 0x14, 0x0a, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1c,
+	0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0xb1, 0x01, 0x0a,
+	0x09, 0x42, 0x65, 0x73, 0x74, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x62, 0x61,
+	0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x62, 0x61, 0x73, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x71,
+	0x75, 0x6f, 0x74, 0x65, 0x12, 0x30, 0x0a, 0x03, 0x62, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1e, 0x2e, 0x63, 0x68, 0x61, 0x6f, 0x73, 0x74, 0x68, 0x65, 0x6f, 0x72, 0x79, 0x2e,
+	0x70, 0x72, 0x69, 0x63, 0x65, 0x2e, 0x50, 0x72, 0x69, 0x63, 0x65, 0x55, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x52, 0x03, 0x62, 0x69, 0x64, 0x12, 0x30, 0x0a, 0x03, 0x61, 0x73, 0x6b, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x63, 0x68, 0x61, 0x6f, 0x73, 0x74, 0x68, 0x65, 0x6f, 0x72,
+	0x79, 0x2e, 0x70, 0x72, 0x69, 0x63, 0x65, 0x2e, 0x50, 0x72, 0x69, 0x63, 0x65, 0x55, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x52, 0x03, 0x61, 0x73, 0x6b, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x70, 0x72, 0x65,
+	0x61, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x70, 0x72, 0x65, 0x61, 0x64,
+	0x22, 0xa6, 0x02, 0x0a, 0x10, 0x42, 0x65, 0x73, 0x74, 0x50, 0x72, 0x69, 0x63, 0x65, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x69,
+	0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x69, 0x72, 0x12, 0x12, 0x0a,
+	0x04, 0x73, 0x69, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x73, 0x69, 0x64,
+	0x65, 0x12, 0x34, 0x0a, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1e, 0x2e, 0x63, 0x68, 0x61, 0x6f, 0x73, 0x74, 0x68, 0x65, 0x6f, 0x72, 0x79, 0x2e, 0x70,
+	0x72, 0x69, 0x63, 0x65, 0x2e, 0x50, 0x72, 0x69, 0x63, 0x65, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x52, 0x05, 0x70, 0x72, 0x69, 0x63, 0x65, 0x12, 0x47, 0x0a, 0x06, 0x70, 0x72, 0x69, 0x63, 0x65,
+	0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x63, 0x68, 0x61, 0x6f, 0x73, 0x74,
+	0x68, 0x65, 0x6f, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x69, 0x63, 0x65, 0x2e, 0x42, 0x65, 0x73, 0x74,
+	0x50, 0x72, 0x69, 0x63, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x50, 0x72, 0x69,
+	0x63, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x70, 0x72, 0x69, 0x63, 0x65, 0x73,
+	0x1a, 0x57, 0x0a, 0x0b, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12,
+	0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65,
+	0x79, 0x12, 0x32, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1c, 0x2e, 0x63, 0x68, 0x61, 0x6f, 0x73, 0x74, 0x68, 0x65, 0x6f, 0x72, 0x79, 0x2e, 0x70,
+	0x72, 0x69, 0x63, 0x65, 0x2e, 0x42, 0x65, 0x73, 0x74, 0x50, 0x72, 0x69, 0x63, 0x65, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x32, 0xbc, 0x02, 0x0a, 0x0c, 0x50, 0x72,
+	0x69, 0x63, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x5b, 0x0a, 0x0c, 0x50, 0x75,
+	0x62, 0x6c, 0x69, 0x73, 0x68, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x25, 0x2e, 0x63, 0x68, 0x61,
+	0x6f, 0x73, 0x74, 0x68, 0x65, 0x6f, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x69, 0x63, 0x65, 0x2e, 0x50,
+	0x72, 0x69, 0x63, 0x65, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x24, 0x2e, 0x63, 0x68, 0x61, 0x6f, 0x73, 0x74, 0x68, 0x65, 0x6f, 0x72, 0x79, 0x2e,
+	0x70, 0x72, 0x69, 0x63, 0x65, 0x2e, 0x50, 0x72, 0x69, 0x63, 0x65, 0x55, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x62, 0x0a, 0x0d, 0x50, 0x75, 0x62, 0x6c, 0x69,
+	0x73, 0x68, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x12, 0x25, 0x2e, 0x63, 0x68, 0x61, 0x6f, 0x73,
+	0x74, 0x68, 0x65, 0x6f, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x69, 0x63, 0x65, 0x2e, 0x50, 0x72, 0x69,
+	0x63, 0x65, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x28, 0x2e, 0x63, 0x68, 0x61, 0x6f, 0x73, 0x74, 0x68, 0x65, 0x6f, 0x72, 0x79, 0x2e, 0x70, 0x72,
+	0x69, 0x63, 0x65, 0x2e, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x50, 0x72, 0x69, 0x63, 0x65,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x12, 0x6b, 0x0a, 0x13, 0x53,
+	0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x42, 0x65, 0x73, 0x74, 0x50, 0x72, 0x69, 0x63,
+	0x65, 0x73, 0x12, 0x2d, 0x2e, 0x63, 0x68, 0x61, 0x6f, 0x73, 0x74, 0x68, 0x65, 0x6f, 0x72, 0x79,
+	0x2e, 0x70, 0x72, 0x69, 0x63, 0x65, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65,
+	0x42, 0x65, 0x73, 0x74, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x23, 0x2e, 0x63, 0x68, 0x61, 0x6f, 0x73, 0x74, 0x68, 0x65, 0x6f, 0x72, 0x79, 0x2e,
+	0x70, 0x72, 0x69, 0x63, 0x65, 0x2e, 0x42, 0x65, 0x73, 0x74, 0x50, 0x72, 0x69, 0x63, 0x65, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x30, 0x01, 0x42, 0x3f, 0x5a, 0x3d, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x68, 0x6f, 0x6e, 0x67, 0x6b, 0x6f, 0x6e, 0x67, 0x6b,
+	0x69, 0x77, 0x69, 0x2f, 0x63, 0x68, 0x61, 0x6f, 0x73, 0x74, 0x68, 0x65, 0x6f, 0x72, 0x79, 0x2f,
+	0x73, 0x72, 0x63, 0x2f, 0x50, 0x72, 0x69, 0x63, 0x65, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x3b, 0x50,
+	0x72, 0x69, 0x63, 0x65, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_priceService_proto_rawDescOnce sync.Once
+	file_priceService_proto_rawDescData = file_priceService_proto_rawDesc
+)
+
+func file_priceService_proto_rawDescGZIP() []byte {
+	file_priceService_proto_rawDescOnce.Do(func() {
+		file_priceService_proto_rawDescData = protoimpl.X.CompressGZIP(file_priceService_proto_rawDescData)
+	})
+	return file_priceService_proto_rawDescData
+}
+
+var file_priceService_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_priceService_proto_goTypes = []any{
+	(*PriceUpdateRequest)(nil),         // 0: chaostheory.price.PriceUpdateRequest
+	(*PriceUpdateResult)(nil),          // 1: chaostheory.price.PriceUpdateResult
+	(*PublishPricesResponse)(nil),      // 2: chaostheory.price.PublishPricesResponse
+	(*SubscribeBestPricesRequest)(nil), // 3: chaostheory.price.SubscribeBestPricesRequest
+	(*PriceUpdate)(nil),                // 4: chaostheory.price.PriceUpdate
+	(*BestPrice)(nil),                  // 5: chaostheory.price.BestPrice
+	(*BestPriceMessage)(nil),           // 6: chaostheory.price.BestPriceMessage
+	nil,                                // 7: chaostheory.price.BestPriceMessage.PricesEntry
+}
+var file_priceService_proto_depIdxs = []int32{
+	1, // 0: chaostheory.price.PublishPricesResponse.rejections:type_name -> chaostheory.price.PriceUpdateResult
+	4, // 1: chaostheory.price.BestPrice.bid:type_name -> chaostheory.price.PriceUpdate
+	4, // 2: chaostheory.price.BestPrice.ask:type_name -> chaostheory.price.PriceUpdate
+	4, // 3: chaostheory.price.BestPriceMessage.price:type_name -> chaostheory.price.PriceUpdate
+	7, // 4: chaostheory.price.BestPriceMessage.prices:type_name -> chaostheory.price.BestPriceMessage.PricesEntry
+	5, // 5: chaostheory.price.BestPriceMessage.PricesEntry.value:type_name -> chaostheory.price.BestPrice
+	0, // 6: chaostheory.price.PriceService.PublishPrice:input_type -> chaostheory.price.PriceUpdateRequest
+	0, // 7: chaostheory.price.PriceService.PublishPrices:input_type -> chaostheory.price.PriceUpdateRequest
+	3, // 8: chaostheory.price.PriceService.SubscribeBestPrices:input_type -> chaostheory.price.SubscribeBestPricesRequest
+	1, // 9: chaostheory.price.PriceService.PublishPrice:output_type -> chaostheory.price.PriceUpdateResult
+	2, // 10: chaostheory.price.PriceService.PublishPrices:output_type -> chaostheory.price.PublishPricesResponse
+	6, // 11: chaostheory.price.PriceService.SubscribeBestPrices:output_type -> chaostheory.price.BestPriceMessage
+	9, // [9:12] is the sub-list for method output_type
+	6, // [6:9] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_priceService_proto_init() }
+func file_priceService_proto_init() {
+	if File_priceService_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_priceService_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*PriceUpdateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_priceService_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*PriceUpdateResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_priceService_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*PublishPricesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_priceService_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*SubscribeBestPricesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_priceService_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*PriceUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_priceService_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*BestPrice); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_priceService_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*BestPriceMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_priceService_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_priceService_proto_goTypes,
+		DependencyIndexes: file_priceService_proto_depIdxs,
+		MessageInfos:      file_priceService_proto_msgTypes,
+	}.Build()
+	File_priceService_proto = out.File
+	file_priceService_proto_rawDesc = nil
+	file_priceService_proto_goTypes = nil
+	file_priceService_proto_depIdxs = nil
+}
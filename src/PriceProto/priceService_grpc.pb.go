@@ -0,0 +1,262 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: priceService.proto
+
+package PriceProto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	PriceService_PublishPrice_FullMethodName        = "/chaostheory.price.PriceService/PublishPrice"
+	PriceService_PublishPrices_FullMethodName       = "/chaostheory.price.PriceService/PublishPrices"
+	PriceService_SubscribeBestPrices_FullMethodName = "/chaostheory.price.PriceService/SubscribeBestPrices"
+)
+
+// PriceServiceClient is the client API for PriceService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PriceService is the gRPC transport for the PriceAPI, it applies the same validation
+// and best price selection as the HTTP endpoints.
+type PriceServiceClient interface {
+	// Publish a single price update, rejected updates return an InvalidArgument error
+	PublishPrice(ctx context.Context, in *PriceUpdateRequest, opts ...grpc.CallOption) (*PriceUpdateResult, error)
+	// Publish a stream of price updates over a long lived connection, each update is
+	// applied as it arrives and the rejections are returned when the stream is closed
+	PublishPrices(ctx context.Context, opts ...grpc.CallOption) (PriceService_PublishPricesClient, error)
+	// Receive a snapshot of the current best prices followed by every change
+	SubscribeBestPrices(ctx context.Context, in *SubscribeBestPricesRequest, opts ...grpc.CallOption) (PriceService_SubscribeBestPricesClient, error)
+}
+
+type priceServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPriceServiceClient(cc grpc.ClientConnInterface) PriceServiceClient {
+	return &priceServiceClient{cc}
+}
+
+func (c *priceServiceClient) PublishPrice(ctx context.Context, in *PriceUpdateRequest, opts ...grpc.CallOption) (*PriceUpdateResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PriceUpdateResult)
+	err := c.cc.Invoke(ctx, PriceService_PublishPrice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *priceServiceClient) PublishPrices(ctx context.Context, opts ...grpc.CallOption) (PriceService_PublishPricesClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PriceService_ServiceDesc.Streams[0], PriceService_PublishPrices_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &priceServicePublishPricesClient{ClientStream: stream}
+	return x, nil
+}
+
+type PriceService_PublishPricesClient interface {
+	Send(*PriceUpdateRequest) error
+	CloseAndRecv() (*PublishPricesResponse, error)
+	grpc.ClientStream
+}
+
+type priceServicePublishPricesClient struct {
+	grpc.ClientStream
+}
+
+func (x *priceServicePublishPricesClient) Send(m *PriceUpdateRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *priceServicePublishPricesClient) CloseAndRecv() (*PublishPricesResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(PublishPricesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *priceServiceClient) SubscribeBestPrices(ctx context.Context, in *SubscribeBestPricesRequest, opts ...grpc.CallOption) (PriceService_SubscribeBestPricesClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PriceService_ServiceDesc.Streams[1], PriceService_SubscribeBestPrices_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &priceServiceSubscribeBestPricesClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PriceService_SubscribeBestPricesClient interface {
+	Recv() (*BestPriceMessage, error)
+	grpc.ClientStream
+}
+
+type priceServiceSubscribeBestPricesClient struct {
+	grpc.ClientStream
+}
+
+func (x *priceServiceSubscribeBestPricesClient) Recv() (*BestPriceMessage, error) {
+	m := new(BestPriceMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// PriceServiceServer is the server API for PriceService service.
+// All implementations must embed UnimplementedPriceServiceServer
+// for forward compatibility
+//
+// PriceService is the gRPC transport for the PriceAPI, it applies the same validation
+// and best price selection as the HTTP endpoints.
+type PriceServiceServer interface {
+	// Publish a single price update, rejected updates return an InvalidArgument error
+	PublishPrice(context.Context, *PriceUpdateRequest) (*PriceUpdateResult, error)
+	// Publish a stream of price updates over a long lived connection, each update is
+	// applied as it arrives and the rejections are returned when the stream is closed
+	PublishPrices(PriceService_PublishPricesServer) error
+	// Receive a snapshot of the current best prices followed by every change
+	SubscribeBestPrices(*SubscribeBestPricesRequest, PriceService_SubscribeBestPricesServer) error
+	mustEmbedUnimplementedPriceServiceServer()
+}
+
+// UnimplementedPriceServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedPriceServiceServer struct {
+}
+
+func (UnimplementedPriceServiceServer) PublishPrice(context.Context, *PriceUpdateRequest) (*PriceUpdateResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PublishPrice not implemented")
+}
+func (UnimplementedPriceServiceServer) PublishPrices(PriceService_PublishPricesServer) error {
+	return status.Errorf(codes.Unimplemented, "method PublishPrices not implemented")
+}
+func (UnimplementedPriceServiceServer) SubscribeBestPrices(*SubscribeBestPricesRequest, PriceService_SubscribeBestPricesServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeBestPrices not implemented")
+}
+func (UnimplementedPriceServiceServer) mustEmbedUnimplementedPriceServiceServer() {}
+
+// UnsafePriceServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PriceServiceServer will
+// result in compilation errors.
+type UnsafePriceServiceServer interface {
+	mustEmbedUnimplementedPriceServiceServer()
+}
+
+func RegisterPriceServiceServer(s grpc.ServiceRegistrar, srv PriceServiceServer) {
+	s.RegisterService(&PriceService_ServiceDesc, srv)
+}
+
+func _PriceService_PublishPrice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PriceUpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PriceServiceServer).PublishPrice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PriceService_PublishPrice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PriceServiceServer).PublishPrice(ctx, req.(*PriceUpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PriceService_PublishPrices_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PriceServiceServer).PublishPrices(&priceServicePublishPricesServer{ServerStream: stream})
+}
+
+type PriceService_PublishPricesServer interface {
+	SendAndClose(*PublishPricesResponse) error
+	Recv() (*PriceUpdateRequest, error)
+	grpc.ServerStream
+}
+
+type priceServicePublishPricesServer struct {
+	grpc.ServerStream
+}
+
+func (x *priceServicePublishPricesServer) SendAndClose(m *PublishPricesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *priceServicePublishPricesServer) Recv() (*PriceUpdateRequest, error) {
+	m := new(PriceUpdateRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _PriceService_SubscribeBestPrices_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeBestPricesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PriceServiceServer).SubscribeBestPrices(m, &priceServiceSubscribeBestPricesServer{ServerStream: stream})
+}
+
+type PriceService_SubscribeBestPricesServer interface {
+	Send(*BestPriceMessage) error
+	grpc.ServerStream
+}
+
+type priceServiceSubscribeBestPricesServer struct {
+	grpc.ServerStream
+}
+
+func (x *priceServiceSubscribeBestPricesServer) Send(m *BestPriceMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// PriceService_ServiceDesc is the grpc.ServiceDesc for PriceService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PriceService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chaostheory.price.PriceService",
+	HandlerType: (*PriceServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PublishPrice",
+			Handler:    _PriceService_PublishPrice_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "PublishPrices",
+			Handler:       _PriceService_PublishPrices_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "SubscribeBestPrices",
+			Handler:       _PriceService_SubscribeBestPrices_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "priceService.proto",
+}
@@ -0,0 +1,91 @@
+package ProviderConfig
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AutoDisabledPair is a provider's pair that was disabled automatically, e.g. by the
+// PriceAPI's health scoring, rather than through the ProviderConfigAPI.
+type AutoDisabledPair struct {
+	Provider   string    `json:"provider"`
+	Pair       string    `json:"pair"`
+	Reason     string    `json:"reason"`
+	DisabledAt time.Time `json:"disabled_at"`
+}
+
+const createAutoDisabledPairsTable = `CREATE TABLE IF NOT EXISTS auto_disabled_pairs (
+		provider TEXT NOT NULL,
+		pair TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		disabled_at INTEGER NOT NULL,
+		PRIMARY KEY (provider, pair)
+	);`
+
+// AutoDisablePair disables a provider's pair and records why. Only automatically
+// disabled pairs are automatically re-enabled.
+func AutoDisablePair(providerName string, pairName string, reason string) error {
+	defer observeQuery("auto_disable_pair", time.Now())
+	// Disabled and recorded together, a pair disabled without its record would never be re-enabled
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	provider, err := setPairsEnabled(tx, providerName, map[string]bool{pairName: false})
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("REPLACE INTO auto_disabled_pairs (provider, pair, reason, disabled_at) VALUES (?, ?, ?, ?)",
+		providerName, pairName, reason, time.Now().UnixMilli())
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	notifyProviderChange(provider)
+	return nil
+}
+
+// AutoReEnablePair enables a pair that was disabled automatically.
+func AutoReEnablePair(providerName string, pairName string) error {
+	return SetPairEnabled(providerName, pairName, true)
+}
+
+// GetAutoDisabledPairs returns every automatically disabled pair keyed by provider then pair.
+func GetAutoDisabledPairs() (map[string]map[string]*AutoDisabledPair, error) {
+	defer observeQuery("get_auto_disabled_pairs", time.Now())
+	disabled := make(map[string]map[string]*AutoDisabledPair)
+
+	rows, err := db.Query("SELECT provider, pair, reason, disabled_at FROM auto_disabled_pairs")
+	if err != nil {
+		return disabled, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		pair := &AutoDisabledPair{}
+		var disabledAt int64
+		if err := rows.Scan(&pair.Provider, &pair.Pair, &pair.Reason, &disabledAt); err != nil {
+			return disabled, err
+		}
+		pair.DisabledAt = time.UnixMilli(disabledAt).UTC()
+		if disabled[pair.Provider] == nil {
+			disabled[pair.Provider] = make(map[string]*AutoDisabledPair)
+		}
+		disabled[pair.Provider][pair.Pair] = pair
+	}
+	return disabled, rows.Err()
+}
+
+// clearAutoDisabledPairs forgets why pairs were disabled once they are changed again,
+// a manual change always wins over an automatic one.
+func clearAutoDisabledPairs(tx *sql.Tx, providerName string, pairNames ...string) error {
+	for _, pairName := range pairNames {
+		if _, err := tx.Exec("DELETE FROM auto_disabled_pairs WHERE provider = ? AND pair = ?", providerName, pairName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
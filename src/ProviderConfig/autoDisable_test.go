@@ -0,0 +1,108 @@
+package ProviderConfig
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+)
+
+func TestAutoDisablePair(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestAutoDisablePair")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	err := OpenDB(tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	SetPairsEnabled("ProviderA", map[string]bool{"BTC/USD": true, "ETH/USD": true})
+	if err := AutoDisablePair("ProviderA", "BTC/USD", "health score 10"); err != nil {
+		t.Fatalf("Error disabling pair: %v", err)
+	}
+	if enabled, _ := GetProviderPairEnabled("ProviderA", "BTC/USD"); enabled {
+		t.Errorf("Expected BTC/USD to be disabled")
+	}
+	disabled, err := GetAutoDisabledPairs()
+	if err != nil || disabled["ProviderA"]["BTC/USD"] == nil || disabled["ProviderA"]["BTC/USD"].Reason != "health score 10" {
+		t.Fatalf("Expected the reason to be recorded; got %v, %v", disabled, err)
+	}
+
+	if err := AutoReEnablePair("ProviderA", "BTC/USD"); err != nil {
+		t.Fatalf("Error re-enabling pair: %v", err)
+	}
+	if enabled, _ := GetProviderPairEnabled("ProviderA", "BTC/USD"); !enabled {
+		t.Errorf("Expected BTC/USD to be enabled")
+	}
+	if disabled, _ := GetAutoDisabledPairs(); len(disabled) != 0 {
+		t.Errorf("Expected the reason to be cleared; got %v", disabled)
+	}
+
+	// Changing a pair by hand clears its reason so it isn't re-enabled automatically
+	AutoDisablePair("ProviderA", "ETH/USD", "health score 20")
+	SetPairsEnabled("ProviderA", map[string]bool{"ETH/USD": false})
+	if disabled, _ := GetAutoDisabledPairs(); len(disabled) != 0 {
+		t.Errorf("Expected a manual change to clear the reason; got %v", disabled)
+	}
+}
+
+func TestAutoDisablePairConcurrentChanges(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestAutoDisablePairConcurrentChanges")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	err := OpenDB(tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	// Pairs changed by hand while another is disabled automatically are all kept
+	SetPairsEnabled("ProviderA", map[string]bool{"BTC/USD": true})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := SetPairsEnabled("ProviderA", map[string]bool{fmt.Sprintf("P%d/USD", i): true}); err != nil {
+				t.Errorf("Error enabling pair: %v", err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			if err := AutoDisablePair("ProviderA", "BTC/USD", "health score 10"); err != nil {
+				t.Errorf("Error disabling pair: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	provider, err := GetProvider("ProviderA")
+	if err != nil {
+		t.Fatalf("Error getting provider: %v", err)
+	}
+	if len(provider.Pairs) != 11 || provider.Pairs["BTC/USD"] {
+		t.Errorf("Expected 10 enabled pairs and BTC/USD disabled; got %v", provider.Pairs)
+	}
+	if disabled, _ := GetAutoDisabledPairs(); disabled["ProviderA"]["BTC/USD"] == nil {
+		t.Errorf("Expected BTC/USD's reason to be recorded; got %v", disabled)
+	}
+
+	// A provider without a row yet is added
+	if err := AutoDisablePair("ProviderB", "ETH/USD", "health score 5"); err != nil {
+		t.Fatalf("Error disabling pair: %v", err)
+	}
+	if provider, _ := GetProvider("ProviderB"); provider == nil || provider.Pairs["ETH/USD"] {
+		t.Errorf("Expected ProviderB with ETH/USD disabled; got %v", provider)
+	}
+}
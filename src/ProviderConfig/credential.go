@@ -0,0 +1,87 @@
+package ProviderConfig
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Credential lets a provider authenticate its price updates. The API key sent in headers
+// is "<key_id>.<secret>", the secret is also the HMAC key for signed requests so it is
+// only returned when the credential is created.
+type Credential struct {
+	KeyID     string     `json:"key_id"`
+	Provider  string     `json:"provider"`
+	Secret    string     `json:"secret,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// APIKey returns the key to send in the X-API-Key header.
+func (credential *Credential) APIKey() string {
+	return credential.KeyID + "." + credential.Secret
+}
+
+// IsExpired reports whether the credential can no longer be used.
+func (credential *Credential) IsExpired(now time.Time) bool {
+	return credential.ExpiresAt != nil && !now.Before(*credential.ExpiresAt)
+}
+
+// VerifySecret compares a secret in constant time.
+func (credential *Credential) VerifySecret(secret string) bool {
+	return hmac.Equal([]byte(credential.Secret), []byte(secret))
+}
+
+// SignatureMessage is what gets signed: the timestamp header, a newline, then the body.
+func SignatureMessage(timestamp string, body []byte) []byte {
+	message := make([]byte, 0, len(timestamp)+1+len(body))
+	message = append(message, timestamp...)
+	message = append(message, '\n')
+	return append(message, body...)
+}
+
+// SignRequest returns the hex HMAC-SHA256 signature of a request with the given secret.
+func SignRequest(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(SignatureMessage(timestamp, body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseAPIKey splits an API key into its key ID and secret.
+func ParseAPIKey(apiKey string) (string, string, error) {
+	keyID, secret, ok := strings.Cut(apiKey, ".")
+	if !ok || keyID == "" || secret == "" {
+		return "", "", fmt.Errorf("API key must be <key_id>.<secret>")
+	}
+	return keyID, secret, nil
+}
+
+// NewCredential generates a random key ID and secret for a provider.
+func NewCredential(providerName string) (*Credential, error) {
+	keyID, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	return &Credential{
+		KeyID:     keyID,
+		Provider:  providerName,
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+func randomHex(size int) (string, error) {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
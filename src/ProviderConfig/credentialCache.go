@@ -0,0 +1,62 @@
+package ProviderConfig
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	credentialCache   *dataVersionCache[map[string]*Credential]
+	credentialCacheMu sync.RWMutex
+)
+
+// StartCredentialCache loads every credential into memory and checks for changes made by
+// other processes every interval. While it is running GetCredential is served from memory.
+func StartCredentialCache(interval time.Duration) error {
+	cache, err := startDataVersionCache("credential", interval, getAllCredentials)
+	if err != nil {
+		return err
+	}
+	StopCredentialCache()
+	credentialCacheMu.Lock()
+	credentialCache = cache
+	credentialCacheMu.Unlock()
+	return nil
+}
+
+// StopCredentialCache stops the cache, lookups go back to the database.
+func StopCredentialCache() {
+	credentialCacheMu.Lock()
+	cache := credentialCache
+	credentialCache = nil
+	credentialCacheMu.Unlock()
+	if cache != nil {
+		cache.stop()
+	}
+}
+
+// RefreshCredentialCache reloads the cache straight away if another process has
+// changed the database. It does nothing if the cache isn't running.
+func RefreshCredentialCache() error {
+	cache := getCredentialCache()
+	if cache == nil {
+		return nil
+	}
+	return cache.refreshIfChanged()
+}
+
+func getCredentialCache() *dataVersionCache[map[string]*Credential] {
+	credentialCacheMu.RLock()
+	defer credentialCacheMu.RUnlock()
+	return credentialCache
+}
+
+// reloadCredentialCache picks up a credential written by this process.
+func reloadCredentialCache() {
+	if cache := getCredentialCache(); cache != nil {
+		if err := cache.reload(); err != nil {
+			fmt.Println("Error reloading credential cache:", err)
+		}
+	}
+}
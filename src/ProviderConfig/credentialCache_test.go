@@ -0,0 +1,88 @@
+package ProviderConfig
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/Metrics"
+)
+
+func TestCredentialCache(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestCredentialCache")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	err := OpenDB(tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	existing, err := CreateCredential("ProviderA")
+	if err != nil {
+		t.Fatalf("Error creating credential: %v", err)
+	}
+
+	// Use a long interval so only explicit refreshes pick up outside changes
+	if err := StartCredentialCache(time.Hour); err != nil {
+		t.Fatalf("Error starting credential cache: %v", err)
+	}
+	gets := Metrics.HistogramCount(queryDuration, "get_credential")
+	if credential, _ := GetCredential(existing.KeyID); credential == nil || credential.Secret != existing.Secret {
+		t.Errorf("Expected cached credential %s; got %v", existing.KeyID, credential)
+	}
+	if credential, _ := GetCredential("missing"); credential != nil {
+		t.Errorf("Expected unknown key to be nil; got %v", credential)
+	}
+	if Metrics.HistogramCount(queryDuration, "get_credential") != gets {
+		t.Errorf("Expected lookups to be served from memory")
+	}
+
+	// In process changes are applied straight away
+	created, _ := CreateCredential("ProviderA")
+	if credential, _ := GetCredential(created.KeyID); credential == nil {
+		t.Errorf("Expected in process create to add %s", created.KeyID)
+	}
+	rotated, _ := RotateCredentials("ProviderA", time.Minute)
+	if credential, _ := GetCredential(created.KeyID); credential == nil || credential.ExpiresAt == nil {
+		t.Errorf("Expected in process rotation to expire %s; got %v", created.KeyID, credential)
+	}
+	DeleteCredential("ProviderA", rotated.KeyID)
+	if credential, _ := GetCredential(rotated.KeyID); credential != nil {
+		t.Errorf("Expected in process delete to remove %s; got %v", rotated.KeyID, credential)
+	}
+
+	// Changes from another process are picked up through data_version
+	otherDB, err := sql.Open("sqlite3", tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening second connection: %v", err)
+	}
+	defer otherDB.Close()
+	if _, err := otherDB.Exec("DELETE FROM credentials WHERE key_id = ?", existing.KeyID); err != nil {
+		t.Fatalf("Error writing from second connection: %v", err)
+	}
+	if credential, _ := GetCredential(existing.KeyID); credential == nil {
+		t.Errorf("Expected cache to not see outside change before refresh")
+	}
+	if err := RefreshCredentialCache(); err != nil {
+		t.Fatalf("Error refreshing cache: %v", err)
+	}
+	if credential, _ := GetCredential(existing.KeyID); credential != nil {
+		t.Errorf("Expected cache to see outside delete after refresh")
+	}
+
+	// Without the cache lookups go back to the database
+	StopCredentialCache()
+	if credential, _ := GetCredential(created.KeyID); credential == nil {
+		t.Errorf("Expected database lookup once the cache is stopped")
+	}
+	if Metrics.HistogramCount(queryDuration, "get_credential") != gets+1 {
+		t.Errorf("Expected lookup to read the database once the cache is stopped")
+	}
+}
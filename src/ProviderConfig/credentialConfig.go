@@ -0,0 +1,160 @@
+package ProviderConfig
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Secrets are kept in plain text as they are needed to verify HMAC signatures
+const createCredentialsTable = `CREATE TABLE IF NOT EXISTS credentials (
+		key_id TEXT PRIMARY KEY,
+		provider TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER
+	);`
+
+const selectCredentials = "SELECT key_id, provider, secret, created_at, expires_at FROM credentials"
+
+// CreateCredential generates and stores a new credential for a provider. Existing
+// credentials keep working.
+func CreateCredential(providerName string) (*Credential, error) {
+	defer observeQuery("create_credential", time.Now())
+	credential, err := NewCredential(providerName)
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.Exec("INSERT INTO credentials (key_id, provider, secret, created_at) VALUES (?, ?, ?, ?)",
+		credential.KeyID, credential.Provider, credential.Secret, credential.CreatedAt.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	reloadCredentialCache()
+	return credential, nil
+}
+
+// RotateCredentials creates a new credential for a provider and expires its existing
+// credentials after the grace period, so it has time to switch over.
+func RotateCredentials(providerName string, grace time.Duration) (*Credential, error) {
+	defer observeQuery("rotate_credentials", time.Now())
+	credential, err := NewCredential(providerName)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := credential.CreatedAt.Add(grace).UnixMilli()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	// Credentials already due to expire sooner keep their expiry
+	_, err = tx.Exec("UPDATE credentials SET expires_at = ? WHERE provider = ? AND (expires_at IS NULL OR expires_at > ?)",
+		expiresAt, providerName, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec("INSERT INTO credentials (key_id, provider, secret, created_at) VALUES (?, ?, ?, ?)",
+		credential.KeyID, credential.Provider, credential.Secret, credential.CreatedAt.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	reloadCredentialCache()
+	return credential, nil
+}
+
+// DeleteCredential revokes a provider's credential, returning false if it didn't exist.
+func DeleteCredential(providerName string, keyID string) (bool, error) {
+	defer observeQuery("delete_credential", time.Now())
+	result, err := db.Exec("DELETE FROM credentials WHERE provider = ? AND key_id = ?", providerName, keyID)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := result.RowsAffected()
+	if deleted > 0 {
+		reloadCredentialCache()
+	}
+	return deleted > 0, err
+}
+
+// GetCredential returns a credential including its secret, or nil if it doesn't exist.
+// If the credential cache is running the lookup is served from memory.
+func GetCredential(keyID string) (*Credential, error) {
+	if cache := getCredentialCache(); cache != nil {
+		if credential := cache.get()[keyID]; credential != nil {
+			// Copied so callers can't change the cached credential
+			copied := *credential
+			return &copied, nil
+		}
+		return nil, nil
+	}
+
+	defer observeQuery("get_credential", time.Now())
+	credential, err := scanCredential(db.QueryRow(selectCredentials+" WHERE key_id = ?", keyID))
+	if err == sql.ErrNoRows {
+		// This is not an error, just no credential
+		return nil, nil
+	}
+	return credential, err
+}
+
+// GetCredentials returns a provider's credentials without their secrets, oldest first.
+func GetCredentials(providerName string) ([]*Credential, error) {
+	defer observeQuery("get_credentials", time.Now())
+	credentials := make([]*Credential, 0)
+
+	rows, err := db.Query(selectCredentials+" WHERE provider = ? ORDER BY created_at", providerName)
+	if err != nil {
+		return credentials, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		credential, err := scanCredential(rows)
+		if err != nil {
+			return credentials, err
+		}
+		credential.Secret = ""
+		credentials = append(credentials, credential)
+	}
+	return credentials, rows.Err()
+}
+
+// getAllCredentials returns every credential including its secret keyed by key ID.
+func getAllCredentials() (map[string]*Credential, error) {
+	defer observeQuery("get_all_credentials", time.Now())
+	credentials := make(map[string]*Credential)
+
+	rows, err := db.Query(selectCredentials)
+	if err != nil {
+		return credentials, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		credential, err := scanCredential(rows)
+		if err != nil {
+			return credentials, err
+		}
+		credentials[credential.KeyID] = credential
+	}
+	return credentials, rows.Err()
+}
+
+func scanCredential(row rowScanner) (*Credential, error) {
+	credential := &Credential{}
+	var createdAt int64
+	var expiresAt sql.NullInt64
+	if err := row.Scan(&credential.KeyID, &credential.Provider, &credential.Secret, &createdAt, &expiresAt); err != nil {
+		return nil, err
+	}
+	credential.CreatedAt = time.UnixMilli(createdAt).UTC()
+	if expiresAt.Valid {
+		expires := time.UnixMilli(expiresAt.Int64).UTC()
+		credential.ExpiresAt = &expires
+	}
+	return credential, nil
+}
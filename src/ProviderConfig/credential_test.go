@@ -0,0 +1,125 @@
+package ProviderConfig
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+)
+
+func TestCredentialSigning(t *testing.T) {
+	credential, err := NewCredential("ProviderA")
+	if err != nil {
+		t.Fatalf("Error creating credential: %v", err)
+	}
+	keyID, secret, err := ParseAPIKey(credential.APIKey())
+	if err != nil || keyID != credential.KeyID || secret != credential.Secret {
+		t.Errorf("Expected API key to split into %s and %s; got %s, %s, %v", credential.KeyID, credential.Secret, keyID, secret, err)
+	}
+	for _, apiKey := range []string{"", "nodot", ".secret", "keyid."} {
+		if _, _, err := ParseAPIKey(apiKey); err == nil {
+			t.Errorf("Expected API key %q to be invalid", apiKey)
+		}
+	}
+	if !credential.VerifySecret(secret) || credential.VerifySecret(secret+"0") {
+		t.Errorf("Expected only the credential's own secret to verify")
+	}
+
+	// Known HMAC-SHA256 of "1700000000000\n{}" with the key "secret"
+	signature := SignRequest("secret", "1700000000000", []byte("{}"))
+	if signature != "439cedf0a20e6f0c3127d3afb02f2891deef3ca9b525cd80a5416fe041c04099" {
+		t.Errorf("Unexpected signature %s", signature)
+	}
+	if signature == SignRequest("secret", "1700000000001", []byte("{}")) {
+		t.Errorf("Expected the timestamp to change the signature")
+	}
+	if signature == SignRequest("other", "1700000000000", []byte("{}")) {
+		t.Errorf("Expected the secret to change the signature")
+	}
+
+	now := time.Now()
+	if credential.IsExpired(now) {
+		t.Errorf("Expected a credential without an expiry not to expire")
+	}
+	credential.ExpiresAt = &now
+	if !credential.IsExpired(now) {
+		t.Errorf("Expected a credential to expire at its expiry time")
+	}
+}
+
+func TestCredentialConfig(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestCredentialConfig")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	err := OpenDB(tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	first, err := CreateCredential("ProviderA")
+	if err != nil {
+		t.Fatalf("Error creating credential: %v", err)
+	}
+	stored, err := GetCredential(first.KeyID)
+	if err != nil || stored == nil || stored.Secret != first.Secret || stored.Provider != "ProviderA" || stored.ExpiresAt != nil {
+		t.Fatalf("Expected credential to be stored; got %+v, %v", stored, err)
+	}
+	missing, err := GetCredential("missing")
+	if err != nil || missing != nil {
+		t.Errorf("Expected no credential for an unknown key; got %v, %v", missing, err)
+	}
+
+	// Rotating keeps the old credential working for the grace period
+	second, err := RotateCredentials("ProviderA", time.Minute)
+	if err != nil {
+		t.Fatalf("Error rotating credentials: %v", err)
+	}
+	stored, _ = GetCredential(first.KeyID)
+	if stored.ExpiresAt == nil || stored.IsExpired(time.Now()) || !stored.IsExpired(time.Now().Add(2*time.Minute)) {
+		t.Errorf("Expected the old credential to expire in a minute; got %v", stored.ExpiresAt)
+	}
+	firstExpiry := *stored.ExpiresAt
+
+	// A longer grace period doesn't extend credentials that already expire sooner
+	if _, err := RotateCredentials("ProviderA", time.Hour); err != nil {
+		t.Fatalf("Error rotating credentials: %v", err)
+	}
+	stored, _ = GetCredential(first.KeyID)
+	if !stored.ExpiresAt.Equal(firstExpiry) {
+		t.Errorf("Expected the old credential to keep its expiry %v; got %v", firstExpiry, stored.ExpiresAt)
+	}
+	stored, _ = GetCredential(second.KeyID)
+	if stored.ExpiresAt == nil || stored.IsExpired(time.Now().Add(time.Minute)) {
+		t.Errorf("Expected the second credential to expire in an hour; got %v", stored.ExpiresAt)
+	}
+
+	credentials, err := GetCredentials("ProviderA")
+	if err != nil || len(credentials) != 3 {
+		t.Fatalf("Expected 3 credentials; got %v, %v", credentials, err)
+	}
+	for _, credential := range credentials {
+		if credential.Secret != "" {
+			t.Errorf("Expected secrets not to be listed")
+		}
+	}
+	if credentials, _ := GetCredentials("ProviderB"); len(credentials) != 0 {
+		t.Errorf("Expected ProviderB to have no credentials; got %v", credentials)
+	}
+
+	// Credentials can only be deleted by their own provider
+	if deleted, _ := DeleteCredential("ProviderB", first.KeyID); deleted {
+		t.Errorf("Expected another provider's credential not to be deleted")
+	}
+	if deleted, _ := DeleteCredential("ProviderA", first.KeyID); !deleted {
+		t.Errorf("Expected credential to be deleted")
+	}
+	if stored, _ := GetCredential(first.KeyID); stored != nil {
+		t.Errorf("Expected deleted credential to be gone; got %v", stored)
+	}
+}
@@ -0,0 +1,151 @@
+package ProviderConfig
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// dataVersionCache holds a value loaded from the database in memory. Writes made in
+// this process reload it straight away, changes made by other processes (such as the
+// ProviderConfigAPI) are picked up by watching SQLite's data_version.
+type dataVersionCache[T any] struct {
+	// Used in errors
+	name  string
+	load  func() (T, error)
+	value T
+	// Dedicated connection as data_version only changes for commits made on other connections
+	conn        *sql.Conn
+	dataVersion int64
+	lastRefresh time.Time
+	refreshes   atomic.Uint64
+	// Called with the new value after every reload
+	onReload func(value T)
+	mu       sync.RWMutex
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// startDataVersionCache loads the value and checks for outside changes every interval
+// until the cache is stopped.
+func startDataVersionCache[T any](name string, interval time.Duration, load func() (T, error)) (*dataVersionCache[T], error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is not open")
+	}
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &dataVersionCache[T]{
+		name: name,
+		load: load,
+		conn: conn,
+		done: make(chan struct{}),
+	}
+	if err := cache.reload(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go cache.watch(interval)
+	return cache, nil
+}
+
+func (cache *dataVersionCache[T]) get() T {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	return cache.value
+}
+
+func (cache *dataVersionCache[T]) getDataVersion() (int64, error) {
+	defer observeQuery("data_version", time.Now())
+	var dataVersion int64
+	err := cache.conn.QueryRowContext(context.Background(), "PRAGMA data_version").Scan(&dataVersion)
+	return dataVersion, err
+}
+
+// reload replaces the cached value with a fresh load from the database.
+func (cache *dataVersionCache[T]) reload() error {
+	// Read the version first so a change during the load triggers another reload
+	dataVersion, err := cache.getDataVersion()
+	if err != nil {
+		return err
+	}
+	value, err := cache.load()
+	if err != nil {
+		return err
+	}
+	cache.mu.Lock()
+	cache.value = value
+	cache.dataVersion = dataVersion
+	cache.lastRefresh = time.Now()
+	cache.refreshes.Add(1)
+	onReload := cache.onReload
+	cache.mu.Unlock()
+	if onReload != nil {
+		onReload(value)
+	}
+	return nil
+}
+
+// setOnReload sets a function to call with the new value after every reload, whether
+// it was made by this process or picked up from another.
+func (cache *dataVersionCache[T]) setOnReload(onReload func(value T)) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.onReload = onReload
+}
+
+// update replaces the cached value with a change made by this process. The value
+// passed in must not be modified as readers may still hold it.
+func (cache *dataVersionCache[T]) update(change func(value T) T) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.value = change(cache.value)
+}
+
+// refreshStats returns the data_version last loaded, when and how many loads there have been.
+func (cache *dataVersionCache[T]) refreshStats() (int64, time.Time, uint64) {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	return cache.dataVersion, cache.lastRefresh, cache.refreshes.Load()
+}
+
+func (cache *dataVersionCache[T]) refreshIfChanged() error {
+	dataVersion, err := cache.getDataVersion()
+	if err != nil {
+		return err
+	}
+	cache.mu.RLock()
+	changed := dataVersion != cache.dataVersion
+	cache.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	return cache.reload()
+}
+
+func (cache *dataVersionCache[T]) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := cache.refreshIfChanged(); err != nil {
+				fmt.Printf("Error refreshing %s cache: %v\n", cache.name, err)
+			}
+		case <-cache.done:
+			return
+		}
+	}
+}
+
+func (cache *dataVersionCache[T]) stop() {
+	cache.stopOnce.Do(func() {
+		close(cache.done)
+		cache.conn.Close()
+	})
+}
@@ -0,0 +1,186 @@
+package ProviderConfig
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ProviderChangeListener is called after a provider is written to the database.
+type ProviderChangeListener func(provider *Provider)
+
+// EligibilityCacheStats are the counters for the eligibility cache.
+type EligibilityCacheStats struct {
+	Providers   int       `json:"providers"`
+	Hits        uint64    `json:"hits"`
+	Misses      uint64    `json:"misses"`
+	Refreshes   uint64    `json:"refreshes"`
+	Changes     uint64    `json:"changes"`
+	DataVersion int64     `json:"data_version"`
+	LastRefresh time.Time `json:"last_refresh"`
+}
+
+// EligibilityCache holds every provider's enabled pairs in memory. In process changes
+// arrive through SetProvider notifications, changes made by other processes (such as
+// the ProviderConfigAPI) are picked up by watching SQLite's data_version.
+type EligibilityCache struct {
+	// Enabled pairs by provider, replaced rather than changed so readers don't need a lock
+	providers   *dataVersionCache[map[string]map[string]bool]
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	changes     atomic.Uint64
+	unsubscribe func()
+}
+
+var (
+	changeListeners  = make(map[int]ProviderChangeListener)
+	nextListenerID   int
+	listenersMu      sync.RWMutex
+	eligibilityCache *EligibilityCache
+	cacheMu          sync.RWMutex
+)
+
+// SubscribeProviderChanges registers a listener for provider writes and returns a function to unsubscribe.
+func SubscribeProviderChanges(listener ProviderChangeListener) func() {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	id := nextListenerID
+	nextListenerID++
+	changeListeners[id] = listener
+	return func() {
+		listenersMu.Lock()
+		defer listenersMu.Unlock()
+		delete(changeListeners, id)
+	}
+}
+
+func notifyProviderChange(provider *Provider) {
+	listenersMu.RLock()
+	defer listenersMu.RUnlock()
+	for _, listener := range changeListeners {
+		listener(provider)
+	}
+}
+
+// StartEligibilityCache loads every provider into memory and checks for changes made by
+// other processes every interval. While it is running GetProviderPairEnabled is served
+// from memory.
+func StartEligibilityCache(interval time.Duration) error {
+	providers, err := startDataVersionCache("eligibility", interval, loadEnabledPairs)
+	if err != nil {
+		return err
+	}
+	cache := &EligibilityCache{providers: providers}
+	cache.unsubscribe = SubscribeProviderChanges(cache.applyChange)
+
+	StopEligibilityCache()
+	cacheMu.Lock()
+	eligibilityCache = cache
+	cacheMu.Unlock()
+	return nil
+}
+
+// StopEligibilityCache stops the cache, lookups go back to the database.
+func StopEligibilityCache() {
+	cacheMu.Lock()
+	cache := eligibilityCache
+	eligibilityCache = nil
+	cacheMu.Unlock()
+	if cache != nil {
+		cache.stop()
+	}
+}
+
+// RefreshEligibilityCache reloads the cache straight away if another process has
+// changed the database. It does nothing if the cache isn't running.
+func RefreshEligibilityCache() error {
+	cache := getEligibilityCache()
+	if cache == nil {
+		return nil
+	}
+	return cache.refreshIfChanged()
+}
+
+// GetEligibilityCacheStats returns the cache counters, or nil if the cache isn't running.
+func GetEligibilityCacheStats() *EligibilityCacheStats {
+	cache := getEligibilityCache()
+	if cache == nil {
+		return nil
+	}
+	return cache.stats()
+}
+
+func getEligibilityCache() *EligibilityCache {
+	cacheMu.RLock()
+	defer cacheMu.RUnlock()
+	return eligibilityCache
+}
+
+// isPairEnabled answers an eligibility lookup from memory.
+func (cache *EligibilityCache) isPairEnabled(providerName string, pairName string) bool {
+	pairs, ok := cache.providers.get()[providerName]
+	if !ok {
+		cache.misses.Add(1)
+		return false
+	}
+	cache.hits.Add(1)
+	return pairs[pairName]
+}
+
+// hasProvider returns true if the provider is in the cache, it doesn't count as a lookup.
+func (cache *EligibilityCache) hasProvider(providerName string) bool {
+	_, ok := cache.providers.get()[providerName]
+	return ok
+}
+
+func (cache *EligibilityCache) refreshIfChanged() error {
+	return cache.providers.refreshIfChanged()
+}
+
+// loadEnabledPairs returns every provider's pairs keyed by provider name.
+func loadEnabledPairs() (map[string]map[string]bool, error) {
+	providers, err := GetProviders()
+	if err != nil {
+		return nil, err
+	}
+	pairsByProvider := make(map[string]map[string]bool, len(providers))
+	for providerName, provider := range providers {
+		pairsByProvider[providerName] = provider.Pairs
+	}
+	return pairsByProvider, nil
+}
+
+// applyChange updates a single provider after an in process write.
+func (cache *EligibilityCache) applyChange(provider *Provider) {
+	pairs := make(map[string]bool, len(provider.Pairs))
+	for pairName, enabled := range provider.Pairs {
+		pairs[pairName] = enabled
+	}
+	cache.providers.update(func(current map[string]map[string]bool) map[string]map[string]bool {
+		updated := make(map[string]map[string]bool, len(current)+1)
+		for providerName, providerPairs := range current {
+			updated[providerName] = providerPairs
+		}
+		updated[provider.Name] = pairs
+		return updated
+	})
+	cache.changes.Add(1)
+}
+
+func (cache *EligibilityCache) stop() {
+	cache.unsubscribe()
+	cache.providers.stop()
+}
+
+func (cache *EligibilityCache) stats() *EligibilityCacheStats {
+	dataVersion, lastRefresh, refreshes := cache.providers.refreshStats()
+	return &EligibilityCacheStats{
+		Providers:   len(cache.providers.get()),
+		Hits:        cache.hits.Load(),
+		Misses:      cache.misses.Load(),
+		Refreshes:   refreshes,
+		Changes:     cache.changes.Load(),
+		DataVersion: dataVersion,
+		LastRefresh: lastRefresh,
+	}
+}
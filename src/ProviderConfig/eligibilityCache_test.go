@@ -0,0 +1,85 @@
+package ProviderConfig
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+)
+
+func TestEligibilityCache(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestEligibilityCache")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	err := OpenDB(tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	SetPairEnabled("TestProvider", "BTC/USD", true)
+
+	// Use a long interval so only explicit refreshes pick up outside changes
+	if err := StartEligibilityCache(time.Hour); err != nil {
+		t.Fatalf("Error starting eligibility cache: %v", err)
+	}
+
+	enabled, _ := GetProviderPairEnabled("TestProvider", "BTC/USD")
+	if !enabled {
+		t.Errorf("Expected pair to be enabled; got disabled")
+	}
+	enabled, _ = GetProviderPairEnabled("UnknownProvider", "BTC/USD")
+	if enabled {
+		t.Errorf("Expected unknown provider to be disabled; got enabled")
+	}
+
+	stats := GetEligibilityCacheStats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Refreshes != 1 {
+		t.Errorf("Unexpected cache stats %+v", stats)
+	}
+
+	// In process changes are applied straight away
+	SetPairEnabled("TestProvider", "BTC/USD", false)
+	enabled, _ = GetProviderPairEnabled("TestProvider", "BTC/USD")
+	if enabled {
+		t.Errorf("Expected in process change to disable pair; got enabled")
+	}
+
+	// Changes from another process are picked up through data_version
+	otherDB, err := sql.Open("sqlite3", tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening second connection: %v", err)
+	}
+	defer otherDB.Close()
+	if _, err := otherDB.Exec(`REPLACE INTO providers (name, pairs) VALUES ('OtherProvider', '{"ETH/USD":true}')`); err != nil {
+		t.Fatalf("Error writing from second connection: %v", err)
+	}
+
+	enabled, _ = GetProviderPairEnabled("OtherProvider", "ETH/USD")
+	if enabled {
+		t.Errorf("Expected cache to not see outside change before refresh")
+	}
+	if err := RefreshEligibilityCache(); err != nil {
+		t.Fatalf("Error refreshing cache: %v", err)
+	}
+	enabled, _ = GetProviderPairEnabled("OtherProvider", "ETH/USD")
+	if !enabled {
+		t.Errorf("Expected cache to see outside change after refresh")
+	}
+
+	// Without the cache lookups go back to the database
+	StopEligibilityCache()
+	if GetEligibilityCacheStats() != nil {
+		t.Errorf("Expected no stats once the cache is stopped")
+	}
+	enabled, _ = GetProviderPairEnabled("OtherProvider", "ETH/USD")
+	if !enabled {
+		t.Errorf("Expected pair to be enabled; got disabled")
+	}
+}
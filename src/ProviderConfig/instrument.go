@@ -0,0 +1,82 @@
+package ProviderConfig
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Instrument describes a tradeable currency pair and the prices and amounts allowed for it.
+type Instrument struct {
+	Base  string `json:"base"`
+	Quote string `json:"quote"`
+	// Maximum number of decimal places in a price
+	PricePrecision int32 `json:"price_precision"`
+	// Prices must be a multiple of the tick size, zero allows any price
+	TickSize decimal.Decimal `json:"tick_size"`
+	// Amounts must be a multiple of the lot size, zero allows any amount
+	LotSize   decimal.Decimal `json:"lot_size"`
+	MinAmount decimal.Decimal `json:"min_amount"`
+	// Zero means there is no maximum
+	MaxAmount decimal.Decimal `json:"max_amount"`
+	Active    bool            `json:"active"`
+}
+
+// GetPairName returns the pair name for the instrument e.g. BTC/USD
+func (i *Instrument) GetPairName() string {
+	return fmt.Sprintf("%s/%s", i.Base, i.Quote)
+}
+
+// AmountPrecision returns the number of decimal places in the lot size.
+func (i *Instrument) AmountPrecision() int32 {
+	precision := int32(0)
+	for !i.LotSize.Equal(i.LotSize.Truncate(precision)) {
+		precision++
+	}
+	return precision
+}
+
+// Validate checks the instrument's own settings are consistent.
+func (i *Instrument) Validate() error {
+	if i.Base == "" || i.Quote == "" {
+		return fmt.Errorf("base and quote are required")
+	}
+	if i.PricePrecision < 0 {
+		return fmt.Errorf("price_precision must not be negative")
+	}
+	if i.TickSize.IsNegative() || i.LotSize.IsNegative() || i.MinAmount.IsNegative() || i.MaxAmount.IsNegative() {
+		return fmt.Errorf("tick_size, lot_size, min_amount and max_amount must not be negative")
+	}
+	if !i.TickSize.Equal(i.TickSize.Truncate(i.PricePrecision)) {
+		return fmt.Errorf("tick_size %s has more decimal places than price_precision %d", i.TickSize, i.PricePrecision)
+	}
+	if i.MaxAmount.IsPositive() && i.MinAmount.GreaterThan(i.MaxAmount) {
+		return fmt.Errorf("min_amount %s is greater than max_amount %s", i.MinAmount, i.MaxAmount)
+	}
+	return nil
+}
+
+// ValidatePrice checks a price is within the instrument's precision and on a tick.
+func (i *Instrument) ValidatePrice(price decimal.Decimal) error {
+	if !price.Equal(price.Truncate(i.PricePrecision)) {
+		return fmt.Errorf("price %s for %s has more than %d decimal places", price, i.GetPairName(), i.PricePrecision)
+	}
+	if i.TickSize.IsPositive() && !price.Mod(i.TickSize).IsZero() {
+		return fmt.Errorf("price %s for %s is not a multiple of the tick size %s", price, i.GetPairName(), i.TickSize)
+	}
+	return nil
+}
+
+// ValidateAmount checks an amount is within the instrument's limits and a whole number of lots.
+func (i *Instrument) ValidateAmount(amount decimal.Decimal) error {
+	if amount.LessThan(i.MinAmount) {
+		return fmt.Errorf("amount %s for %s is below the minimum %s", amount, i.GetPairName(), i.MinAmount)
+	}
+	if i.MaxAmount.IsPositive() && amount.GreaterThan(i.MaxAmount) {
+		return fmt.Errorf("amount %s for %s is above the maximum %s", amount, i.GetPairName(), i.MaxAmount)
+	}
+	if i.LotSize.IsPositive() && !amount.Mod(i.LotSize).IsZero() {
+		return fmt.Errorf("amount %s for %s is not a multiple of the lot size %s", amount, i.GetPairName(), i.LotSize)
+	}
+	return nil
+}
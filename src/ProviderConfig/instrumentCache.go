@@ -0,0 +1,72 @@
+package ProviderConfig
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	instrumentCache   *dataVersionCache[map[string]*Instrument]
+	instrumentCacheMu sync.RWMutex
+)
+
+// StartInstrumentCache loads every instrument into memory and checks for changes made by
+// other processes every interval. While it is running GetInstrument is served from memory.
+func StartInstrumentCache(interval time.Duration) error {
+	cache, err := startDataVersionCache("instrument", interval, GetInstruments)
+	if err != nil {
+		return err
+	}
+	StopInstrumentCache()
+	instrumentCacheMu.Lock()
+	instrumentCache = cache
+	instrumentCacheMu.Unlock()
+	return nil
+}
+
+// StopInstrumentCache stops the cache, lookups go back to the database.
+func StopInstrumentCache() {
+	instrumentCacheMu.Lock()
+	cache := instrumentCache
+	instrumentCache = nil
+	instrumentCacheMu.Unlock()
+	if cache != nil {
+		cache.stop()
+	}
+}
+
+// RefreshInstrumentCache reloads the cache straight away if another process has
+// changed the database. It does nothing if the cache isn't running.
+func RefreshInstrumentCache() error {
+	cache := getInstrumentCache()
+	if cache == nil {
+		return nil
+	}
+	return cache.refreshIfChanged()
+}
+
+// GetCachedInstrument returns the instrument for a pair from the cache without ever
+// reading the database, nil if it isn't registered or the cache isn't running.
+func GetCachedInstrument(pairName string) *Instrument {
+	cache := getInstrumentCache()
+	if cache == nil {
+		return nil
+	}
+	return cache.get()[pairName]
+}
+
+func getInstrumentCache() *dataVersionCache[map[string]*Instrument] {
+	instrumentCacheMu.RLock()
+	defer instrumentCacheMu.RUnlock()
+	return instrumentCache
+}
+
+// reloadInstrumentCache picks up an instrument written by this process.
+func reloadInstrumentCache() {
+	if cache := getInstrumentCache(); cache != nil {
+		if err := cache.reload(); err != nil {
+			fmt.Println("Error reloading instrument cache:", err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package ProviderConfig
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/Metrics"
+)
+
+func TestInstrumentCache(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestInstrumentCache")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	err := OpenDB(tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	SetInstrument(NewDefaultInstrument("BTC", "USD"))
+	if GetCachedInstrument("BTC/USD") != nil {
+		t.Errorf("Expected no cached instrument before the cache is started")
+	}
+
+	// Use a long interval so only explicit refreshes pick up outside changes
+	if err := StartInstrumentCache(time.Hour); err != nil {
+		t.Fatalf("Error starting instrument cache: %v", err)
+	}
+	gets := Metrics.HistogramCount(queryDuration, "get_instrument")
+	if instrument, _ := GetInstrument("BTC/USD"); instrument == nil || instrument.PricePrecision != 4 {
+		t.Errorf("Expected BTC/USD with 4 decimal places; got %v", instrument)
+	}
+	if instrument, _ := GetInstrument("ETH/USD"); instrument != nil {
+		t.Errorf("Expected unregistered instrument to be nil; got %v", instrument)
+	}
+	if Metrics.HistogramCount(queryDuration, "get_instrument") != gets {
+		t.Errorf("Expected lookups to be served from memory")
+	}
+
+	// In process changes are applied straight away
+	SetInstrument(NewDefaultInstrument("ETH", "JPY"))
+	if instrument := GetCachedInstrument("ETH/JPY"); instrument == nil || instrument.PricePrecision != 2 {
+		t.Errorf("Expected in process change to add ETH/JPY; got %v", instrument)
+	}
+	DeleteInstrument("ETH/JPY")
+	if instrument := GetCachedInstrument("ETH/JPY"); instrument != nil {
+		t.Errorf("Expected in process delete to remove ETH/JPY; got %v", instrument)
+	}
+
+	// Changes from another process are picked up through data_version
+	otherDB, err := sql.Open("sqlite3", tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening second connection: %v", err)
+	}
+	defer otherDB.Close()
+	if _, err := otherDB.Exec("UPDATE instruments SET price_precision = 6, tick_size = '0.000001' WHERE pair = 'BTC/USD'"); err != nil {
+		t.Fatalf("Error writing from second connection: %v", err)
+	}
+	if instrument, _ := GetInstrument("BTC/USD"); instrument.PricePrecision != 4 {
+		t.Errorf("Expected cache to not see outside change before refresh")
+	}
+	if err := RefreshInstrumentCache(); err != nil {
+		t.Fatalf("Error refreshing cache: %v", err)
+	}
+	if instrument, _ := GetInstrument("BTC/USD"); instrument.PricePrecision != 6 {
+		t.Errorf("Expected cache to see outside change after refresh")
+	}
+
+	// Without the cache lookups go back to the database
+	StopInstrumentCache()
+	if GetCachedInstrument("BTC/USD") != nil {
+		t.Errorf("Expected no cached instrument once the cache is stopped")
+	}
+	if instrument, _ := GetInstrument("BTC/USD"); instrument == nil || instrument.PricePrecision != 6 {
+		t.Errorf("Expected BTC/USD from the database; got %v", instrument)
+	}
+}
@@ -0,0 +1,195 @@
+package ProviderConfig
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const createInstrumentsTable = `CREATE TABLE IF NOT EXISTS instruments (
+		pair TEXT PRIMARY KEY,
+		base TEXT NOT NULL,
+		quote TEXT NOT NULL,
+		price_precision INTEGER NOT NULL,
+		tick_size TEXT NOT NULL,
+		lot_size TEXT NOT NULL,
+		min_amount TEXT NOT NULL,
+		max_amount TEXT NOT NULL,
+		active INTEGER NOT NULL
+	);`
+
+const selectInstruments = "SELECT base, quote, price_precision, tick_size, lot_size, min_amount, max_amount, active FROM instruments"
+
+// SetInstrument adds or replaces an instrument.
+func SetInstrument(instrument *Instrument) error {
+	defer observeQuery("set_instrument", time.Now())
+	if err := instrument.Validate(); err != nil {
+		return err
+	}
+
+	stmt, err := db.Prepare("REPLACE INTO instruments (pair, base, quote, price_precision, tick_size, lot_size, min_amount, max_amount, active) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(instrument.GetPairName(), instrument.Base, instrument.Quote, instrument.PricePrecision,
+		instrument.TickSize.String(), instrument.LotSize.String(), instrument.MinAmount.String(), instrument.MaxAmount.String(), instrument.Active)
+	if err != nil {
+		return err
+	}
+	reloadInstrumentCache()
+	return nil
+}
+
+// DeleteInstrument removes an instrument, returning false if it didn't exist.
+func DeleteInstrument(pairName string) (bool, error) {
+	defer observeQuery("delete_instrument", time.Now())
+	result, err := db.Exec("DELETE FROM instruments WHERE pair = ?", pairName)
+	if err != nil {
+		return false, err
+	}
+	reloadInstrumentCache()
+	deleted, err := result.RowsAffected()
+	return deleted > 0, err
+}
+
+// GetInstrument returns the instrument for a pair, or nil if it isn't registered.
+// If the instrument cache is running the lookup is served from memory.
+func GetInstrument(pairName string) (*Instrument, error) {
+	if cache := getInstrumentCache(); cache != nil {
+		return cache.get()[pairName], nil
+	}
+	defer observeQuery("get_instrument", time.Now())
+	instrument, err := scanInstrument(db.QueryRow(selectInstruments+" WHERE pair = ?", pairName))
+	if err == sql.ErrNoRows {
+		// This is not an error, just no instrument
+		return nil, nil
+	}
+	return instrument, err
+}
+
+// GetInstruments returns every registered instrument keyed by pair name.
+func GetInstruments() (map[string]*Instrument, error) {
+	defer observeQuery("get_instruments", time.Now())
+	instruments := make(map[string]*Instrument)
+
+	rows, err := db.Query(selectInstruments)
+	if err != nil {
+		return instruments, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		instrument, err := scanInstrument(rows)
+		if err != nil {
+			return instruments, err
+		}
+		instruments[instrument.GetPairName()] = instrument
+	}
+	return instruments, rows.Err()
+}
+
+// rowScanner is implemented by both sql.Row and sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanInstrument(row rowScanner) (*Instrument, error) {
+	instrument := &Instrument{}
+	var tickSize, lotSize, minAmount, maxAmount string
+	err := row.Scan(&instrument.Base, &instrument.Quote, &instrument.PricePrecision, &tickSize, &lotSize, &minAmount, &maxAmount, &instrument.Active)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range []struct {
+		value string
+		dest  *decimal.Decimal
+	}{
+		{tickSize, &instrument.TickSize},
+		{lotSize, &instrument.LotSize},
+		{minAmount, &instrument.MinAmount},
+		{maxAmount, &instrument.MaxAmount},
+	} {
+		if *field.dest, err = decimal.NewFromString(field.value); err != nil {
+			return nil, err
+		}
+	}
+	return instrument, nil
+}
+
+// registerDefaultInstruments registers any of the given pairs that aren't already
+// registered, leaving existing instruments untouched.
+func registerDefaultInstruments(pairNames []string) error {
+	for _, pairName := range pairNames {
+		existing, err := GetInstrument(pairName)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			continue
+		}
+		base, quote, _ := strings.Cut(pairName, "/")
+		if err := SetInstrument(NewDefaultInstrument(base, quote)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedDefaultInstruments registers a default instrument for every pair in the providers
+// table. It runs before the database is made global so it is given the connection.
+func seedDefaultInstruments(sqliteDB *sql.DB) error {
+	rows, err := sqliteDB.Query("SELECT DISTINCT pair.key FROM providers, json_each(COALESCE(providers.pairs, '{}')) AS pair")
+	if err != nil {
+		return err
+	}
+	pairNames := make([]string, 0)
+	for rows.Next() {
+		var pairName string
+		if err := rows.Scan(&pairName); err != nil {
+			rows.Close()
+			return err
+		}
+		pairNames = append(pairNames, pairName)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, pairName := range pairNames {
+		base, quote, ok := strings.Cut(pairName, "/")
+		if !ok {
+			continue
+		}
+		instrument := NewDefaultInstrument(base, quote)
+		_, err := sqliteDB.Exec("INSERT OR IGNORE INTO instruments (pair, base, quote, price_precision, tick_size, lot_size, min_amount, max_amount, active) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			instrument.GetPairName(), instrument.Base, instrument.Quote, instrument.PricePrecision,
+			instrument.TickSize.String(), instrument.LotSize.String(), instrument.MinAmount.String(), instrument.MaxAmount.String(), instrument.Active)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewDefaultInstrument returns an active instrument with 4 decimal places (2 for JPY quoted pairs)
+// and no amount limits.
+func NewDefaultInstrument(base string, quote string) *Instrument {
+	instrument := &Instrument{
+		Base:           base,
+		Quote:          quote,
+		PricePrecision: 4,
+		TickSize:       decimal.New(1, -4),
+		LotSize:        decimal.New(1, -8),
+		Active:         true,
+	}
+	if quote == "JPY" {
+		instrument.PricePrecision = 2
+		instrument.TickSize = decimal.New(1, -2)
+	}
+	return instrument
+}
@@ -0,0 +1,105 @@
+package ProviderConfig
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/shopspring/decimal"
+)
+
+func TestInstrumentValidation(t *testing.T) {
+	instrument := &Instrument{
+		Base:           "XRP",
+		Quote:          "USD",
+		PricePrecision: 5,
+		TickSize:       decimal.RequireFromString("0.00005"),
+		LotSize:        decimal.RequireFromString("0.1"),
+		MinAmount:      decimal.NewFromInt(10),
+		MaxAmount:      decimal.NewFromInt(100000),
+		Active:         true,
+	}
+	if err := instrument.Validate(); err != nil {
+		t.Fatalf("Expected instrument to be valid; got %v", err)
+	}
+	if precision := instrument.AmountPrecision(); precision != 1 {
+		t.Errorf("Expected amount precision 1; got %d", precision)
+	}
+
+	validPrices := []string{"0.5", "0.51235", "1"}
+	for _, price := range validPrices {
+		if err := instrument.ValidatePrice(decimal.RequireFromString(price)); err != nil {
+			t.Errorf("Expected price %s to be valid; got %v", price, err)
+		}
+	}
+	invalidPrices := []string{"0.512351", "0.51234"}
+	for _, price := range invalidPrices {
+		if err := instrument.ValidatePrice(decimal.RequireFromString(price)); err == nil {
+			t.Errorf("Expected price %s to be invalid", price)
+		}
+	}
+
+	invalidAmounts := []string{"9.9", "100000.1", "10.05"}
+	for _, amount := range invalidAmounts {
+		if err := instrument.ValidateAmount(decimal.RequireFromString(amount)); err == nil {
+			t.Errorf("Expected amount %s to be invalid", amount)
+		}
+	}
+
+	instrument.TickSize = decimal.RequireFromString("0.000001")
+	if err := instrument.Validate(); err == nil {
+		t.Errorf("Expected tick size finer than the precision to be invalid")
+	}
+}
+
+func TestInstrumentConfig(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestInstrumentConfig")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	err := OpenDB(tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	instrument := NewDefaultInstrument("USD", "JPY")
+	instrument.MaxAmount = decimal.NewFromInt(1000000)
+	if err := SetInstrument(instrument); err != nil {
+		t.Fatalf("Error setting instrument: %v", err)
+	}
+
+	stored, err := GetInstrument("USD/JPY")
+	if err != nil || stored == nil {
+		t.Fatalf("Expected instrument to be stored; got %v, %v", stored, err)
+	}
+	if stored.PricePrecision != 2 || !stored.TickSize.Equal(decimal.RequireFromString("0.01")) || !stored.MaxAmount.Equal(instrument.MaxAmount) || !stored.Active {
+		t.Errorf("Stored instrument %+v does not match %+v", stored, instrument)
+	}
+
+	missing, err := GetInstrument("USD/NZD")
+	if err != nil || missing != nil {
+		t.Errorf("Expected no instrument for an unregistered pair; got %v, %v", missing, err)
+	}
+
+	// Default instruments don't replace existing ones
+	if err := registerDefaultInstruments([]string{"USD/JPY", "EUR/USD"}); err != nil {
+		t.Fatalf("Error registering default instruments: %v", err)
+	}
+	instruments, _ := GetInstruments()
+	if len(instruments) != 2 || !instruments["USD/JPY"].MaxAmount.Equal(instrument.MaxAmount) {
+		t.Errorf("Unexpected instruments %v", instruments)
+	}
+
+	deleted, _ := DeleteInstrument("USD/JPY")
+	if !deleted {
+		t.Errorf("Expected instrument to be deleted")
+	}
+	deleted, _ = DeleteInstrument("USD/JPY")
+	if deleted {
+		t.Errorf("Expected deleting a missing instrument to do nothing")
+	}
+}
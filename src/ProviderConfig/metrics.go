@@ -0,0 +1,20 @@
+package ProviderConfig
+
+import (
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/Metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "providerconfig_query_duration_seconds",
+	Help:    "SQLite query latency by operation.",
+	Buckets: Metrics.DefaultBuckets,
+}, []string{"operation"})
+
+// observeQuery records how long a database operation took, call it deferred with the start time.
+func observeQuery(operation string, start time.Time) {
+	queryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,35 @@
+package ProviderConfig
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/Metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryMetrics(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestQueryMetrics")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	sets := Metrics.HistogramCount(queryDuration, "set_pairs_enabled")
+	gets := Metrics.HistogramCount(queryDuration, "get_provider")
+
+	assert.NoError(t, SetPairEnabled("MetricsProvider", "BTC/USD", true))
+	_, err := GetProvider("MetricsProvider")
+	assert.NoError(t, err)
+
+	// Enabling a pair updates it in place without reading the provider first
+	assert.Equal(t, sets+1, Metrics.HistogramCount(queryDuration, "set_pairs_enabled"))
+	assert.Equal(t, gets+1, Metrics.HistogramCount(queryDuration, "get_provider"))
+}
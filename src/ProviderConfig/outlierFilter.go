@@ -0,0 +1,82 @@
+package ProviderConfig
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// OutlierReference is the price quotes are compared to by the outlier filter.
+type OutlierReference = string
+
+const (
+	// The median mid of every other provider's current quote for the pair
+	MedianReference OutlierReference = "median"
+	// The mid of the pair's current best bid and ask
+	ConsolidatedMidReference OutlierReference = "mid"
+)
+
+// OutlierFilterConfig controls how far a quote can be from the reference price before
+// the PriceAPI quarantines it. A zero limit turns that check off.
+type OutlierFilterConfig struct {
+	Enabled   bool             `json:"enabled"`
+	Reference OutlierReference `json:"reference"`
+	// Largest distance of a bid or ask from the reference, in basis points
+	MaxDeviationBps decimal.Decimal `json:"max_deviation_bps"`
+	// Widest spread allowed, in basis points of the quote's own mid
+	MaxSpreadBps decimal.Decimal `json:"max_spread_bps"`
+	// Fewest other providers needed for a median reference, there is no reference without them
+	MinProviders int `json:"min_providers"`
+}
+
+// OutlierFilterConfigs holds the default and per pair outlier filter settings. A null
+// pair setting removes the override for that pair.
+type OutlierFilterConfigs struct {
+	Default *OutlierFilterConfig            `json:"default"`
+	Pairs   map[string]*OutlierFilterConfig `json:"pairs"`
+}
+
+// Validate checks the settings are usable.
+func (config *OutlierFilterConfig) Validate() error {
+	if config.Reference != MedianReference && config.Reference != ConsolidatedMidReference {
+		return fmt.Errorf("reference must be %q or %q", MedianReference, ConsolidatedMidReference)
+	}
+	if config.MaxDeviationBps.IsNegative() || config.MaxSpreadBps.IsNegative() {
+		return fmt.Errorf("max_deviation_bps and max_spread_bps must not be negative")
+	}
+	if config.MinProviders < 1 {
+		return fmt.Errorf("min_providers must be at least 1")
+	}
+	return nil
+}
+
+// Validate checks every setting being changed is usable.
+func (configs *OutlierFilterConfigs) Validate() error {
+	if configs.Default != nil {
+		if err := configs.Default.Validate(); err != nil {
+			return fmt.Errorf("invalid default: %v", err)
+		}
+	}
+	for pairName, config := range configs.Pairs {
+		if config == nil {
+			continue
+		}
+		if err := config.Validate(); err != nil {
+			return fmt.Errorf("invalid settings for %s: %v", pairName, err)
+		}
+	}
+	return nil
+}
+
+// NewDefaultOutlierFilterConfig returns the settings used until they are changed. Quotes
+// more than 5% from the median of at least two other providers, or wider than 10%, are
+// quarantined.
+func NewDefaultOutlierFilterConfig() *OutlierFilterConfig {
+	return &OutlierFilterConfig{
+		Enabled:         true,
+		Reference:       MedianReference,
+		MaxDeviationBps: decimal.NewFromInt(500),
+		MaxSpreadBps:    decimal.NewFromInt(1000),
+		MinProviders:    2,
+	}
+}
@@ -0,0 +1,63 @@
+package ProviderConfig
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	outlierFilterCache   *dataVersionCache[*OutlierFilterConfigs]
+	outlierFilterCacheMu sync.RWMutex
+)
+
+// StartOutlierFilterCache loads the outlier filter settings into memory and checks for
+// changes made by other processes every interval. While it is running
+// GetOutlierFilterConfig is served from memory.
+func StartOutlierFilterCache(interval time.Duration) error {
+	cache, err := startDataVersionCache("outlier filter", interval, queryOutlierFilterConfigs)
+	if err != nil {
+		return err
+	}
+	StopOutlierFilterCache()
+	outlierFilterCacheMu.Lock()
+	outlierFilterCache = cache
+	outlierFilterCacheMu.Unlock()
+	return nil
+}
+
+// StopOutlierFilterCache stops the cache, lookups go back to the database.
+func StopOutlierFilterCache() {
+	outlierFilterCacheMu.Lock()
+	cache := outlierFilterCache
+	outlierFilterCache = nil
+	outlierFilterCacheMu.Unlock()
+	if cache != nil {
+		cache.stop()
+	}
+}
+
+// RefreshOutlierFilterCache reloads the cache straight away if another process has
+// changed the database. It does nothing if the cache isn't running.
+func RefreshOutlierFilterCache() error {
+	cache := getOutlierFilterCache()
+	if cache == nil {
+		return nil
+	}
+	return cache.refreshIfChanged()
+}
+
+func getOutlierFilterCache() *dataVersionCache[*OutlierFilterConfigs] {
+	outlierFilterCacheMu.RLock()
+	defer outlierFilterCacheMu.RUnlock()
+	return outlierFilterCache
+}
+
+// reloadOutlierFilterCache picks up settings written by this process.
+func reloadOutlierFilterCache() {
+	if cache := getOutlierFilterCache(); cache != nil {
+		if err := cache.reload(); err != nil {
+			fmt.Println("Error reloading outlier filter cache:", err)
+		}
+	}
+}
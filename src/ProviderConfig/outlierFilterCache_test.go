@@ -0,0 +1,77 @@
+package ProviderConfig
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/Metrics"
+	"github.com/shopspring/decimal"
+)
+
+func TestOutlierFilterCache(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestOutlierFilterCache")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	err := OpenDB(tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	// Use a long interval so only explicit refreshes pick up outside changes
+	if err := StartOutlierFilterCache(time.Hour); err != nil {
+		t.Fatalf("Error starting outlier filter cache: %v", err)
+	}
+	gets := Metrics.HistogramCount(queryDuration, "get_outlier_filters")
+	if config, _ := GetOutlierFilterConfig("BTC/USD"); config == nil || !config.Enabled {
+		t.Errorf("Expected the enabled default for BTC/USD; got %v", config)
+	}
+	if Metrics.HistogramCount(queryDuration, "get_outlier_filters") != gets {
+		t.Errorf("Expected lookups to be served from memory")
+	}
+
+	// In process changes are applied straight away
+	pairConfig := NewDefaultOutlierFilterConfig()
+	pairConfig.MaxDeviationBps = decimal.NewFromInt(200)
+	if err := SetOutlierFilterConfigs(&OutlierFilterConfigs{Pairs: map[string]*OutlierFilterConfig{"BTC/USD": pairConfig}}); err != nil {
+		t.Fatalf("Error saving outlier filter settings: %v", err)
+	}
+	if config, _ := GetOutlierFilterConfig("BTC/USD"); config.MaxDeviationBps.String() != "200" {
+		t.Errorf("Expected in process change to set 200 bps; got %s", config.MaxDeviationBps)
+	}
+
+	// Changes from another process are picked up through data_version
+	otherDB, err := sql.Open("sqlite3", tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening second connection: %v", err)
+	}
+	defer otherDB.Close()
+	if _, err := otherDB.Exec("UPDATE outlier_filters SET max_deviation_bps = '300' WHERE pair = 'BTC/USD'"); err != nil {
+		t.Fatalf("Error writing from second connection: %v", err)
+	}
+	if config, _ := GetOutlierFilterConfig("BTC/USD"); config.MaxDeviationBps.String() != "200" {
+		t.Errorf("Expected cache to not see outside change before refresh")
+	}
+	if err := RefreshOutlierFilterCache(); err != nil {
+		t.Fatalf("Error refreshing cache: %v", err)
+	}
+	if config, _ := GetOutlierFilterConfig("BTC/USD"); config.MaxDeviationBps.String() != "300" {
+		t.Errorf("Expected cache to see outside change after refresh")
+	}
+
+	// Without the cache lookups go back to the database
+	StopOutlierFilterCache()
+	if config, _ := GetOutlierFilterConfig("BTC/USD"); config.MaxDeviationBps.String() != "300" {
+		t.Errorf("Expected 300 bps from the database; got %s", config.MaxDeviationBps)
+	}
+	if Metrics.HistogramCount(queryDuration, "get_outlier_filters") == gets {
+		t.Errorf("Expected lookups to go to the database once the cache is stopped")
+	}
+}
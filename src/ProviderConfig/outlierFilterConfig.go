@@ -0,0 +1,119 @@
+package ProviderConfig
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// An empty pair is the default for every pair without its own settings. Basis points
+// are stored as text so they keep their exact decimal value.
+const createOutlierFiltersTable = `CREATE TABLE IF NOT EXISTS outlier_filters (
+		pair TEXT PRIMARY KEY,
+		enabled INTEGER NOT NULL,
+		reference TEXT NOT NULL,
+		max_deviation_bps TEXT NOT NULL,
+		max_spread_bps TEXT NOT NULL,
+		min_providers INTEGER NOT NULL
+	);`
+
+// SetOutlierFilterConfigs replaces the default and/or per pair outlier filter settings
+// together, a nil pair setting removes its override. Nothing is changed if any of the
+// settings are invalid.
+func SetOutlierFilterConfigs(configs *OutlierFilterConfigs) error {
+	defer observeQuery("set_outlier_filters", time.Now())
+	if err := configs.Validate(); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	changes := make(map[string]*OutlierFilterConfig, len(configs.Pairs)+1)
+	for pairName, config := range configs.Pairs {
+		changes[pairName] = config
+	}
+	if configs.Default != nil {
+		changes[""] = configs.Default
+	}
+	for pairName, config := range changes {
+		if config == nil {
+			_, err = tx.Exec("DELETE FROM outlier_filters WHERE pair = ?", pairName)
+		} else {
+			_, err = tx.Exec(`REPLACE INTO outlier_filters (pair, enabled, reference, max_deviation_bps, max_spread_bps, min_providers)
+				VALUES (?, ?, ?, ?, ?, ?)`,
+				pairName, config.Enabled, config.Reference, config.MaxDeviationBps.String(), config.MaxSpreadBps.String(), config.MinProviders)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	reloadOutlierFilterCache()
+	return nil
+}
+
+// GetOutlierFilterConfigs returns the default and per pair outlier filter settings. The
+// default is NewDefaultOutlierFilterConfig until it is changed. If the outlier filter
+// cache is running the settings are served from memory, they must not be modified.
+func GetOutlierFilterConfigs() (*OutlierFilterConfigs, error) {
+	if cache := getOutlierFilterCache(); cache != nil {
+		return cache.get(), nil
+	}
+	return queryOutlierFilterConfigs()
+}
+
+// GetOutlierFilterConfig returns a pair's outlier filter settings, or the default if it
+// has none of its own.
+func GetOutlierFilterConfig(pairName string) (*OutlierFilterConfig, error) {
+	configs, err := GetOutlierFilterConfigs()
+	if err != nil {
+		return nil, err
+	}
+	if config, ok := configs.Pairs[pairName]; ok {
+		return config, nil
+	}
+	return configs.Default, nil
+}
+
+func queryOutlierFilterConfigs() (*OutlierFilterConfigs, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is not open")
+	}
+	defer observeQuery("get_outlier_filters", time.Now())
+	configs := &OutlierFilterConfigs{
+		Default: NewDefaultOutlierFilterConfig(),
+		Pairs:   make(map[string]*OutlierFilterConfig),
+	}
+
+	rows, err := db.Query("SELECT pair, enabled, reference, max_deviation_bps, max_spread_bps, min_providers FROM outlier_filters")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pairName, maxDeviationBps, maxSpreadBps string
+		config := &OutlierFilterConfig{}
+		if err := rows.Scan(&pairName, &config.Enabled, &config.Reference, &maxDeviationBps, &maxSpreadBps, &config.MinProviders); err != nil {
+			return nil, err
+		}
+		if config.MaxDeviationBps, err = decimal.NewFromString(maxDeviationBps); err != nil {
+			return nil, err
+		}
+		if config.MaxSpreadBps, err = decimal.NewFromString(maxSpreadBps); err != nil {
+			return nil, err
+		}
+		if pairName == "" {
+			configs.Default = config
+		} else {
+			configs.Pairs[pairName] = config
+		}
+	}
+	return configs, rows.Err()
+}
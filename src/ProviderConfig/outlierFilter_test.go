@@ -0,0 +1,61 @@
+package ProviderConfig
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutlierFilterConfigs(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestOutlierFilterConfigs")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	// Until anything is saved the default is used
+	configs, err := GetOutlierFilterConfigs()
+	if assert.NoError(t, err) {
+		assert.Equal(t, NewDefaultOutlierFilterConfig(), configs.Default)
+		assert.Empty(t, configs.Pairs)
+	}
+
+	pairConfig := &OutlierFilterConfig{Enabled: true, Reference: ConsolidatedMidReference, MaxDeviationBps: decimal.RequireFromString("12.5"), MinProviders: 1}
+	defaultConfig := NewDefaultOutlierFilterConfig()
+	defaultConfig.Enabled = false
+	assert.NoError(t, SetOutlierFilterConfigs(&OutlierFilterConfigs{
+		Default: defaultConfig,
+		Pairs:   map[string]*OutlierFilterConfig{"BTC/USD": pairConfig},
+	}))
+	configs, err = GetOutlierFilterConfigs()
+	if assert.NoError(t, err) {
+		assert.False(t, configs.Default.Enabled)
+		if assert.Contains(t, configs.Pairs, "BTC/USD") {
+			assert.Equal(t, ConsolidatedMidReference, configs.Pairs["BTC/USD"].Reference)
+			assert.Equal(t, "12.5", configs.Pairs["BTC/USD"].MaxDeviationBps.String())
+		}
+	}
+
+	// An invalid setting changes nothing
+	err = SetOutlierFilterConfigs(&OutlierFilterConfigs{
+		Default: NewDefaultOutlierFilterConfig(),
+		Pairs:   map[string]*OutlierFilterConfig{"ETH/USD": {Reference: "bogus", MinProviders: 1}},
+	})
+	assert.ErrorContains(t, err, "invalid settings for ETH/USD")
+	configs, _ = GetOutlierFilterConfigs()
+	assert.False(t, configs.Default.Enabled)
+
+	// A nil pair removes its override
+	assert.NoError(t, SetOutlierFilterConfigs(&OutlierFilterConfigs{Pairs: map[string]*OutlierFilterConfig{"BTC/USD": nil}}))
+	configs, _ = GetOutlierFilterConfigs()
+	assert.Empty(t, configs.Pairs)
+}
@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"os"
 	"path"
+	"strconv"
 	"time"
 
 	"database/sql"
@@ -25,8 +26,10 @@ func OpenDB(dbFile string) error {
 		}
 	}
 
-	// Open or create the database file
-	sqliteDB, err := sql.Open("sqlite3", dbFile)
+	// Open or create the database file. Transactions take the write lock when they begin
+	// (BEGIN IMMEDIATE) so nothing they read can be changed by another process before
+	// they commit.
+	sqliteDB, err := sql.Open("sqlite3", dbFile+"?_txlock=immediate")
 	if err != nil {
 		return err
 	}
@@ -41,6 +44,50 @@ func OpenDB(dbFile string) error {
 		return err
 	}
 
+	// Instruments describe every pair providers can quote. Databases from before there
+	// were instruments get a default one for every pair already configured, as updates
+	// for unregistered pairs are rejected.
+	var hadInstruments bool
+	err = sqliteDB.QueryRow("SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'instruments')").Scan(&hadInstruments)
+	if err != nil {
+		sqliteDB.Close()
+		return err
+	}
+	if _, err = sqliteDB.Exec(createInstrumentsTable); err != nil {
+		sqliteDB.Close()
+		return err
+	}
+	if !hadInstruments {
+		if err = seedDefaultInstruments(sqliteDB); err != nil {
+			sqliteDB.Close()
+			return err
+		}
+	}
+
+	// Credentials let providers authenticate their price updates
+	if _, err = sqliteDB.Exec(createCredentialsTable); err != nil {
+		sqliteDB.Close()
+		return err
+	}
+
+	// Rate limits cap how fast providers can send price updates
+	if _, err = sqliteDB.Exec(createRateLimitsTable); err != nil {
+		sqliteDB.Close()
+		return err
+	}
+
+	// Pairs disabled automatically and why
+	if _, err = sqliteDB.Exec(createAutoDisabledPairsTable); err != nil {
+		sqliteDB.Close()
+		return err
+	}
+
+	// Outlier filter settings the PriceAPI quarantines quotes with
+	if _, err = sqliteDB.Exec(createOutlierFiltersTable); err != nil {
+		sqliteDB.Close()
+		return err
+	}
+
 	// Set the global database variable
 	db = sqliteDB
 
@@ -48,12 +95,18 @@ func OpenDB(dbFile string) error {
 }
 
 func CloseDB() {
+	StopEligibilityCache()
+	StopInstrumentCache()
+	StopCredentialCache()
+	StopOutlierFilterCache()
+	StopRateLimitCache()
 	if db != nil {
 		db.Close()
 	}
 }
 
 func SetProvider(provider *Provider) error {
+	defer observeQuery("set_provider", time.Now())
 	// Null check for provider
 	if provider == nil {
 		return fmt.Errorf("provider is nil")
@@ -81,10 +134,14 @@ func SetProvider(provider *Provider) error {
 		return err
 	}
 
+	// Let anything caching providers know about the change
+	notifyProviderChange(provider)
+
 	return nil
 }
 
 func GetProviders() (map[string]*Provider, error) {
+	defer observeQuery("get_providers", time.Now())
 	providers := make(map[string]*Provider)
 
 	rows, err := db.Query("SELECT name, pairs FROM providers")
@@ -118,6 +175,7 @@ func GetProviders() (map[string]*Provider, error) {
 }
 
 func GetProvider(providerName string) (*Provider, error) {
+	defer observeQuery("get_provider", time.Now())
 	var provider *Provider
 
 	row := db.QueryRow("SELECT pairs FROM providers WHERE name = ?", providerName)
@@ -144,7 +202,12 @@ func GetProvider(providerName string) (*Provider, error) {
 }
 
 // GetProviderPairEnabled retrieves the enabled status of a specific currency pair for a given provider.
+// If the eligibility cache is running the lookup is served from memory.
 func GetProviderPairEnabled(providerName string, pairName string) (bool, error) {
+	if cache := getEligibilityCache(); cache != nil {
+		return cache.isPairEnabled(providerName, pairName), nil
+	}
+
 	// Retrieve the provider
 	provider, err := GetProvider(providerName)
 	if err != nil {
@@ -163,38 +226,75 @@ func GetProviderPairEnabled(providerName string, pairName string) (bool, error)
 	return enabled, nil
 }
 
-// SetPairEnabled enables or disables a specific currency pair for a given provider.
-func SetPairEnabled(providerName string, pair string, enabled bool) error {
-	provider, err := GetProvider(providerName)
-	if err != nil {
-		return err
+// IsKnownProvider returns true if the provider has been configured. If the eligibility
+// cache is running the lookup is served from memory.
+func IsKnownProvider(providerName string) (bool, error) {
+	if cache := getEligibilityCache(); cache != nil {
+		return cache.hasProvider(providerName), nil
 	}
-	if provider == nil {
-		provider = &Provider{
-			Name:  providerName,
-			Pairs: make(map[string]bool, 0),
-		}
+	if db == nil {
+		return false, fmt.Errorf("database is not open")
 	}
-	provider.Pairs[pair] = enabled
-	return SetProvider(provider)
+	provider, err := GetProvider(providerName)
+	return provider != nil, err
 }
 
+// SetPairEnabled enables or disables a specific currency pair for a given provider.
+func SetPairEnabled(providerName string, pair string, enabled bool) error {
+	return SetPairsEnabled(providerName, map[string]bool{pair: enabled})
+}
+
+// SetPairsEnabled enables or disables a provider's pairs, leaving its other pairs as
+// they are even if they are changed at the same time.
 func SetPairsEnabled(providerName string, pairsEnabled map[string]bool) error {
 	fmt.Printf("Setting pairs for %s: %v\n", providerName, pairsEnabled)
-	provider, err := GetProvider(providerName)
+	defer observeQuery("set_pairs_enabled", time.Now())
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
-	if provider == nil {
-		provider = &Provider{
-			Name:  providerName,
-			Pairs: make(map[string]bool, 0),
-		}
+	defer tx.Rollback()
+	provider, err := setPairsEnabled(tx, providerName, pairsEnabled)
+	if err != nil {
+		return err
+	}
+	pairNames := make([]string, 0, len(pairsEnabled))
+	for pair := range pairsEnabled {
+		pairNames = append(pairNames, pair)
+	}
+	if err := clearAutoDisabledPairs(tx, providerName, pairNames...); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
 	}
+	notifyProviderChange(provider)
+	return nil
+}
+
+// setPairsEnabled updates just the given pairs in a provider's row, adding the provider
+// if needed, and returns the provider as it now is.
+func setPairsEnabled(tx *sql.Tx, providerName string, pairsEnabled map[string]bool) (*Provider, error) {
 	for pair, enabled := range pairsEnabled {
-		provider.Pairs[pair] = enabled
+		// JSON path for the pair's key, quoted as pairs contain a slash
+		pairPath := fmt.Sprintf("$.%q", pair)
+		_, err := tx.Exec(`INSERT INTO providers (name, pairs) VALUES (?, json_object(?, json(?)))
+			ON CONFLICT (name) DO UPDATE SET pairs = json_set(COALESCE(pairs, '{}'), ?, json(?))`,
+			providerName, pair, strconv.FormatBool(enabled), pairPath, strconv.FormatBool(enabled))
+		if err != nil {
+			return nil, err
+		}
 	}
-	return SetProvider(provider)
+
+	var pairsJSON string
+	if err := tx.QueryRow("SELECT pairs FROM providers WHERE name = ?", providerName).Scan(&pairsJSON); err != nil {
+		return nil, err
+	}
+	provider := &Provider{Name: providerName}
+	if err := json.Unmarshal([]byte(pairsJSON), &provider.Pairs); err != nil {
+		return nil, err
+	}
+	return provider, nil
 }
 
 // generateRandomProviderSettings generates random enabled/disabled states
@@ -216,6 +316,13 @@ func RandomizeProviders() (map[string]*Provider, error) {
 		"GoldenDragonExchange":   {"BTC/USD", "ETH/USD", "XRP/USD", "EUR/USD", "BCH/USD", "BTC/GBP", "LTC/GBP", "EUR/GBP", "XRP/GBP", "BCH/GBP", "BTC/AUD", "ETH/AUD"},
 	}
 
+	// Every pair needs an instrument before it can be quoted
+	for _, pairNames := range defaultProviders {
+		if err := registerDefaultInstruments(pairNames); err != nil {
+			return nil, err
+		}
+	}
+
 	providers := make(map[string]*Provider)
 	// Assign enabled or disabled status randomly for each pair
 	for providerName := range defaultProviders {
@@ -0,0 +1,33 @@
+package ProviderConfig
+
+import (
+	"fmt"
+	"math"
+)
+
+// RateLimit caps how many price updates a second a provider can send, across all of its
+// pairs or for a single pair. Burst is how many can be sent at once after being idle.
+type RateLimit struct {
+	Provider string `json:"provider"`
+	// Empty for a limit across all of the provider's pairs
+	Pair  string  `json:"pair,omitempty"`
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// Validate checks the limit's settings, a missing burst defaults to one second of updates.
+func (limit *RateLimit) Validate() error {
+	if limit.Provider == "" {
+		return fmt.Errorf("provider is required")
+	}
+	if limit.Rate <= 0 || math.IsInf(limit.Rate, 0) || math.IsNaN(limit.Rate) {
+		return fmt.Errorf("rate must be a positive number of updates per second")
+	}
+	if limit.Burst < 0 {
+		return fmt.Errorf("burst must not be negative")
+	}
+	if limit.Burst == 0 {
+		limit.Burst = int(math.Max(1, math.Ceil(limit.Rate)))
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package ProviderConfig
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimitChangeListener is called with every provider's rate limits after they change.
+type RateLimitChangeListener func(limits []*RateLimit)
+
+var (
+	rateLimitCache          *dataVersionCache[[]*RateLimit]
+	rateLimitCacheMu        sync.RWMutex
+	rateLimitListeners      = make(map[int]RateLimitChangeListener)
+	nextRateLimitListenerID int
+	rateLimitListenersMu    sync.RWMutex
+)
+
+// SubscribeRateLimitChanges registers a listener for rate limit changes and returns a
+// function to unsubscribe. If the rate limit cache is running the listener is called
+// straight away with the current limits.
+func SubscribeRateLimitChanges(listener RateLimitChangeListener) func() {
+	rateLimitListenersMu.Lock()
+	id := nextRateLimitListenerID
+	nextRateLimitListenerID++
+	rateLimitListeners[id] = listener
+	rateLimitListenersMu.Unlock()
+
+	if cache := getRateLimitCache(); cache != nil {
+		listener(cache.get())
+	}
+	return func() {
+		rateLimitListenersMu.Lock()
+		defer rateLimitListenersMu.Unlock()
+		delete(rateLimitListeners, id)
+	}
+}
+
+func notifyRateLimitChanges(limits []*RateLimit) {
+	rateLimitListenersMu.RLock()
+	defer rateLimitListenersMu.RUnlock()
+	for _, listener := range rateLimitListeners {
+		listener(limits)
+	}
+}
+
+// StartRateLimitCache loads every rate limit into memory and checks for changes made by
+// other processes every interval. Listeners are notified whenever the limits are reloaded.
+func StartRateLimitCache(interval time.Duration) error {
+	cache, err := startDataVersionCache("rate limit", interval, queryAllRateLimits)
+	if err != nil {
+		return err
+	}
+	cache.setOnReload(notifyRateLimitChanges)
+	StopRateLimitCache()
+	rateLimitCacheMu.Lock()
+	rateLimitCache = cache
+	rateLimitCacheMu.Unlock()
+	// Listeners subscribed before the cache started may have missed limits
+	notifyRateLimitChanges(cache.get())
+	return nil
+}
+
+// StopRateLimitCache stops the cache, lookups go back to the database.
+func StopRateLimitCache() {
+	rateLimitCacheMu.Lock()
+	cache := rateLimitCache
+	rateLimitCache = nil
+	rateLimitCacheMu.Unlock()
+	if cache != nil {
+		cache.stop()
+	}
+}
+
+// RefreshRateLimitCache reloads the cache straight away if another process has
+// changed the database. It does nothing if the cache isn't running.
+func RefreshRateLimitCache() error {
+	cache := getRateLimitCache()
+	if cache == nil {
+		return nil
+	}
+	return cache.refreshIfChanged()
+}
+
+func getRateLimitCache() *dataVersionCache[[]*RateLimit] {
+	rateLimitCacheMu.RLock()
+	defer rateLimitCacheMu.RUnlock()
+	return rateLimitCache
+}
+
+// rateLimitsChanged passes a limit written by this process on to the listeners, through
+// the cache if it is running.
+func rateLimitsChanged() {
+	if cache := getRateLimitCache(); cache != nil {
+		if err := cache.reload(); err != nil {
+			fmt.Println("Error reloading rate limit cache:", err)
+		}
+		return
+	}
+
+	rateLimitListenersMu.RLock()
+	listening := len(rateLimitListeners) > 0
+	rateLimitListenersMu.RUnlock()
+	if !listening {
+		return
+	}
+	limits, err := queryAllRateLimits()
+	if err != nil {
+		fmt.Println("Error loading rate limits:", err)
+		return
+	}
+	notifyRateLimitChanges(limits)
+}
@@ -0,0 +1,84 @@
+package ProviderConfig
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/Metrics"
+)
+
+func TestRateLimitCache(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestRateLimitCache")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	err := OpenDB(tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	var notified []*RateLimit
+	unsubscribe := SubscribeRateLimitChanges(func(limits []*RateLimit) { notified = limits })
+	defer unsubscribe()
+
+	// Without the cache in process changes are still passed on
+	SetRateLimit(&RateLimit{Provider: "CacheProviderA", Rate: 5, Burst: 5})
+	if len(notified) != 1 || notified[0].Rate != 5 {
+		t.Errorf("Expected the new limit to be passed on; got %v", notified)
+	}
+
+	// Use a long interval so only explicit refreshes pick up outside changes
+	if err := StartRateLimitCache(time.Hour); err != nil {
+		t.Fatalf("Error starting rate limit cache: %v", err)
+	}
+	gets := Metrics.HistogramCount(queryDuration, "get_all_rate_limits")
+	if limits, _ := GetAllRateLimits(); len(limits) != 1 {
+		t.Errorf("Expected 1 rate limit; got %v", limits)
+	}
+	if Metrics.HistogramCount(queryDuration, "get_all_rate_limits") != gets {
+		t.Errorf("Expected lookups to be served from memory")
+	}
+
+	// In process changes are applied straight away
+	SetRateLimit(&RateLimit{Provider: "CacheProviderA", Pair: "BTC/USD", Rate: 1, Burst: 1})
+	if len(notified) != 2 {
+		t.Errorf("Expected in process change to be passed on; got %v", notified)
+	}
+	DeleteRateLimit("CacheProviderA", "BTC/USD")
+	if len(notified) != 1 {
+		t.Errorf("Expected in process delete to be passed on; got %v", notified)
+	}
+
+	// Changes from another process are picked up through data_version
+	otherDB, err := sql.Open("sqlite3", tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening second connection: %v", err)
+	}
+	defer otherDB.Close()
+	if _, err := otherDB.Exec("UPDATE rate_limits SET rate = 10 WHERE provider = 'CacheProviderA'"); err != nil {
+		t.Fatalf("Error writing from second connection: %v", err)
+	}
+	if notified[0].Rate != 5 {
+		t.Errorf("Expected outside change to not be passed on before refresh")
+	}
+	if err := RefreshRateLimitCache(); err != nil {
+		t.Fatalf("Error refreshing cache: %v", err)
+	}
+	if notified[0].Rate != 10 {
+		t.Errorf("Expected outside change to be passed on after refresh")
+	}
+
+	// Late subscribers are given the current limits straight away
+	var late []*RateLimit
+	defer SubscribeRateLimitChanges(func(limits []*RateLimit) { late = limits })()
+	if len(late) != 1 || late[0].Rate != 10 {
+		t.Errorf("Expected the current limits on subscribing; got %v", late)
+	}
+}
@@ -0,0 +1,80 @@
+package ProviderConfig
+
+import "time"
+
+// An empty pair is a limit across all of the provider's pairs
+const createRateLimitsTable = `CREATE TABLE IF NOT EXISTS rate_limits (
+		provider TEXT NOT NULL,
+		pair TEXT NOT NULL,
+		rate REAL NOT NULL,
+		burst INTEGER NOT NULL,
+		PRIMARY KEY (provider, pair)
+	);`
+
+const selectRateLimits = "SELECT provider, pair, rate, burst FROM rate_limits"
+
+// SetRateLimit adds or replaces a provider's rate limit.
+func SetRateLimit(limit *RateLimit) error {
+	defer observeQuery("set_rate_limit", time.Now())
+	if err := limit.Validate(); err != nil {
+		return err
+	}
+	_, err := db.Exec("REPLACE INTO rate_limits (provider, pair, rate, burst) VALUES (?, ?, ?, ?)",
+		limit.Provider, limit.Pair, limit.Rate, limit.Burst)
+	if err != nil {
+		return err
+	}
+	rateLimitsChanged()
+	return nil
+}
+
+// DeleteRateLimit removes a provider's rate limit, returning false if it didn't exist.
+func DeleteRateLimit(providerName string, pairName string) (bool, error) {
+	defer observeQuery("delete_rate_limit", time.Now())
+	result, err := db.Exec("DELETE FROM rate_limits WHERE provider = ? AND pair = ?", providerName, pairName)
+	if err != nil {
+		return false, err
+	}
+	rateLimitsChanged()
+	deleted, err := result.RowsAffected()
+	return deleted > 0, err
+}
+
+// GetRateLimits returns a provider's rate limits, the limit across all pairs first.
+func GetRateLimits(providerName string) ([]*RateLimit, error) {
+	defer observeQuery("get_rate_limits", time.Now())
+	return queryRateLimits(selectRateLimits+" WHERE provider = ? ORDER BY pair", providerName)
+}
+
+// GetAllRateLimits returns every provider's rate limits. If the rate limit cache is
+// running they are served from memory and must not be modified.
+func GetAllRateLimits() ([]*RateLimit, error) {
+	if cache := getRateLimitCache(); cache != nil {
+		return cache.get(), nil
+	}
+	return queryAllRateLimits()
+}
+
+func queryAllRateLimits() ([]*RateLimit, error) {
+	defer observeQuery("get_all_rate_limits", time.Now())
+	return queryRateLimits(selectRateLimits + " ORDER BY provider, pair")
+}
+
+func queryRateLimits(query string, args ...any) ([]*RateLimit, error) {
+	limits := make([]*RateLimit, 0)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return limits, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		limit := &RateLimit{}
+		if err := rows.Scan(&limit.Provider, &limit.Pair, &limit.Rate, &limit.Burst); err != nil {
+			return limits, err
+		}
+		limits = append(limits, limit)
+	}
+	return limits, rows.Err()
+}
@@ -0,0 +1,85 @@
+package ProviderConfig
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+)
+
+func TestRateLimitValidation(t *testing.T) {
+	limit := &RateLimit{Provider: "ProviderA", Rate: 2.5}
+	if err := limit.Validate(); err != nil {
+		t.Fatalf("Expected rate limit to be valid; got %v", err)
+	}
+	if limit.Burst != 3 {
+		t.Errorf("Expected burst to default to one second of updates; got %d", limit.Burst)
+	}
+
+	invalidLimits := []*RateLimit{
+		{Rate: 1, Burst: 1},
+		{Provider: "ProviderA", Rate: 0, Burst: 1},
+		{Provider: "ProviderA", Rate: -1, Burst: 1},
+		{Provider: "ProviderA", Rate: 1, Burst: -1},
+	}
+	for _, limit := range invalidLimits {
+		if err := limit.Validate(); err == nil {
+			t.Errorf("Expected rate limit %+v to be invalid", limit)
+		}
+	}
+}
+
+func TestRateLimitConfig(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestRateLimitConfig")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	err := OpenDB(tmpDBFileName.Name())
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	if err := SetRateLimit(&RateLimit{Provider: "ProviderA", Pair: "BTC/USD", Rate: 5, Burst: 10}); err != nil {
+		t.Fatalf("Error setting rate limit: %v", err)
+	}
+	if err := SetRateLimit(&RateLimit{Provider: "ProviderA", Rate: 100}); err != nil {
+		t.Fatalf("Error setting rate limit: %v", err)
+	}
+	if err := SetRateLimit(&RateLimit{Provider: "ProviderB", Rate: 0}); err == nil {
+		t.Errorf("Expected an invalid rate limit not to be stored")
+	}
+	// Setting a limit again replaces it
+	if err := SetRateLimit(&RateLimit{Provider: "ProviderB", Rate: 1, Burst: 1}); err != nil {
+		t.Fatalf("Error setting rate limit: %v", err)
+	}
+	if err := SetRateLimit(&RateLimit{Provider: "ProviderB", Rate: 2, Burst: 4}); err != nil {
+		t.Fatalf("Error setting rate limit: %v", err)
+	}
+
+	limits, err := GetRateLimits("ProviderA")
+	if err != nil || len(limits) != 2 {
+		t.Fatalf("Expected 2 rate limits; got %v, %v", limits, err)
+	}
+	if limits[0].Pair != "" || limits[0].Rate != 100 || limits[0].Burst != 100 {
+		t.Errorf("Expected the limit across all pairs first; got %+v", limits[0])
+	}
+	if limits[1].Pair != "BTC/USD" || limits[1].Rate != 5 || limits[1].Burst != 10 {
+		t.Errorf("Unexpected pair limit %+v", limits[1])
+	}
+
+	limits, _ = GetAllRateLimits()
+	if len(limits) != 3 || limits[2].Provider != "ProviderB" || limits[2].Rate != 2 || limits[2].Burst != 4 {
+		t.Errorf("Unexpected rate limits %v", limits)
+	}
+
+	if deleted, _ := DeleteRateLimit("ProviderA", "BTC/USD"); !deleted {
+		t.Errorf("Expected rate limit to be deleted")
+	}
+	if deleted, _ := DeleteRateLimit("ProviderA", "BTC/USD"); deleted {
+		t.Errorf("Expected deleting a missing rate limit to do nothing")
+	}
+}
@@ -0,0 +1,29 @@
+package ProviderConfigAPI
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header admin routes expect the admin API key in
+const AdminAPIKeyHeader = "X-Admin-API-Key"
+
+// AdminAPIKey is the key admin routes, such as credential management, must be called
+// with. While it is empty admin routes are refused.
+var AdminAPIKey string
+
+// AuthenticateAdmin is middleware that rejects requests without the admin API key.
+func AuthenticateAdmin(c *gin.Context) {
+	if AdminAPIKey == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin routes are disabled until an admin API key is set"})
+		return
+	}
+	// Compared in constant time so the key can't be guessed a byte at a time
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader(AdminAPIKeyHeader)), []byte(AdminAPIKey)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid " + AdminAPIKeyHeader + " header"})
+		return
+	}
+	c.Next()
+}
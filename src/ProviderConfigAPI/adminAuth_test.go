@@ -0,0 +1,71 @@
+package ProviderConfigAPI
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentialEndpointsRequireAdmin(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestCredentialEndpointsRequireAdmin")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	if err := ProviderConfig.SetProvider(&ProviderConfig.Provider{Name: "ProviderA", Pairs: map[string]bool{"BTC/USD": true}}); err != nil {
+		t.Fatal(err)
+	}
+
+	previousKey := AdminAPIKey
+	defer func() { AdminAPIKey = previousKey }()
+
+	router := gin.Default()
+	keys := router.Group("/providers/:providerName/keys", AuthenticateAdmin)
+	keys.GET("", GetCredentials)
+	keys.POST("", CreateCredential)
+	keys.POST("/rotate", RotateCredentials)
+	keys.DELETE("/:keyID", DeleteCredential)
+
+	serve := func(method string, path string, adminKey string) int {
+		req, _ := http.NewRequest(method, path, nil)
+		if adminKey != "" {
+			req.Header.Set(AdminAPIKeyHeader, adminKey)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	// Without an admin key configured every call is refused
+	AdminAPIKey = ""
+	assert.Equal(t, http.StatusUnauthorized, serve("GET", "/providers/ProviderA/keys", ""))
+	assert.Equal(t, http.StatusUnauthorized, serve("POST", "/providers/ProviderA/keys", "anything"))
+
+	AdminAPIKey = "admin-secret"
+	for _, route := range []struct{ method, path string }{
+		{"GET", "/providers/ProviderA/keys"},
+		{"POST", "/providers/ProviderA/keys"},
+		{"POST", "/providers/ProviderA/keys/rotate"},
+		{"DELETE", "/providers/ProviderA/keys/missing"},
+	} {
+		assert.Equal(t, http.StatusUnauthorized, serve(route.method, route.path, ""), "%s %s without a key", route.method, route.path)
+		assert.Equal(t, http.StatusUnauthorized, serve(route.method, route.path, "wrong"), "%s %s with the wrong key", route.method, route.path)
+	}
+	credentials, _ := ProviderConfig.GetCredentials("ProviderA")
+	assert.Empty(t, credentials, "Unauthenticated calls shouldn't create credentials")
+
+	assert.Equal(t, http.StatusCreated, serve("POST", "/providers/ProviderA/keys", "admin-secret"))
+	assert.Equal(t, http.StatusOK, serve("GET", "/providers/ProviderA/keys", "admin-secret"))
+}
@@ -0,0 +1,131 @@
+package ProviderConfigAPI
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+)
+
+// How long existing credentials keep working after a rotation when no grace period is given
+var DefaultRotationGracePeriod = time.Hour
+
+// CredentialResponse is a newly created credential, the only time its secret is returned.
+type CredentialResponse struct {
+	*ProviderConfig.Credential
+	APIKey string `json:"api_key"`
+}
+
+// RotateCredentialsRequest sets how long existing credentials keep working, e.g. "10m".
+type RotateCredentialsRequest struct {
+	GracePeriod string `json:"grace_period"`
+}
+
+// GetCredentials lists a provider's credentials without their secrets.
+func GetCredentials(c *gin.Context) {
+	providerName, ok := getExistingProviderParam(c)
+	if !ok {
+		return
+	}
+
+	credentials, err := ProviderConfig.GetCredentials(providerName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, credentials)
+}
+
+// CreateCredential adds a credential for a provider alongside its existing ones.
+func CreateCredential(c *gin.Context) {
+	providerName, ok := getExistingProviderParam(c)
+	if !ok {
+		return
+	}
+
+	credential, err := ProviderConfig.CreateCredential(providerName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, &CredentialResponse{Credential: credential, APIKey: credential.APIKey()})
+}
+
+// RotateCredentials creates a new credential and expires the provider's existing
+// credentials once the grace period is over.
+func RotateCredentials(c *gin.Context) {
+	providerName, ok := getExistingProviderParam(c)
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var req RotateCredentialsRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	grace := DefaultRotationGracePeriod
+	if req.GracePeriod != "" {
+		var err error
+		grace, err = time.ParseDuration(req.GracePeriod)
+		if err != nil || grace < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid grace_period %q", req.GracePeriod)})
+			return
+		}
+	}
+
+	credential, err := ProviderConfig.RotateCredentials(providerName, grace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, &CredentialResponse{Credential: credential, APIKey: credential.APIKey()})
+}
+
+// DeleteCredential revokes one of a provider's credentials straight away.
+func DeleteCredential(c *gin.Context) {
+	providerName := c.Param("providerName")
+	keyID := c.Param("keyID")
+	if providerName == "" || keyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty provider or key ID param"})
+		return
+	}
+
+	deleted, err := ProviderConfig.DeleteCredential(providerName, keyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("%s has no credential %s", providerName, keyID)})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// getExistingProviderParam returns the provider name from the URL, replying with a 404
+// if the provider doesn't exist.
+func getExistingProviderParam(c *gin.Context) (string, bool) {
+	providerName := c.Param("providerName")
+	if providerName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty provider param"})
+		return "", false
+	}
+	provider, err := ProviderConfig.GetProvider(providerName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return "", false
+	}
+	if provider == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("provider %s does not exist", providerName)})
+		return "", false
+	}
+	return providerName, true
+}
@@ -0,0 +1,99 @@
+package ProviderConfigAPI
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentialEndpoints(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestCredentialEndpoints")
+	if tempErr != nil {
+		t.Errorf("Error creating temporary file: %v", tempErr)
+		return
+	}
+	err := ProviderConfig.OpenDB(tmpDBFileName.Name())
+	if err != nil {
+		t.Errorf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	if err := ProviderConfig.SetProvider(&ProviderConfig.Provider{Name: "ProviderA", Pairs: map[string]bool{"BTC/USD": true}}); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.Default()
+	router.GET("/providers/:providerName/keys", GetCredentials)
+	router.POST("/providers/:providerName/keys", CreateCredential)
+	router.POST("/providers/:providerName/keys/rotate", RotateCredentials)
+	router.DELETE("/providers/:providerName/keys/:keyID", DeleteCredential)
+
+	serve := func(method string, path string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Create
+	rr := serve("POST", "/providers/ProviderA/keys", "")
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var created CredentialResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "ProviderA", created.Provider)
+	assert.NotEmpty(t, created.Secret)
+	assert.Equal(t, created.KeyID+"."+created.Secret, created.APIKey)
+
+	// Unknown providers can't have credentials
+	rr = serve("POST", "/providers/Unknown/keys", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = serve("GET", "/providers/Unknown/keys", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Rotate with a grace period
+	rr = serve("POST", "/providers/ProviderA/keys/rotate", `{"grace_period":"10m"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var rotated CredentialResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &rotated); err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEqual(t, created.KeyID, rotated.KeyID)
+
+	rr = serve("POST", "/providers/ProviderA/keys/rotate", `{"grace_period":"soon"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// List without secrets
+	rr = serve("GET", "/providers/ProviderA/keys", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), created.Secret)
+	var credentials []*ProviderConfig.Credential
+	if err := json.Unmarshal(rr.Body.Bytes(), &credentials); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Equal(t, 2, len(credentials)) {
+		assert.Equal(t, created.KeyID, credentials[0].KeyID)
+		if assert.NotNil(t, credentials[0].ExpiresAt) {
+			assert.WithinDuration(t, time.Now().Add(10*time.Minute), *credentials[0].ExpiresAt, time.Minute)
+		}
+		assert.Nil(t, credentials[1].ExpiresAt)
+	}
+
+	// Delete
+	rr = serve("DELETE", "/providers/ProviderA/keys/"+created.KeyID, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serve("DELETE", "/providers/ProviderA/keys/"+created.KeyID, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
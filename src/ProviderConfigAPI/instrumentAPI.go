@@ -0,0 +1,95 @@
+package ProviderConfigAPI
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+)
+
+// GetInstruments retrieves every registered instrument.
+func GetInstruments(c *gin.Context) {
+	instruments, err := ProviderConfig.GetInstruments()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, instruments)
+}
+
+// GetInstrument retrieves a single instrument.
+func GetInstrument(c *gin.Context) {
+	pairName, ok := getInstrumentPairParam(c)
+	if !ok {
+		return
+	}
+
+	instrument, err := ProviderConfig.GetInstrument(pairName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if instrument == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("instrument %s is not registered", pairName)})
+		return
+	}
+	c.JSON(http.StatusOK, instrument)
+}
+
+// SetInstrument creates or replaces an instrument, the base and quote are taken from the URL.
+func SetInstrument(c *gin.Context) {
+	if _, ok := getInstrumentPairParam(c); !ok {
+		return
+	}
+
+	var instrument ProviderConfig.Instrument
+	if err := c.BindJSON(&instrument); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	instrument.Base = c.Param("base")
+	instrument.Quote = c.Param("quote")
+
+	if err := instrument.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := ProviderConfig.SetInstrument(&instrument); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, instrument)
+}
+
+// DeleteInstrument removes an instrument.
+func DeleteInstrument(c *gin.Context) {
+	pairName, ok := getInstrumentPairParam(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := ProviderConfig.DeleteInstrument(pairName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("instrument %s is not registered", pairName)})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func getInstrumentPairParam(c *gin.Context) (string, bool) {
+	base := c.Param("base")
+	quote := c.Param("quote")
+	if base == "" || quote == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty base or quote param"})
+		return "", false
+	}
+	return fmt.Sprintf("%s/%s", base, quote), true
+}
@@ -0,0 +1,78 @@
+package ProviderConfigAPI
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentEndpoints(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestInstrumentEndpoints")
+	if tempErr != nil {
+		t.Errorf("Error creating temporary file: %v", tempErr)
+		return
+	}
+	err := ProviderConfig.OpenDB(tmpDBFileName.Name())
+	if err != nil {
+		t.Errorf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	router := gin.Default()
+	router.GET("/instruments", GetInstruments)
+	router.GET("/instruments/:base/:quote", GetInstrument)
+	router.PUT("/instruments/:base/:quote", SetInstrument)
+	router.DELETE("/instruments/:base/:quote", DeleteInstrument)
+
+	serve := func(method string, path string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Create
+	rr := serve("PUT", "/instruments/XRP/USD", `{"price_precision":5,"tick_size":"0.00001","lot_size":"1","min_amount":"10","max_amount":"0","active":true}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Invalid settings are rejected
+	rr = serve("PUT", "/instruments/XRP/EUR", `{"price_precision":2,"tick_size":"0.001","active":true}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Read
+	rr = serve("GET", "/instruments/XRP/USD", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var instrument ProviderConfig.Instrument
+	if err := json.Unmarshal(rr.Body.Bytes(), &instrument); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "XRP", instrument.Base)
+	assert.Equal(t, int32(5), instrument.PricePrecision)
+	assert.Equal(t, "0.00001", instrument.TickSize.String())
+
+	rr = serve("GET", "/instruments", "")
+	var instruments map[string]*ProviderConfig.Instrument
+	if err := json.Unmarshal(rr.Body.Bytes(), &instruments); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(instruments))
+
+	// Delete
+	rr = serve("DELETE", "/instruments/XRP/USD", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serve("GET", "/instruments/XRP/USD", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = serve("DELETE", "/instruments/XRP/USD", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,31 @@
+package ProviderConfigAPI
+
+import (
+	"time"
+
+	"github.com/hongkongkiwi/chaostheory/src/Metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	recalculations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "providerconfigapi_recalculate_prices_total",
+		Help: "Calls asking the PriceAPI to recalculate best prices by outcome.",
+	}, []string{"outcome"})
+	recalculationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "providerconfigapi_recalculate_prices_duration_seconds",
+		Help:    "Latency of calls asking the PriceAPI to recalculate best prices by outcome.",
+		Buckets: Metrics.DefaultBuckets,
+	}, []string{"outcome"})
+)
+
+// recordRecalculation counts a recalculatePrices call as a success or an error.
+func recordRecalculation(start time.Time, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	recalculations.WithLabelValues(outcome).Inc()
+	recalculationDuration.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
+}
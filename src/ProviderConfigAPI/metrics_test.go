@@ -0,0 +1,34 @@
+package ProviderConfigAPI
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hongkongkiwi/chaostheory/src/Metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecalculatePricesMetrics(t *testing.T) {
+	status := http.StatusOK
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer mockServer.Close()
+	previousURLBase := PriceAPIURLBase
+	PriceAPIURLBase = mockServer.URL
+	defer func() { PriceAPIURLBase = previousURLBase }()
+
+	successes := testutil.ToFloat64(recalculations.WithLabelValues("success"))
+	errors := testutil.ToFloat64(recalculations.WithLabelValues("error"))
+
+	assert.NoError(t, recalculatePrices(context.Background()))
+	status = http.StatusInternalServerError
+	assert.Error(t, recalculatePrices(context.Background()))
+
+	assert.Equal(t, successes+1, testutil.ToFloat64(recalculations.WithLabelValues("success")))
+	assert.Equal(t, errors+1, testutil.ToFloat64(recalculations.WithLabelValues("error")))
+	assert.Less(t, uint64(0), Metrics.HistogramCount(recalculationDuration, "error"))
+}
@@ -0,0 +1,45 @@
+package ProviderConfigAPI
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+)
+
+// GetOutlierFilterConfigs retrieves the default and per pair outlier filter settings.
+func GetOutlierFilterConfigs(c *gin.Context) {
+	configs, err := ProviderConfig.GetOutlierFilterConfigs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, configs)
+}
+
+// SetOutlierFilterConfigs changes the default and/or per pair outlier filter settings,
+// a null pair setting removes the override. The PriceAPI picks them up within a second.
+func SetOutlierFilterConfigs(c *gin.Context) {
+	var req ProviderConfig.OutlierFilterConfigs
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := ProviderConfig.SetOutlierFilterConfigs(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	configs, err := ProviderConfig.GetOutlierFilterConfigs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, configs)
+}
@@ -0,0 +1,75 @@
+package ProviderConfigAPI
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutlierFilterEndpoints(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestOutlierFilterEndpoints")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	if err := ProviderConfig.OpenDB(tmpDBFileName.Name()); err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+
+	router := gin.Default()
+	router.GET("/outliers/config", GetOutlierFilterConfigs)
+	router.PUT("/outliers/config", SetOutlierFilterConfigs)
+
+	serve := func(method string, path string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	decode := func(rr *httptest.ResponseRecorder) *ProviderConfig.OutlierFilterConfigs {
+		var configs ProviderConfig.OutlierFilterConfigs
+		if err := json.Unmarshal(rr.Body.Bytes(), &configs); err != nil {
+			t.Fatal(err)
+		}
+		return &configs
+	}
+
+	// The default is on with a 500 bps band around the median
+	rr := serve("GET", "/outliers/config", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	configs := decode(rr)
+	assert.True(t, configs.Default.Enabled)
+	assert.Equal(t, ProviderConfig.MedianReference, configs.Default.Reference)
+	assert.Equal(t, "500", configs.Default.MaxDeviationBps.String())
+
+	rr = serve("PUT", "/outliers/config", `{"pairs":{"BTC/USD":{"enabled":true,"reference":"bogus","min_providers":1}}}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "invalid settings for BTC/USD")
+
+	rr = serve("PUT", "/outliers/config", `{"default":{"enabled":false,"reference":"median","max_deviation_bps":300,"max_spread_bps":1000,"min_providers":2},"pairs":{"BTC/USD":{"enabled":true,"reference":"mid","max_deviation_bps":100,"min_providers":1}}}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	configs = decode(serve("GET", "/outliers/config", ""))
+	assert.False(t, configs.Default.Enabled)
+	assert.Equal(t, "300", configs.Default.MaxDeviationBps.String())
+	if assert.Contains(t, configs.Pairs, "BTC/USD") {
+		assert.Equal(t, ProviderConfig.ConsolidatedMidReference, configs.Pairs["BTC/USD"].Reference)
+	}
+
+	// A null pair removes its override and leaves the default alone
+	rr = serve("PUT", "/outliers/config", `{"pairs":{"BTC/USD":null}}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	configs = decode(rr)
+	assert.Empty(t, configs.Pairs)
+	assert.False(t, configs.Default.Enabled)
+}
@@ -1,14 +1,16 @@
 package ProviderConfigAPI
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/parnurzeal/gorequest"
-
 	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/hongkongkiwi/chaostheory/src/Tracing"
+	"github.com/parnurzeal/gorequest"
 )
 
 var PriceAPIURLBase = "http://localhost:8080"
@@ -41,7 +43,25 @@ func SetPairsForProvider(c *gin.Context) {
 
 	changedPairs := make(map[string]bool)
 	for _, changedPair := range req.Pairs {
-		changedPairs[changedPair.Base+"/"+changedPair.Quote] = changedPair.Enabled
+		pairName := changedPair.Base + "/" + changedPair.Quote
+		changedPairs[pairName] = changedPair.Enabled
+		if !changedPair.Enabled {
+			continue
+		}
+		// Only registered, active instruments can be enabled
+		instrument, err := ProviderConfig.GetInstrument(pairName)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if instrument == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("instrument %s is not registered", pairName)})
+			return
+		}
+		if !instrument.Active {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("instrument %s is not active", pairName)})
+			return
+		}
 	}
 
 	// Update our internal store
@@ -52,7 +72,7 @@ func SetPairsForProvider(c *gin.Context) {
 	}
 
 	// Make a REST client call to /prices/recalculate
-	if err := recalculatePrices(); err != nil {
+	if err := recalculatePrices(c.Request.Context()); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -96,14 +116,23 @@ func GetProviders(c *gin.Context) {
 	c.JSON(http.StatusOK, allProviders)
 }
 
-// recalculatePrices makes a PUT to /prices/recalculate
-func recalculatePrices() error {
+// recalculatePrices makes a PUT to /prices/recalculate, continuing the trace in the
+// context, and records the outcome.
+func recalculatePrices(ctx context.Context) error {
+	start := time.Now()
+	err := requestRecalculatePrices(ctx)
+	recordRecalculation(start, err)
+	return err
+}
+
+func requestRecalculatePrices(ctx context.Context) error {
 	// Create a new gorequest instance
-	request := gorequest.New()
+	request := gorequest.New().Put(fmt.Sprintf("%s/prices/recalculate", PriceAPIURLBase))
 
 	// Send a PUT request with the scheme and URL specified
-	resp, _, errs := request.Put(fmt.Sprintf("%s/prices/recalculate", PriceAPIURLBase)).
-		End()
+	_, span := Tracing.StartClient(ctx, request)
+	resp, _, errs := request.End()
+	Tracing.EndClient(span, resp, errs)
 
 	// Check for errors
 	if len(errs) > 0 {
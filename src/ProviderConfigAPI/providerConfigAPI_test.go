@@ -8,11 +8,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hongkongkiwi/chaostheory/src/Helpers"
 	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/hongkongkiwi/chaostheory/src/Tracing"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -50,6 +52,11 @@ func TestSetPairsForProvider(t *testing.T) {
 		{Base: "XRP", Quote: "USD", Enabled: false},
 	}
 
+	// Only registered instruments can be enabled
+	for _, testPair := range testPairs {
+		ProviderConfig.SetInstrument(ProviderConfig.NewDefaultInstrument(testPair.Base, testPair.Quote))
+	}
+
 	// Create test data
 	providerName := "DragonFlyExchange"
 
@@ -92,6 +99,16 @@ func TestSetPairsForProvider(t *testing.T) {
 
 	// Check the response status code for setting pairs
 	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Enabling a pair without an instrument is refused
+	reqBody, _ = json.Marshal(ProviderPairEnableRequest{Pairs: []*CurrencyPairs{{Base: "BTC", Quote: "NZD", Enabled: true}}})
+	req, _ = http.NewRequest("PUT", "/providers/"+providerName, bytes.NewBuffer(reqBody))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	enabled, _ := ProviderConfig.GetProviderPairEnabled(providerName, "BTC/NZD")
+	assert.False(t, enabled)
 }
 
 func conveertTestPairsToString(testPairs []*CurrencyPairs) string {
@@ -220,3 +237,30 @@ func TestGetProviders(t *testing.T) {
 	// Compare the retrieved providers with the expected ones
 	assert.Equal(t, expectedProviders, actualProviders)
 }
+
+func TestRecalculatePricesPropagatesTrace(t *testing.T) {
+	var traceparent string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer mockServer.Close()
+	previousURLBase := PriceAPIURLBase
+	PriceAPIURLBase = mockServer.URL
+	defer func() { PriceAPIURLBase = previousURLBase }()
+
+	// The trace a config change arrived with is continued by the PriceAPI
+	router := gin.New()
+	router.Use(Tracing.Middleware)
+	router.GET("/recalculate", func(c *gin.Context) {
+		if err := recalculatePrices(c.Request.Context()); err != nil {
+			c.Status(http.StatusInternalServerError)
+		}
+	})
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/recalculate", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, strings.HasPrefix(traceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-"), traceparent)
+}
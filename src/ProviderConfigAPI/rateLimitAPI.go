@@ -0,0 +1,101 @@
+package ProviderConfigAPI
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+)
+
+// SetRateLimitRequest is a limit in updates per second, e.g. {"rate":100,"burst":200}.
+type SetRateLimitRequest struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// GetRateLimits lists a provider's rate limits.
+func GetRateLimits(c *gin.Context) {
+	providerName, ok := getExistingProviderParam(c)
+	if !ok {
+		return
+	}
+
+	limits, err := ProviderConfig.GetRateLimits(providerName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, limits)
+}
+
+// SetRateLimit sets a provider's rate limit across all of its pairs, or for the pair in
+// the URL.
+func SetRateLimit(c *gin.Context) {
+	providerName, ok := getExistingProviderParam(c)
+	if !ok {
+		return
+	}
+
+	var req SetRateLimitRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit := &ProviderConfig.RateLimit{
+		Provider: providerName,
+		Pair:     getRateLimitPairParam(c),
+		Rate:     req.Rate,
+		Burst:    req.Burst,
+	}
+	if err := limit.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := ProviderConfig.SetRateLimit(limit); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, limit)
+}
+
+// DeleteRateLimit removes a provider's rate limit across all of its pairs, or for the
+// pair in the URL.
+func DeleteRateLimit(c *gin.Context) {
+	providerName := c.Param("providerName")
+	if providerName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty provider param"})
+		return
+	}
+
+	pairName := getRateLimitPairParam(c)
+	deleted, err := ProviderConfig.DeleteRateLimit(providerName, pairName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("%s has no rate limit for %s", providerName, describeRateLimitPair(pairName))})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// getRateLimitPairParam returns the pair from the URL, or an empty name for routes
+// without one.
+func getRateLimitPairParam(c *gin.Context) string {
+	base, quote := c.Param("base"), c.Param("quote")
+	if base == "" || quote == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s", base, quote)
+}
+
+func describeRateLimitPair(pairName string) string {
+	if pairName == "" {
+		return "all pairs"
+	}
+	return pairName
+}
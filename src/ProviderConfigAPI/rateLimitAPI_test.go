@@ -0,0 +1,84 @@
+package ProviderConfigAPI
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/hongkongkiwi/chaostheory/src/ProviderConfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitEndpoints(t *testing.T) {
+	tmpDBFileName, tempErr := Helpers.CreateTempFile("TestRateLimitEndpoints")
+	if tempErr != nil {
+		t.Errorf("Error creating temporary file: %v", tempErr)
+		return
+	}
+	err := ProviderConfig.OpenDB(tmpDBFileName.Name())
+	if err != nil {
+		t.Errorf("Error opening database: %v", err)
+	}
+	defer func() {
+		ProviderConfig.CloseDB()
+		os.Remove(tmpDBFileName.Name())
+	}()
+	if err := ProviderConfig.SetProvider(&ProviderConfig.Provider{Name: "ProviderA", Pairs: map[string]bool{"BTC/USD": true}}); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.Default()
+	router.GET("/providers/:providerName/limits", GetRateLimits)
+	router.PUT("/providers/:providerName/limits", SetRateLimit)
+	router.DELETE("/providers/:providerName/limits", DeleteRateLimit)
+	router.PUT("/providers/:providerName/limits/:base/:quote", SetRateLimit)
+	router.DELETE("/providers/:providerName/limits/:base/:quote", DeleteRateLimit)
+
+	serve := func(method string, path string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Set
+	rr := serve("PUT", "/providers/ProviderA/limits", `{"rate":100,"burst":200}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serve("PUT", "/providers/ProviderA/limits/BTC/USD", `{"rate":10}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var limit ProviderConfig.RateLimit
+	if err := json.Unmarshal(rr.Body.Bytes(), &limit); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ProviderConfig.RateLimit{Provider: "ProviderA", Pair: "BTC/USD", Rate: 10, Burst: 10}, limit)
+
+	// Invalid limits and unknown providers are rejected
+	rr = serve("PUT", "/providers/ProviderA/limits", `{"rate":0}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = serve("PUT", "/providers/Unknown/limits", `{"rate":1}`)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// List
+	rr = serve("GET", "/providers/ProviderA/limits", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var limits []*ProviderConfig.RateLimit
+	if err := json.Unmarshal(rr.Body.Bytes(), &limits); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Equal(t, 2, len(limits)) {
+		assert.Equal(t, ProviderConfig.RateLimit{Provider: "ProviderA", Rate: 100, Burst: 200}, *limits[0])
+	}
+
+	// Delete
+	rr = serve("DELETE", "/providers/ProviderA/limits/BTC/USD", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serve("DELETE", "/providers/ProviderA/limits/BTC/USD", "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = serve("DELETE", "/providers/ProviderA/limits", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
@@ -0,0 +1,185 @@
+// Package Tracing propagates W3C trace context between the simulator, the PriceAPI and
+// the ProviderConfigAPI and exports their spans to an OTLP collector or a file.
+package Tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/parnurzeal/gorequest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporters that can be passed to Setup
+const (
+	// Spans are only propagated, not recorded
+	NoExporter = ""
+	// Spans are sent to an OTLP/HTTP collector, localhost:4318 unless the standard
+	// OTEL_EXPORTER_OTLP_ENDPOINT variable is set
+	OTLPExporter = "otlp"
+	// Spans are appended to a file as JSON lines
+	FileExporter = "file"
+)
+
+const instrumentationName = "github.com/hongkongkiwi/chaostheory"
+
+// Only the W3C traceparent and tracestate headers are used
+var propagator = propagation.TraceContext{}
+
+// Setup records spans for the service with the given exporter, returning a function that
+// flushes and stops the exporter.
+func Setup(serviceName string, exporterName string, fileName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagator)
+	if exporterName == NoExporter {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(exporterName, fileName)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return nil, err
+	}
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tracerProvider)
+	return tracerProvider.Shutdown, nil
+}
+
+// SetupFromEnv calls Setup with the exporter in TRACE_EXPORTER and, for the file exporter,
+// the file in TRACE_FILE or the default file.
+func SetupFromEnv(serviceName string, defaultFileName string) (func(context.Context) error, error) {
+	exporterName := os.Getenv("TRACE_EXPORTER")
+	fileName := os.Getenv("TRACE_FILE")
+	if fileName == "" {
+		fileName = defaultFileName
+	}
+	if exporterName == FileExporter {
+		if err := Helpers.CreateDirIfNotExist(filepath.Dir(fileName)); err != nil {
+			return nil, err
+		}
+	}
+	return Setup(serviceName, exporterName, fileName)
+}
+
+func newExporter(exporterName string, fileName string) (sdktrace.SpanExporter, error) {
+	switch exporterName {
+	case OTLPExporter:
+		options := []otlptracehttp.Option{}
+		// A local collector doesn't use TLS unless an endpoint says otherwise
+		if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(context.Background(), options...)
+	case FileExporter:
+		if fileName == "" {
+			return nil, fmt.Errorf("a file is needed for the %s exporter", FileExporter)
+		}
+		file, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		return stdouttrace.New(stdouttrace.WithWriter(file))
+	}
+	return nil, fmt.Errorf("unknown trace exporter %q, use %q or %q", exporterName, OTLPExporter, FileExporter)
+}
+
+// Start starts a span as a child of any span in the context.
+func Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, options...)
+}
+
+// ContextWithSpanContext returns a background context continuing a span that was saved to
+// be picked up by another goroutine.
+func ContextWithSpanContext(spanContext trace.SpanContext) context.Context {
+	return trace.ContextWithSpanContext(context.Background(), spanContext)
+}
+
+// RecordError marks a span as failed.
+func RecordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// Middleware continues the trace from the request's traceparent header, or starts a new
+// one, in a server span named after the route. Handlers get it from c.Request.Context().
+func Middleware(c *gin.Context) {
+	ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	ctx, span := Start(ctx, c.Request.Method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", c.Request.URL.Path),
+		))
+	defer span.End()
+
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+
+	status := c.Writer.Status()
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+	if len(c.Errors) > 0 {
+		span.SetStatus(codes.Error, c.Errors.String())
+	}
+}
+
+// Inject adds the traceparent header for the span in the context to an outgoing request.
+// It must be called after the method is set as that clears the headers.
+func Inject(ctx context.Context, request *gorequest.SuperAgent) *gorequest.SuperAgent {
+	carrier := propagation.HeaderCarrier(http.Header{})
+	propagator.Inject(ctx, carrier)
+	for _, key := range carrier.Keys() {
+		request.Set(key, carrier.Get(key))
+	}
+	return request
+}
+
+// StartClient starts a client span for an outgoing request and adds its traceparent header.
+func StartClient(ctx context.Context, request *gorequest.SuperAgent) (context.Context, trace.Span) {
+	ctx, span := Start(ctx, request.Method+" "+request.Url,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", request.Method),
+			attribute.String("url.full", request.Url),
+		))
+	Inject(ctx, request)
+	return ctx, span
+}
+
+// EndClient records the response status, or the request errors, and ends a client span.
+func EndClient(span trace.Span, resp gorequest.Response, errs []error) {
+	if len(errs) > 0 {
+		RecordError(span, errs[0])
+	}
+	if resp != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if resp.StatusCode >= http.StatusBadRequest {
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		}
+	}
+	span.End()
+}
@@ -0,0 +1,99 @@
+package Tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hongkongkiwi/chaostheory/src/Helpers"
+	"github.com/parnurzeal/gorequest"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const incomingTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+func TestPropagation(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(tracerProvider)
+	defer tracerProvider.Shutdown(context.Background())
+
+	// A downstream service that records the traceparent it was sent
+	var outgoingTraceparent string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outgoingTraceparent = r.Header.Get("traceparent")
+	}))
+	defer downstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware)
+	router.PUT("/things/:id", func(c *gin.Context) {
+		request := gorequest.New().Put(downstream.URL)
+		_, span := StartClient(c.Request.Context(), request)
+		resp, _, errs := request.End()
+		EndClient(span, resp, errs)
+		c.Status(http.StatusAccepted)
+	})
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/things/1", nil)
+	req.Header.Set("traceparent", "00-"+incomingTraceID+"-00f067aa0ba902b7-01")
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+
+	// The server and client spans continue the incoming trace
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	names := make(map[string]tracetest.SpanStub)
+	for _, span := range spans {
+		assert.Equal(t, incomingTraceID, span.SpanContext.TraceID().String())
+		names[span.Name] = span
+	}
+	server, ok := names["PUT /things/:id"]
+	assert.True(t, ok)
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+	client, ok := names["PUT "+downstream.URL]
+	assert.True(t, ok)
+	assert.Equal(t, server.SpanContext.SpanID(), client.Parent.SpanID())
+
+	// The downstream service is sent the client span as its parent
+	assert.Equal(t, "00-"+incomingTraceID+"-"+client.SpanContext.SpanID().String()+"-01", outgoingTraceparent)
+}
+
+func TestSetupFileExporter(t *testing.T) {
+	tmpFile, tempErr := Helpers.CreateTempFile("TestSetupFileExporter")
+	if tempErr != nil {
+		t.Fatalf("Error creating temporary file: %v", tempErr)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err := Setup("TestService", "zipkin", tmpFile.Name())
+	assert.Error(t, err)
+	_, err = Setup("TestService", FileExporter, "")
+	assert.Error(t, err)
+
+	shutdown, err := Setup("TestService", FileExporter, tmpFile.Name())
+	assert.NoError(t, err)
+	_, span := Start(context.Background(), "TestSpan")
+	span.End()
+	assert.NoError(t, shutdown(context.Background()))
+
+	// Spans are written as JSON lines with the service name
+	data, err := os.ReadFile(tmpFile.Name())
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 1)
+	assert.Contains(t, lines[0], `"Name":"TestSpan"`)
+	assert.Contains(t, lines[0], span.SpanContext().TraceID().String())
+	assert.Contains(t, lines[0], "TestService")
+}